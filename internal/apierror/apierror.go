@@ -0,0 +1,139 @@
+// Package apierror 定义统一的 API 错误响应：稳定的错误码、按 Accept-Language 本地化的文案、
+// 请求 ID 与可选的字段级详情。所有处理器与中间件通过 Respond 返回错误。
+package apierror
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"main/internal/requestid"
+)
+
+// Error 统一的 API 错误，Details 为可选的字段级错误，
+// Message 非空时替代错误码的默认文案（如管理员填写的维护说明）
+type Error struct {
+	Code    Code
+	Message string
+	Details []FieldError
+}
+
+// New 创建 API 错误
+func New(code Code, details ...FieldError) *Error {
+	return &Error{Code: code, Details: details}
+}
+
+func (e *Error) Error() string {
+	return "apierror: " + string(e.Code)
+}
+
+// FieldError 字段级错误，Message 在写入响应时按请求语言填充
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    Code   `json:"code"`
+	Message string `json:"message"`
+}
+
+// Field 创建字段级错误
+func Field(name string, code Code) FieldError {
+	return FieldError{Field: name, Code: code}
+}
+
+// Body 错误响应内容
+type Body struct {
+	Code      Code         `json:"code"`
+	Message   string       `json:"message"`
+	RequestID string       `json:"request_id,omitempty"`
+	Details   []FieldError `json:"details,omitempty"`
+}
+
+// Response 错误响应体，形如 {"error": {"code": ..., "message": ..., "request_id": ...}}
+type Response struct {
+	Error Body `json:"error"`
+}
+
+// Respond 写入 code 对应状态码的错误响应并中止后续处理器
+func Respond(c *gin.Context, code Code, details ...FieldError) {
+	Write(c, New(code, details...))
+}
+
+// Write 写入 err 的错误响应并中止后续处理器，非 *Error 的错误按服务器内部错误处理
+func Write(c *gin.Context, err error) {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		apiErr = New(CodeInternal)
+	}
+
+	lang := negotiateResponse(c)
+	body := Body{
+		Code:      apiErr.Code,
+		Message:   apiErr.Code.Message(lang),
+		RequestID: requestid.Get(c),
+	}
+	if apiErr.Message != "" {
+		body.Message = apiErr.Message
+	}
+	for _, detail := range apiErr.Details {
+		detail.Message = detail.Code.Message(lang)
+		body.Details = append(body.Details, detail)
+	}
+
+	c.AbortWithStatusJSON(apiErr.Code.Status(), Response{Error: body})
+}
+
+// negotiateResponse 协商响应语言，并写入 Content-Language 与 Vary 以免缓存混用不同语言的响应
+func negotiateResponse(c *gin.Context) Lang {
+	lang := Negotiate(c.GetHeader("Accept-Language"))
+	c.Header("Content-Language", string(lang))
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	return lang
+}
+
+// RespondBindError 将请求体解析失败转换为 invalid_request，字段校验失败时附带对应的字段详情；
+// 未声明长度的请求体读取超过 BodyLimit 时转换为 body_too_large
+func RespondBindError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		Respond(c, CodeBodyTooLarge)
+		return
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		Respond(c, CodeInvalidRequest)
+		return
+	}
+
+	details := make([]FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		code := CodeFieldInvalid
+		if fe.Tag() == "required" {
+			code = CodeFieldRequired
+		}
+		details = append(details, Field(fe.Field(), code))
+	}
+	Respond(c, CodeInvalidRequest, details...)
+}
+
+func init() {
+	// 校验错误中的字段名使用 JSON 字段名，与请求体保持一致
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(jsonFieldName)
+	}
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
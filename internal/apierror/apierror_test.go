@@ -0,0 +1,158 @@
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/requestid"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := map[string]Lang{
+		"":                     LangZH,
+		"zh-CN,zh;q=0.9":       LangZH,
+		"en-US,en;q=0.9":       LangEN,
+		"fr-FR":                LangZH,
+		"fr-FR,en;q=0.5":       LangEN,
+		"zh-TW;q=0.8,en;q=0.9": LangEN,
+		"not a language tag!!": LangZH,
+	}
+	for header, want := range cases {
+		if got := Negotiate(header); got != want {
+			t.Errorf("Negotiate(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestEveryCodeHasMessages(t *testing.T) {
+	for _, code := range Codes() {
+		def := registry[code]
+		if def.status < http.StatusBadRequest || def.zh == "" || def.en == "" {
+			t.Errorf("code %q has incomplete definition: %+v", code, def)
+		}
+	}
+}
+
+func TestLocalizeNegotiatesNotice(t *testing.T) {
+	for n, messages := range notices {
+		if messages[0] == "" || messages[1] == "" {
+			t.Errorf("notice %q has incomplete messages: %q", n, messages)
+		}
+	}
+
+	var message string
+	handler := func(c *gin.Context) {
+		message = Localize(c, NoticeLoggedOut)
+		c.Status(http.StatusOK)
+	}
+	w := serve(handler, "en-US", "")
+	if message != "Signed out" || w.Header().Get("Content-Language") != "en" || w.Header().Get("Vary") != "Accept-Language" {
+		t.Fatalf("expected English notice, got %q (headers %v)", message, w.Header())
+	}
+	if serve(handler, "", ""); message != "登出成功" {
+		t.Fatalf("expected Chinese notice by default, got %q", message)
+	}
+}
+
+func serve(handler gin.HandlerFunc, acceptLanguage, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(requestid.Middleware())
+	r.POST("/", handler)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(requestid.HeaderName, "req-1")
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder) Body {
+	t.Helper()
+	var resp Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid error body %q: %v", w.Body.String(), err)
+	}
+	return resp.Error
+}
+
+func TestRespondWritesLocalizedEnvelope(t *testing.T) {
+	handler := func(c *gin.Context) { Respond(c, CodeUserNotFound) }
+
+	w := serve(handler, "en-US", "")
+	body := decode(t, w)
+	if w.Code != http.StatusNotFound || body.Code != CodeUserNotFound || body.RequestID != "req-1" {
+		t.Fatalf("unexpected response %d %+v", w.Code, body)
+	}
+	if body.Message != "User not found" || w.Header().Get("Content-Language") != "en" {
+		t.Fatalf("expected English message, got %q (Content-Language %q)", body.Message, w.Header().Get("Content-Language"))
+	}
+	if w.Header().Get("Vary") != "Accept-Language" {
+		t.Fatalf("expected Vary: Accept-Language, got %q", w.Header().Get("Vary"))
+	}
+
+	body = decode(t, serve(handler, "", ""))
+	if body.Message != "用户不存在" {
+		t.Fatalf("expected Chinese message by default, got %q", body.Message)
+	}
+}
+
+func TestWriteTreatsUnknownErrorsAsInternal(t *testing.T) {
+	w := serve(func(c *gin.Context) { Write(c, http.ErrAbortHandler) }, "", "")
+	if body := decode(t, w); w.Code != http.StatusInternalServerError || body.Code != CodeInternal {
+		t.Fatalf("expected internal_error, got %d %+v", w.Code, body)
+	}
+}
+
+func TestRespondBindErrorReportsFields(t *testing.T) {
+	type request struct {
+		Username string `json:"username" binding:"required"`
+		Email    string `json:"email" binding:"omitempty,email"`
+	}
+	handler := func(c *gin.Context) {
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			RespondBindError(c, err)
+		}
+	}
+
+	w := serve(handler, "en", `{"email":"nope"}`)
+	body := decode(t, w)
+	if w.Code != http.StatusBadRequest || body.Code != CodeInvalidRequest {
+		t.Fatalf("expected invalid_request, got %d %+v", w.Code, body)
+	}
+	want := []FieldError{
+		{Field: "username", Code: CodeFieldRequired, Message: "is required"},
+		{Field: "email", Code: CodeFieldInvalid, Message: "is invalid"},
+	}
+	if len(body.Details) != len(want) {
+		t.Fatalf("expected %d details, got %+v", len(want), body.Details)
+	}
+	for i := range want {
+		if body.Details[i] != want[i] {
+			t.Fatalf("detail %d = %+v, want %+v", i, body.Details[i], want[i])
+		}
+	}
+
+	body = decode(t, serve(handler, "", `{not json`))
+	if body.Code != CodeInvalidRequest || len(body.Details) != 0 {
+		t.Fatalf("expected invalid_request without details for malformed JSON, got %+v", body)
+	}
+}
+
+func TestWriteUsesMessageOverride(t *testing.T) {
+	w := serve(func(c *gin.Context) { Write(c, &Error{Code: CodeMaintenance, Message: "数据迁移中"}) }, "en", "")
+	body := decode(t, w)
+	if w.Code != http.StatusServiceUnavailable || body.Code != CodeMaintenance || body.Message != "数据迁移中" {
+		t.Fatalf("expected overridden maintenance message, got %d %+v", w.Code, body)
+	}
+}
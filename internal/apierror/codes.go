@@ -0,0 +1,163 @@
+package apierror
+
+import (
+	"net/http"
+	"slices"
+)
+
+// Code 稳定的机器可读错误码，前端据此分支处理，不依赖提示文案。
+// 已发布的错误码只增不改，文案可以调整
+type Code string
+
+// 通用错误
+const (
+	CodeInvalidRequest     Code = "invalid_request"
+	CodeNotFound           Code = "not_found"
+	CodeInternal           Code = "internal_error"
+	CodeRateLimited        Code = "rate_limited"
+	CodeBodyTooLarge       Code = "body_too_large"
+	CodeCSRFFailed         Code = "csrf_failed"
+	CodeCORSOriginRejected Code = "cors_origin_rejected"
+)
+
+// 认证与授权
+const (
+	CodeUnauthorized        Code = "unauthorized"
+	CodeInvalidCredentials  Code = "invalid_credentials"
+	CodeAccountDisabled     Code = "account_disabled"
+	CodeMFAExpired          Code = "mfa_expired"
+	CodeInvalidMFACode      Code = "invalid_mfa_code"
+	CodeForbidden           Code = "forbidden"
+	CodeInvalidAccessToken  Code = "invalid_access_token"
+	CodeInsufficientScope   Code = "insufficient_scope"
+	CodeCertificateUnmapped Code = "certificate_unmapped"
+	CodeOIDCDisabled        Code = "oidc_disabled"
+)
+
+// 用户与两步验证
+const (
+	CodeInvalidUserID      Code = "invalid_user_id"
+	CodeUserNotFound       Code = "user_not_found"
+	CodeUsernameTaken      Code = "username_taken"
+	CodeInvalidUsername    Code = "invalid_username"
+	CodeInvalidRole        Code = "invalid_role"
+	CodeInvalidPassword    Code = "invalid_password"
+	CodeLastAdmin          Code = "last_admin"
+	CodeCannotDisableSelf  Code = "cannot_disable_self"
+	CodeCannotDeleteSelf   Code = "cannot_delete_self"
+	CodeTOTPAlreadyEnabled Code = "totp_already_enabled"
+	CodeTOTPNotEnrolled    Code = "totp_not_enrolled"
+	CodeInvalidTOTPCode    Code = "invalid_totp_code"
+)
+
+// 访问令牌
+const (
+	CodeInvalidTokenID   Code = "invalid_token_id"
+	CodeTokenNotFound    Code = "token_not_found"
+	CodeInvalidTokenName Code = "invalid_token_name"
+	CodeInvalidScope     Code = "invalid_scope"
+	CodeScopeForbidden   Code = "scope_forbidden"
+	CodeInvalidExpiry    Code = "invalid_expiry"
+)
+
+// 审计与系统
+const (
+	CodeInvalidTime            Code = "invalid_time"
+	CodeInvalidLimit           Code = "invalid_limit"
+	CodeInvalidCursor          Code = "invalid_cursor"
+	CodeSystemStatsUnavailable Code = "system_stats_unavailable"
+	CodeMaintenance            Code = "maintenance"
+)
+
+// 字段级错误，只出现在 details 中
+const (
+	CodeFieldRequired Code = "required"
+	CodeFieldInvalid  Code = "invalid"
+)
+
+type definition struct {
+	status int
+	zh     string
+	en     string
+}
+
+// registry 错误码登记表：HTTP 状态码与各语言文案
+var registry = map[Code]definition{
+	CodeInvalidRequest:     {http.StatusBadRequest, "请求格式错误", "Malformed request"},
+	CodeNotFound:           {http.StatusNotFound, "接口不存在", "Endpoint not found"},
+	CodeInternal:           {http.StatusInternalServerError, "服务器内部错误", "Internal server error"},
+	CodeRateLimited:        {http.StatusTooManyRequests, "请求过于频繁，请稍后再试", "Too many requests, please try again later"},
+	CodeBodyTooLarge:       {http.StatusRequestEntityTooLarge, "请求体过大", "Request body too large"},
+	CodeCSRFFailed:         {http.StatusForbidden, "CSRF 校验失败，请刷新页面后重试", "CSRF check failed, please reload the page and try again"},
+	CodeCORSOriginRejected: {http.StatusForbidden, "不允许的跨域来源", "Cross-origin request from this origin is not allowed"},
+
+	CodeUnauthorized:        {http.StatusUnauthorized, "未授权，请先登录", "Unauthorized, please sign in"},
+	CodeInvalidCredentials:  {http.StatusUnauthorized, "认证失败，请检查用户名和密码是否正确", "Authentication failed, please check your username and password"},
+	CodeAccountDisabled:     {http.StatusForbidden, "账号已被禁用", "Account is disabled"},
+	CodeMFAExpired:          {http.StatusUnauthorized, "两步验证已过期，请重新登录", "Two-factor verification expired, please sign in again"},
+	CodeInvalidMFACode:      {http.StatusUnauthorized, "验证码错误", "Invalid verification code"},
+	CodeForbidden:           {http.StatusForbidden, "权限不足", "Permission denied"},
+	CodeInvalidAccessToken:  {http.StatusUnauthorized, "访问令牌无效或已过期", "Access token is invalid or expired"},
+	CodeInsufficientScope:   {http.StatusForbidden, "访问令牌缺少所需权限", "Access token lacks the required scope"},
+	CodeCertificateUnmapped: {http.StatusUnauthorized, "客户端证书未关联可用账号", "Client certificate is not mapped to an active account"},
+	CodeOIDCDisabled:        {http.StatusNotFound, "未启用 OIDC 登录", "OIDC sign-in is not enabled"},
+
+	CodeInvalidUserID:      {http.StatusBadRequest, "无效的用户 ID", "Invalid user ID"},
+	CodeUserNotFound:       {http.StatusNotFound, "用户不存在", "User not found"},
+	CodeUsernameTaken:      {http.StatusConflict, "用户名已存在", "Username already exists"},
+	CodeInvalidUsername:    {http.StatusBadRequest, "用户名只能包含字母、数字和 . _ @ -，长度 1-64", "Username must be 1-64 characters of letters, digits and . _ @ -"},
+	CodeInvalidRole:        {http.StatusBadRequest, "无效的角色", "Invalid role"},
+	CodeInvalidPassword:    {http.StatusBadRequest, "密码长度需为 8-72 字节", "Password must be 8-72 bytes long"},
+	CodeLastAdmin:          {http.StatusConflict, "至少需要保留一个启用的管理员", "At least one active administrator is required"},
+	CodeCannotDisableSelf:  {http.StatusBadRequest, "不能禁用当前登录的账号", "You cannot disable the account you are signed in with"},
+	CodeCannotDeleteSelf:   {http.StatusBadRequest, "不能删除当前登录的账号", "You cannot delete the account you are signed in with"},
+	CodeTOTPAlreadyEnabled: {http.StatusConflict, "两步验证已启用", "Two-factor authentication is already enabled"},
+	CodeTOTPNotEnrolled:    {http.StatusConflict, "尚未开始两步验证注册", "Two-factor enrollment has not been started"},
+	CodeInvalidTOTPCode:    {http.StatusBadRequest, "验证码错误", "Invalid verification code"},
+
+	CodeInvalidTokenID:   {http.StatusBadRequest, "无效的令牌 ID", "Invalid token ID"},
+	CodeTokenNotFound:    {http.StatusNotFound, "令牌不存在", "Token not found"},
+	CodeInvalidTokenName: {http.StatusBadRequest, "令牌名称长度需为 1-64 个字符", "Token name must be 1-64 characters"},
+	CodeInvalidScope:     {http.StatusBadRequest, "无效的权限范围", "Invalid scope"},
+	CodeScopeForbidden:   {http.StatusForbidden, "权限范围超出当前角色", "Scope exceeds your role"},
+	CodeInvalidExpiry:    {http.StatusBadRequest, "有效期需为 0-365 天", "Expiry must be between 0 and 365 days"},
+
+	CodeInvalidTime:            {http.StatusBadRequest, "时间格式错误，需为 RFC 3339 或 Unix 秒", "Invalid time, expected RFC 3339 or Unix seconds"},
+	CodeInvalidLimit:           {http.StatusBadRequest, "无效的 limit", "Invalid limit"},
+	CodeInvalidCursor:          {http.StatusBadRequest, "无效的 cursor", "Invalid cursor"},
+	CodeSystemStatsUnavailable: {http.StatusInternalServerError, "获取内存信息失败", "Failed to read memory statistics"},
+	CodeMaintenance:            {http.StatusServiceUnavailable, "系统维护中，暂时无法修改数据", "The system is under maintenance, changes are temporarily disabled"},
+
+	CodeFieldRequired: {http.StatusBadRequest, "必填", "is required"},
+	CodeFieldInvalid:  {http.StatusBadRequest, "格式不正确", "is invalid"},
+}
+
+// Codes 返回全部已登记的错误码，按字母排序
+func Codes() []Code {
+	codes := make([]Code, 0, len(registry))
+	for code := range registry {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	return codes
+}
+
+// Status 返回错误码对应的 HTTP 状态码，未登记的错误码视为服务器内部错误
+func (c Code) Status() int {
+	if def, ok := registry[c]; ok {
+		return def.status
+	}
+	return http.StatusInternalServerError
+}
+
+// Message 返回错误码在指定语言下的文案
+func (c Code) Message(lang Lang) string {
+	def, ok := registry[c]
+	if !ok {
+		def = registry[CodeInternal]
+	}
+	if lang == LangEN {
+		return def.en
+	}
+	return def.zh
+}
@@ -0,0 +1,30 @@
+package apierror
+
+import "golang.org/x/text/language"
+
+// Lang 错误文案语言
+type Lang string
+
+const (
+	LangZH Lang = "zh-CN"
+	LangEN Lang = "en"
+)
+
+// supported 与 matcher 的顺序一致，第一个为无法匹配时的默认语言
+var (
+	supported = []Lang{LangZH, LangEN}
+	matcher   = language.NewMatcher([]language.Tag{language.SimplifiedChinese, language.English})
+)
+
+// Negotiate 按 Accept-Language 选择文案语言，缺失、无法解析或不支持时使用简体中文
+func Negotiate(acceptLanguage string) Lang {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return LangZH
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return LangZH
+	}
+	return supported[index]
+}
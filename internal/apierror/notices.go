@@ -0,0 +1,28 @@
+package apierror
+
+import "github.com/gin-gonic/gin"
+
+// Notice 成功响应中的提示文案，与错误码共用 Accept-Language 协商
+type Notice string
+
+const (
+	NoticeLoginSucceeded Notice = "login_succeeded"
+	NoticeMFARequired    Notice = "mfa_required"
+	NoticeLoggedOut      Notice = "logged_out"
+)
+
+// notices 提示文案登记表：简体中文与英文
+var notices = map[Notice][2]string{
+	NoticeLoginSucceeded: {"登录成功", "Signed in"},
+	NoticeMFARequired:    {"请输入两步验证码", "Enter your two-factor verification code"},
+	NoticeLoggedOut:      {"登出成功", "Signed out"},
+}
+
+// Localize 按请求的 Accept-Language 返回提示文案，并像错误响应一样声明响应语言
+func Localize(c *gin.Context, n Notice) string {
+	lang := negotiateResponse(c)
+	if lang == LangEN {
+		return notices[n][1]
+	}
+	return notices[n][0]
+}
@@ -0,0 +1,200 @@
+package apitoken
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"main/internal/user"
+)
+
+const tokenColumns = `id, user_id, name, token_prefix, scopes, expires_at, last_used_at, created_at`
+
+// lastUsedResolution last_used_at 的更新粒度，避免每次请求都写库
+const lastUsedResolution = time.Minute
+
+// Store 基于 SQLite 的令牌存储
+type Store struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// NewStore 创建令牌存储
+func NewStore(db *sql.DB) *Store {
+	return &Store{
+		db:  db,
+		now: time.Now,
+	}
+}
+
+// CreateParams 创建令牌参数，ExpiresInDays 为 0 表示永不过期
+type CreateParams struct {
+	Name          string
+	Scopes        []string
+	ExpiresInDays int
+}
+
+// Create 为 owner 创建令牌，返回元数据和仅此一次可见的明文令牌
+func (s *Store) Create(ctx context.Context, owner *user.User, params CreateParams) (*Token, string, error) {
+	name := strings.TrimSpace(params.Name)
+	if err := validateName(name); err != nil {
+		return nil, "", err
+	}
+	scopes, err := ParseScopes(params.Scopes, owner.Role)
+	if err != nil {
+		return nil, "", err
+	}
+	if params.ExpiresInDays < 0 || params.ExpiresInDays > MaxExpiresInDays {
+		return nil, "", ErrInvalidExpiry
+	}
+
+	plaintext, hash, err := generate()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := s.now()
+	var expiresAt *int64
+	if params.ExpiresInDays > 0 {
+		expires := now.AddDate(0, 0, params.ExpiresInDays).Unix()
+		expiresAt = &expires
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO api_tokens(user_id, name, token_hash, token_prefix, scopes, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		owner.ID,
+		name,
+		hash,
+		plaintext[:displayPrefixLen],
+		encodeScopes(scopes),
+		expiresAt,
+		now.Unix(),
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("apitoken: failed to insert token: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, "", fmt.Errorf("apitoken: failed to read inserted id: %w", err)
+	}
+
+	return &Token{
+		ID:        id,
+		UserID:    owner.ID,
+		Name:      name,
+		Prefix:    plaintext[:displayPrefixLen],
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: now.Unix(),
+	}, plaintext, nil
+}
+
+// ListByUser 返回用户的全部令牌，按 ID 升序
+func (s *Store) ListByUser(ctx context.Context, userID int64) ([]Token, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+tokenColumns+` FROM api_tokens WHERE user_id = ? ORDER BY id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("apitoken: failed to query tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := make([]Token, 0)
+	for rows.Next() {
+		t, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("apitoken: failed to iterate tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// Revoke 删除用户自己的令牌，令牌不存在或不属于该用户时返回 ErrNotFound
+func (s *Store) Revoke(ctx context.Context, userID, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM api_tokens WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return fmt.Errorf("apitoken: failed to delete token %d: %w", id, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("apitoken: failed to read affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Authenticate 校验明文令牌，成功时按分钟粒度刷新 last_used_at
+func (s *Store) Authenticate(ctx context.Context, plaintext string) (*Token, error) {
+	if !strings.HasPrefix(plaintext, TokenPrefix) {
+		return nil, ErrInvalidToken
+	}
+
+	row := s.db.QueryRowContext(ctx, `SELECT `+tokenColumns+` FROM api_tokens WHERE token_hash = ?`, hashToken(plaintext))
+	t, err := scanToken(row)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	now := s.now()
+	if t.ExpiresAt != nil && now.Unix() >= *t.ExpiresAt {
+		return nil, ErrExpired
+	}
+
+	if t.LastUsedAt == nil || now.Sub(time.Unix(*t.LastUsedAt, 0)) >= lastUsedResolution {
+		lastUsed := now.Unix()
+		if _, err := s.db.ExecContext(ctx, `UPDATE api_tokens SET last_used_at = ? WHERE id = ?`, lastUsed, t.ID); err != nil {
+			return nil, fmt.Errorf("apitoken: failed to update last_used_at: %w", err)
+		}
+		t.LastUsedAt = &lastUsed
+	}
+
+	return t, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanToken(row rowScanner) (*Token, error) {
+	var (
+		t          Token
+		scopes     string
+		expiresAt  sql.NullInt64
+		lastUsedAt sql.NullInt64
+	)
+	if err := row.Scan(
+		&t.ID,
+		&t.UserID,
+		&t.Name,
+		&t.Prefix,
+		&scopes,
+		&expiresAt,
+		&lastUsedAt,
+		&t.CreatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("apitoken: failed to scan token: %w", err)
+	}
+	t.Scopes = decodeScopes(scopes)
+	if expiresAt.Valid {
+		t.ExpiresAt = &expiresAt.Int64
+	}
+	if lastUsedAt.Valid {
+		t.LastUsedAt = &lastUsedAt.Int64
+	}
+	return &t, nil
+}
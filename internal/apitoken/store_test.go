@@ -0,0 +1,101 @@
+package apitoken
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"main/internal/database"
+	"main/internal/user"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.now
+}
+
+func newTestStores(t *testing.T, clock *fakeClock) (*Store, *user.Store) {
+	t.Helper()
+
+	dbContainer, err := database.Open(t.Context(), database.Options{
+		Path: filepath.Join(t.TempDir(), "data.db"),
+	})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = dbContainer.Close()
+	})
+
+	store := NewStore(dbContainer.DB())
+	store.now = clock.Now
+	return store, user.NewStore(dbContainer.DB())
+}
+
+func TestAuthenticateHonoursExpiry(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	store, users := newTestStores(t, clock)
+
+	owner, err := users.Create(t.Context(), "alice", "alice-password", user.RoleOperator)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	token, plaintext, err := store.Create(t.Context(), owner, CreateParams{
+		Name:          "cron",
+		Scopes:        []string{"logs:read", "stats:read", "logs:read"},
+		ExpiresInDays: 1,
+	})
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	if len(token.Scopes) != 2 || !token.HasScope(ScopeLogsRead) {
+		t.Fatalf("expected deduplicated scopes, got %v", token.Scopes)
+	}
+
+	got, err := store.Authenticate(t.Context(), plaintext)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if got.ID != token.ID || got.LastUsedAt == nil || *got.LastUsedAt != clock.now.Unix() {
+		t.Fatalf("unexpected authenticated token: %+v", got)
+	}
+
+	if _, err := store.Authenticate(t.Context(), plaintext+"x"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken for tampered token, got %v", err)
+	}
+
+	clock.now = clock.now.Add(24 * time.Hour)
+	if _, err := store.Authenticate(t.Context(), plaintext); !errors.Is(err, ErrExpired) {
+		t.Fatalf("expected ErrExpired, got %v", err)
+	}
+}
+
+func TestRevokeOnlyAffectsOwner(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	store, users := newTestStores(t, clock)
+
+	alice, _ := users.Create(t.Context(), "alice", "alice-password", user.RoleViewer)
+	bob, _ := users.Create(t.Context(), "bob", "bobby-password", user.RoleViewer)
+
+	token, _, err := store.Create(t.Context(), alice, CreateParams{Name: "cron", Scopes: []string{"stats:read"}})
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+
+	if err := store.Revoke(t.Context(), bob.ID, token.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound revoking another user's token, got %v", err)
+	}
+	if err := store.Revoke(t.Context(), alice.ID, token.ID); err != nil {
+		t.Fatalf("revoke token: %v", err)
+	}
+
+	tokens, err := store.ListByUser(t.Context(), alice.ID)
+	if err != nil || len(tokens) != 0 {
+		t.Fatalf("expected no tokens after revoke, got %v (%v)", tokens, err)
+	}
+}
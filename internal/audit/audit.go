@@ -0,0 +1,76 @@
+// Package audit 将安全相关事件持久化到 SQLite，供事后查询与追溯。
+package audit
+
+import (
+	"errors"
+	"time"
+)
+
+// Action 审计事件类型
+type Action string
+
+const (
+	ActionLogin           Action = "login"
+	ActionLoginMFA        Action = "login.mfa"
+	ActionLogout          Action = "logout"
+	ActionUnauthorized    Action = "request.unauthorized"
+	ActionUserCreate      Action = "user.create"
+	ActionUserUpdate      Action = "user.update"
+	ActionUserDelete      Action = "user.delete"
+	ActionUserTOTPReset   Action = "user.totp_reset"
+	ActionTOTPEnable      Action = "totp.enable"
+	ActionTOTPDisable     Action = "totp.disable"
+	ActionAPITokenCreate  Action = "api_token.create"
+	ActionAPITokenRevoke  Action = "api_token.revoke"
+	ActionLoginLinkCreate Action = "login_link.create"
+	ActionMaintenance     Action = "maintenance.update"
+)
+
+// Outcome 事件结果
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+var ErrInvalidCursor = errors.New("audit: invalid cursor")
+
+// Event 单条审计记录，Actor 为空表示匿名请求
+type Event struct {
+	ID        int64   `json:"id"`
+	CreatedAt int64   `json:"created_at"`
+	Actor     string  `json:"actor"`
+	ActorID   int64   `json:"actor_id"`
+	SessionID string  `json:"session_id"`
+	IP        string  `json:"ip"`
+	UserAgent string  `json:"user_agent"`
+	Action    Action  `json:"action"`
+	Target    string  `json:"target"`
+	Outcome   Outcome `json:"outcome"`
+	Detail    string  `json:"detail"`
+	PrevHash  string  `json:"prev_hash"`
+	Hash      string  `json:"hash"`
+}
+
+// Filter 查询条件，零值字段表示不过滤
+type Filter struct {
+	From   time.Time
+	To     time.Time
+	Actor  string
+	Action Action
+	// Cursor 上一页返回的 NextCursor，为空表示从最新记录开始
+	Cursor string
+	Limit  int
+}
+
+// Page 按时间倒序的一页审计记录，NextCursor 为空表示没有更多数据
+type Page struct {
+	Events     []Event `json:"events"`
+	NextCursor string  `json:"next_cursor"`
+}
@@ -0,0 +1,310 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"main/internal/keyfile"
+)
+
+// CheckpointFileName 签名检查点文件名，位于 DATA_DIR 下，与数据库分开存放以便发现截断
+const CheckpointFileName = "audit_checkpoints.jsonl"
+
+// VerifyResult 哈希链校验结果，OK 为 false 时 BrokenAt 指向第一处断链
+type VerifyResult struct {
+	OK      bool  `json:"ok"`
+	Checked int   `json:"checked"`
+	FirstID int64 `json:"first_id"`
+	LastID  int64 `json:"last_id"`
+	// Checkpoints 通过校验的检查点数量
+	Checkpoints int `json:"checkpoints"`
+	// PrunedCheckpoints 对应记录已被保留期清理的检查点数量
+	PrunedCheckpoints int    `json:"pruned_checkpoints"`
+	BrokenAt          int64  `json:"broken_at,omitempty"`
+	Reason            string `json:"reason,omitempty"`
+}
+
+func (r *VerifyResult) fail(id int64, reason string) *VerifyResult {
+	r.OK = false
+	r.BrokenAt = id
+	r.Reason = reason
+	return r
+}
+
+// computeHash 对 prev_hash 与记录各字段做长度前缀编码后取 SHA-256，避免字段拼接歧义
+func computeHash(e *Event) string {
+	h := sha256.New()
+	writeField(h, e.PrevHash)
+	writeField(h, strconv.FormatInt(e.ID, 10))
+	writeField(h, strconv.FormatInt(e.CreatedAt, 10))
+	writeField(h, e.Actor)
+	writeField(h, strconv.FormatInt(e.ActorID, 10))
+	writeField(h, e.SessionID)
+	writeField(h, e.IP)
+	writeField(h, e.UserAgent)
+	writeField(h, string(e.Action))
+	writeField(h, e.Target)
+	writeField(h, string(e.Outcome))
+	writeField(h, e.Detail)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeField(h hash.Hash, value string) {
+	_, _ = h.Write([]byte(strconv.Itoa(len(value))))
+	_, _ = h.Write([]byte{':'})
+	_, _ = h.Write([]byte(value))
+}
+
+// Verify 按 ID 顺序遍历哈希链，checkpoints 不为 nil 时同时校验检查点以发现截断。
+// 链的起点取自 audit_chain_state：第一条记录的 prev_hash 必须等于 anchor_hash（保留期清理后更新），
+// 只有 pruned_through_id 及之前的检查点允许找不到对应记录。
+func (s *Store) Verify(ctx context.Context, checkpoints *Checkpoints) (*VerifyResult, error) {
+	result := &VerifyResult{OK: true}
+
+	var (
+		prunedThrough int64
+		prevHash      string
+	)
+	err := s.db.QueryRowContext(ctx,
+		`SELECT pruned_through_id, anchor_hash FROM audit_chain_state WHERE id = 1`,
+	).Scan(&prunedThrough, &prevHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return result.fail(0, "chain state is missing"), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("audit: failed to read chain state: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+eventColumns+` FROM audit_events ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("audit: failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		if result.Checked == 0 {
+			result.FirstID = e.ID
+		}
+		result.LastID = e.ID
+		result.Checked++
+
+		if e.Hash == "" {
+			return result.fail(e.ID, "missing hash"), nil
+		}
+		if e.PrevHash != prevHash {
+			return result.fail(e.ID, "prev_hash does not match previous record"), nil
+		}
+		if computeHash(e) != e.Hash {
+			return result.fail(e.ID, "record content does not match hash"), nil
+		}
+		prevHash = e.Hash
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("audit: failed to iterate events: %w", err)
+	}
+
+	if checkpoints == nil {
+		return result, nil
+	}
+	return checkpoints.verify(ctx, s, result, prunedThrough)
+}
+
+// checkpoint 检查点文件中的一行
+type checkpoint struct {
+	ID        int64  `json:"id"`
+	Hash      string `json:"hash"`
+	CreatedAt int64  `json:"created_at"`
+	KeyID     string `json:"key_id"`
+	Signature string `json:"signature"`
+}
+
+// CheckpointKeyFileName 检查点签名密钥文件名，位于 DATA_DIR 下。
+// 密钥与 AUTH_KEY 无关，AUTH_KEY 自动生成或轮换后已有检查点仍可校验
+const CheckpointKeyFileName = ".audit_checkpoint_key"
+
+// Checkpoints 定期将链尾 ID 与 hash 以 HMAC 签名追加到独立文件。
+// 数据库被截断后，检查点中记录的链尾不再存在，校验即可发现。
+type Checkpoints struct {
+	path    string
+	keyPath string
+
+	mu     sync.Mutex
+	key    []byte
+	keyID  string
+	lastID int64
+}
+
+// NewCheckpoints 创建检查点读写器，签名密钥在首次使用时从 DATA_DIR 加载或生成
+func NewCheckpoints(dataDir string) *Checkpoints {
+	return &Checkpoints{
+		path:    filepath.Join(dataDir, CheckpointFileName),
+		keyPath: filepath.Join(dataDir, CheckpointKeyFileName),
+	}
+}
+
+// loadKey 调用方需持有 cp.mu
+func (cp *Checkpoints) loadKey() error {
+	if cp.key != nil {
+		return nil
+	}
+	key, err := keyfile.LoadOrCreate(cp.keyPath)
+	if err != nil {
+		return fmt.Errorf("audit: failed to load checkpoint key: %w", err)
+	}
+	idSum := sha256.Sum256(key)
+	cp.key = key
+	cp.keyID = hex.EncodeToString(idSum[:4])
+	return nil
+}
+
+func (cp *Checkpoints) sign(c checkpoint) string {
+	mac := hmac.New(sha256.New, cp.key)
+	_, _ = fmt.Fprintf(mac, "%d:%s:%d", c.ID, c.Hash, c.CreatedAt)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Write 链尾有新记录时追加一个签名检查点，返回是否写入
+func (cp *Checkpoints) Write(ctx context.Context, s *Store) (bool, error) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	if err := cp.loadKey(); err != nil {
+		return false, err
+	}
+
+	var c checkpoint
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id, hash FROM audit_events WHERE hash <> '' ORDER BY id DESC LIMIT 1`,
+	).Scan(&c.ID, &c.Hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("audit: failed to read chain head: %w", err)
+	}
+	if c.ID == cp.lastID {
+		return false, nil
+	}
+
+	c.CreatedAt = s.now().Unix()
+	c.KeyID = cp.keyID
+	c.Signature = cp.sign(c)
+	line, err := json.Marshal(c)
+	if err != nil {
+		return false, fmt.Errorf("audit: failed to encode checkpoint: %w", err)
+	}
+
+	f, err := os.OpenFile(cp.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return false, fmt.Errorf("audit: failed to open checkpoint file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return false, fmt.Errorf("audit: failed to write checkpoint: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return false, fmt.Errorf("audit: failed to sync checkpoint file: %w", err)
+	}
+
+	cp.lastID = c.ID
+	return true, nil
+}
+
+// verify 逐个核对检查点：由当前密钥签名且签名有效，且对应记录仍存在并且 hash 一致。
+// 其他密钥签名的检查点无法确认真伪，视为校验失败，否则删除密钥文件即可掩盖截断；
+// 对应记录只有在 prunedThrough 及之前时才允许被保留期清理
+func (cp *Checkpoints) verify(ctx context.Context, s *Store, result *VerifyResult, prunedThrough int64) (*VerifyResult, error) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	if err := cp.loadKey(); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(cp.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("audit: failed to open checkpoint file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		var c checkpoint
+		if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+			return result.fail(0, fmt.Sprintf("checkpoint line %d is malformed", lineNo)), nil
+		}
+		if c.KeyID != cp.keyID {
+			return result.fail(c.ID, fmt.Sprintf("checkpoint line %d is signed by unknown key %s", lineNo, c.KeyID)), nil
+		}
+		if !hmac.Equal([]byte(c.Signature), []byte(cp.sign(c))) {
+			return result.fail(c.ID, fmt.Sprintf("checkpoint line %d has invalid signature", lineNo)), nil
+		}
+
+		if c.ID <= prunedThrough {
+			result.PrunedCheckpoints++
+			continue
+		}
+		if c.ID > result.LastID {
+			return result.fail(c.ID, "audit log truncated after checkpoint"), nil
+		}
+		var storedHash string
+		err := s.db.QueryRowContext(ctx, `SELECT hash FROM audit_events WHERE id = ?`, c.ID).Scan(&storedHash)
+		if errors.Is(err, sql.ErrNoRows) {
+			return result.fail(c.ID, "checkpointed record is missing"), nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("audit: failed to read checkpointed record: %w", err)
+		}
+		if storedHash != c.Hash {
+			return result.fail(c.ID, "checkpointed record hash changed"), nil
+		}
+		result.Checkpoints++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("audit: failed to read checkpoint file: %w", err)
+	}
+
+	return result, nil
+}
+
+// RunCheckpoints 每隔 interval 写入一次检查点，interval <= 0 表示不写入
+func RunCheckpoints(ctx context.Context, s *Store, cp *Checkpoints, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := cp.Write(ctx, s); err != nil {
+				slog.Warn("failed to write audit checkpoint", "error", err)
+			}
+		}
+	}
+}
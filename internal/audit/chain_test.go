@@ -0,0 +1,259 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func recordEvents(t *testing.T, store *Store, count int) {
+	t.Helper()
+
+	for i := range count {
+		if err := store.Record(t.Context(), Event{
+			Actor:   "alice",
+			Action:  ActionLogin,
+			Outcome: OutcomeSuccess,
+			Detail:  "attempt " + strconv.Itoa(i),
+		}); err != nil {
+			t.Fatalf("record event %d: %v", i, err)
+		}
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	store := newTestStore(t, time.Unix(1_700_000_000, 0))
+	recordEvents(t, store, 5)
+
+	result, err := store.Verify(t.Context(), nil)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !result.OK || result.Checked != 5 || result.FirstID != 1 || result.LastID != 5 {
+		t.Fatalf("expected intact chain, got %+v", result)
+	}
+
+	if _, err := store.db.ExecContext(t.Context(), `UPDATE audit_events SET outcome = 'failure' WHERE id = 3`); err != nil {
+		t.Fatalf("tamper event: %v", err)
+	}
+	result, err = store.Verify(t.Context(), nil)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if result.OK || result.BrokenAt != 3 {
+		t.Fatalf("expected broken link at 3 after edit, got %+v", result)
+	}
+}
+
+func TestVerifyDetectsDeletedRecord(t *testing.T) {
+	store := newTestStore(t, time.Unix(1_700_000_000, 0))
+	recordEvents(t, store, 5)
+
+	if _, err := store.db.ExecContext(t.Context(), `DELETE FROM audit_events WHERE id = 2`); err != nil {
+		t.Fatalf("delete event: %v", err)
+	}
+	result, err := store.Verify(t.Context(), nil)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if result.OK || result.BrokenAt != 3 {
+		t.Fatalf("expected broken link at 3 after delete, got %+v", result)
+	}
+}
+
+func TestVerifyAllowsPrunedPrefix(t *testing.T) {
+	base := time.Unix(1_700_000_000, 0)
+	store := newTestStore(t, base)
+	checkpoints := NewCheckpoints(t.TempDir())
+
+	recordEvents(t, store, 2)
+	if _, err := checkpoints.Write(t.Context(), store); err != nil {
+		t.Fatalf("write checkpoint: %v", err)
+	}
+	store.now = func() time.Time { return base.Add(time.Hour) }
+	recordEvents(t, store, 2)
+
+	if _, err := store.Prune(t.Context(), base.Add(time.Minute)); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	result, err := store.Verify(t.Context(), checkpoints)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !result.OK || result.FirstID != 3 || result.Checked != 2 {
+		t.Fatalf("expected pruned chain to verify, got %+v", result)
+	}
+
+	// 全部记录被清理后，新记录从 anchor_hash 继续
+	if _, err := store.Prune(t.Context(), base.Add(2*time.Hour)); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	recordEvents(t, store, 1)
+	result, err = store.Verify(t.Context(), checkpoints)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !result.OK || result.FirstID != 5 || result.Checked != 1 {
+		t.Fatalf("expected chain to continue after full prune, got %+v", result)
+	}
+}
+
+func TestVerifyRejectsRewrittenPrefix(t *testing.T) {
+	store := newTestStore(t, time.Unix(1_700_000_000, 0))
+	recordEvents(t, store, 5)
+
+	// 清空开头记录的 hash 伪装成启用哈希链前的旧记录，并把第 3 条的 prev_hash 挪到第 1 条
+	if _, err := store.db.ExecContext(t.Context(), `
+UPDATE audit_events SET hash = '', detail = 'rewritten' WHERE id <= 2;
+UPDATE audit_events SET prev_hash = (SELECT prev_hash FROM audit_events WHERE id = 3) WHERE id = 1;`,
+	); err != nil {
+		t.Fatalf("tamper events: %v", err)
+	}
+	result, err := store.Verify(t.Context(), nil)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if result.OK || result.BrokenAt != 1 || result.Reason != "missing hash" {
+		t.Fatalf("expected blanked hash to be rejected, got %+v", result)
+	}
+}
+
+func TestVerifyDetectsDeletedPrefix(t *testing.T) {
+	store := newTestStore(t, time.Unix(1_700_000_000, 0))
+	recordEvents(t, store, 5)
+
+	// 未经保留期清理直接删除开头记录，anchor_hash 与现存第一条记录不再衔接
+	if _, err := store.db.ExecContext(t.Context(), `DELETE FROM audit_events WHERE id <= 2`); err != nil {
+		t.Fatalf("delete events: %v", err)
+	}
+	result, err := store.Verify(t.Context(), nil)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if result.OK || result.BrokenAt != 3 {
+		t.Fatalf("expected deleted prefix to be detected at 3, got %+v", result)
+	}
+}
+
+func TestCheckpointsDetectTruncation(t *testing.T) {
+	store := newTestStore(t, time.Unix(1_700_000_000, 0))
+	dataDir := t.TempDir()
+	checkpoints := NewCheckpoints(dataDir)
+
+	recordEvents(t, store, 4)
+	written, err := checkpoints.Write(t.Context(), store)
+	if err != nil || !written {
+		t.Fatalf("expected checkpoint to be written, got %v (%v)", written, err)
+	}
+	if written, err := checkpoints.Write(t.Context(), store); err != nil || written {
+		t.Fatalf("expected unchanged chain head to skip checkpoint, got %v (%v)", written, err)
+	}
+
+	result, err := store.Verify(t.Context(), checkpoints)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !result.OK || result.Checkpoints != 1 {
+		t.Fatalf("expected checkpoint to verify, got %+v", result)
+	}
+
+	// 删除链尾记录后哈希链本身仍然连续，只能依靠检查点发现
+	if _, err := store.db.ExecContext(t.Context(), `DELETE FROM audit_events WHERE id > 2`); err != nil {
+		t.Fatalf("truncate events: %v", err)
+	}
+	result, err = store.Verify(t.Context(), nil)
+	if err != nil || !result.OK {
+		t.Fatalf("expected truncated chain to look intact without checkpoints, got %+v (%v)", result, err)
+	}
+	result, err = store.Verify(t.Context(), checkpoints)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if result.OK || result.BrokenAt != 4 {
+		t.Fatalf("expected truncation at checkpoint 4, got %+v", result)
+	}
+
+	// 删除密钥文件后会生成新密钥，旧检查点无法确认真伪，不能借此掩盖截断
+	if err := os.Remove(filepath.Join(dataDir, CheckpointKeyFileName)); err != nil {
+		t.Fatalf("remove checkpoint key: %v", err)
+	}
+	otherKey := NewCheckpoints(dataDir)
+	result, err = store.Verify(t.Context(), otherKey)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if result.OK || result.BrokenAt != 4 || !strings.HasPrefix(result.Reason, "checkpoint line 1 is signed by unknown key") {
+		t.Fatalf("expected checkpoint signed by another key to be rejected, got %+v", result)
+	}
+}
+
+func TestCheckpointsAllowFullPrune(t *testing.T) {
+	base := time.Unix(1_700_000_000, 0)
+	store := newTestStore(t, base)
+	checkpoints := NewCheckpoints(t.TempDir())
+
+	recordEvents(t, store, 3)
+	if _, err := checkpoints.Write(t.Context(), store); err != nil {
+		t.Fatalf("write checkpoint: %v", err)
+	}
+
+	// 保留期清理删除全部记录后，检查点对应的记录已不存在，但不应报告截断
+	if _, err := store.Prune(t.Context(), base.Add(time.Hour)); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	result, err := store.Verify(t.Context(), checkpoints)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !result.OK || result.LastID != 0 || result.PrunedCheckpoints != 1 {
+		t.Fatalf("expected fully pruned log to verify, got %+v", result)
+	}
+
+	// 清理范围之外被删除的记录仍然按截断处理
+	recordEvents(t, store, 2)
+	if _, err := checkpoints.Write(t.Context(), store); err != nil {
+		t.Fatalf("write checkpoint: %v", err)
+	}
+	if _, err := store.db.ExecContext(t.Context(), `DELETE FROM audit_events`); err != nil {
+		t.Fatalf("truncate events: %v", err)
+	}
+	result, err = store.Verify(t.Context(), checkpoints)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if result.OK || result.BrokenAt != 5 || result.Reason != "audit log truncated after checkpoint" {
+		t.Fatalf("expected truncation after full prune to be detected, got %+v", result)
+	}
+}
+
+func TestCheckpointsRejectForgedSignature(t *testing.T) {
+	store := newTestStore(t, time.Unix(1_700_000_000, 0))
+	dataDir := t.TempDir()
+	checkpoints := NewCheckpoints(dataDir)
+
+	recordEvents(t, store, 2)
+	if _, err := checkpoints.Write(t.Context(), store); err != nil {
+		t.Fatalf("write checkpoint: %v", err)
+	}
+
+	path := filepath.Join(dataDir, CheckpointFileName)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read checkpoint file: %v", err)
+	}
+	forged := []byte(`{"id":1,"hash":"00","created_at":1,"key_id":"` + checkpoints.keyID + `","signature":"00"}` + "\n")
+	if err := os.WriteFile(path, append(content, forged...), 0600); err != nil {
+		t.Fatalf("write checkpoint file: %v", err)
+	}
+
+	result, err := store.Verify(t.Context(), checkpoints)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if result.OK || result.Reason != "checkpoint line 2 has invalid signature" {
+		t.Fatalf("expected forged checkpoint to be rejected, got %+v", result)
+	}
+}
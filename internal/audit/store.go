@@ -0,0 +1,361 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/requestid"
+)
+
+const eventColumns = `id, created_at, actor, actor_id, session_id, ip, user_agent, action, target, outcome, detail, prev_hash, hash`
+
+// maxFieldLength 限制客户端可控字段长度，避免异常请求撑大审计表
+const maxFieldLength = 512
+
+// RetentionInterval 过期审计记录的清理间隔
+const RetentionInterval = time.Hour
+
+// Store 基于 SQLite 的审计存储。nil *Store 的 Record 系列方法为空操作，便于测试中省略审计。
+type Store struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// NewStore 创建审计存储
+func NewStore(db *sql.DB) *Store {
+	return &Store{
+		db:  db,
+		now: time.Now,
+	}
+}
+
+// Record 写入一条审计记录，CreatedAt 为 0 时使用当前时间。
+// 记录的 hash 覆盖自身内容与上一条记录的 hash，构成防篡改哈希链。
+func (s *Store) Record(ctx context.Context, e Event) error {
+	if s == nil {
+		return nil
+	}
+	if e.CreatedAt == 0 {
+		e.CreatedAt = s.now().Unix()
+	}
+	e.Actor = truncate(e.Actor)
+	e.SessionID = truncate(e.SessionID)
+	e.IP = truncate(e.IP)
+	e.UserAgent = truncate(e.UserAgent)
+	e.Target = truncate(e.Target)
+	e.Detail = truncate(e.Detail)
+
+	tx, err := s.beginImmediate(ctx)
+	if err != nil {
+		return fmt.Errorf("audit: failed to begin insert: %w", err)
+	}
+	defer tx.rollback()
+
+	// 表为空（如全部记录已被保留期清理）时从 anchor_hash 继续，保持哈希链连续
+	if err := tx.QueryRowContext(ctx, `SELECT COALESCE(
+		(SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1),
+		(SELECT anchor_hash FROM audit_chain_state WHERE id = 1),
+		''
+	)`).Scan(&e.PrevHash); err != nil {
+		return fmt.Errorf("audit: failed to read chain head: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO audit_events(created_at, actor, actor_id, session_id, ip, user_agent, action, target, outcome, detail, prev_hash) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.CreatedAt,
+		e.Actor,
+		e.ActorID,
+		e.SessionID,
+		e.IP,
+		e.UserAgent,
+		string(e.Action),
+		e.Target,
+		string(e.Outcome),
+		e.Detail,
+		e.PrevHash,
+	)
+	if err != nil {
+		return fmt.Errorf("audit: failed to insert event: %w", err)
+	}
+	if e.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("audit: failed to read inserted id: %w", err)
+	}
+
+	e.Hash = computeHash(&e)
+	if _, err := tx.ExecContext(ctx, `UPDATE audit_events SET hash = ? WHERE id = ?`, e.Hash, e.ID); err != nil {
+		return fmt.Errorf("audit: failed to store event hash: %w", err)
+	}
+
+	if err := tx.commit(ctx); err != nil {
+		return fmt.Errorf("audit: failed to commit event: %w", err)
+	}
+	return nil
+}
+
+// RecordRequest 从请求上下文补全 IP、User-Agent 以及 AuthMiddleware 写入的操作者信息后记录事件。
+// 审计写入失败只记录日志，不影响业务请求。
+func (s *Store) RecordRequest(c *gin.Context, e Event) {
+	if s == nil {
+		return
+	}
+
+	if e.Actor == "" {
+		e.Actor = c.GetString("username")
+	}
+	if e.ActorID == 0 {
+		e.ActorID = c.GetInt64("user_id")
+	}
+	if e.SessionID == "" {
+		e.SessionID = c.GetString("session_id")
+	}
+	e.IP = c.ClientIP()
+	e.UserAgent = c.Request.UserAgent()
+
+	if err := s.Record(c.Request.Context(), e); err != nil {
+		requestid.Logger(c).Error("failed to record audit event", "action", e.Action, "error", err)
+	}
+}
+
+// Query 按条件分页查询，结果按 ID 倒序（即时间倒序）
+func (s *Store) Query(ctx context.Context, filter Filter) (*Page, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	limit = min(limit, MaxPageSize)
+
+	var (
+		conditions []string
+		args       []any
+	)
+	if filter.Cursor != "" {
+		cursorID, err := strconv.ParseInt(filter.Cursor, 10, 64)
+		if err != nil || cursorID <= 0 {
+			return nil, ErrInvalidCursor
+		}
+		conditions = append(conditions, "id < ?")
+		args = append(args, cursorID)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From.Unix())
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, filter.To.Unix())
+	}
+	if filter.Actor != "" {
+		conditions = append(conditions, "actor = ? COLLATE NOCASE")
+		args = append(args, filter.Actor)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, string(filter.Action))
+	}
+
+	query := `SELECT ` + eventColumns + ` FROM audit_events`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	// 多取一条用于判断是否还有下一页
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit+1)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("audit: failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]Event, 0, limit)
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("audit: failed to iterate events: %w", err)
+	}
+
+	page := &Page{Events: events}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		page.NextCursor = strconv.FormatInt(page.Events[limit-1].ID, 10)
+	}
+	return page, nil
+}
+
+// Prune 删除 before 之前的审计记录，返回删除条数。
+// 被删除的最后一条链上记录的 hash 写入 anchor_hash，校验时作为现存第一条记录的 prev_hash；
+// 删除到的最大 ID 写入 pruned_through_id，校验时据此认定更早的检查点已被清理而非截断
+func (s *Store) Prune(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := s.beginImmediate(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("audit: failed to begin prune: %w", err)
+	}
+	defer tx.rollback()
+
+	var anchor string
+	err = tx.QueryRowContext(ctx,
+		`SELECT hash FROM audit_events WHERE created_at < ? AND hash <> '' ORDER BY id DESC LIMIT 1`,
+		before.Unix(),
+	).Scan(&anchor)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("audit: failed to read prune anchor: %w", err)
+	}
+
+	var prunedThrough sql.NullInt64
+	err = tx.QueryRowContext(ctx,
+		`SELECT MAX(id) FROM audit_events WHERE created_at < ?`,
+		before.Unix(),
+	).Scan(&prunedThrough)
+	if err != nil {
+		return 0, fmt.Errorf("audit: failed to read prune range: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM audit_events WHERE created_at < ?`, before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("audit: failed to prune events: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("audit: failed to read affected rows: %w", err)
+	}
+	if anchor != "" {
+		if _, err := tx.ExecContext(ctx, `UPDATE audit_chain_state SET anchor_hash = ? WHERE id = 1`, anchor); err != nil {
+			return 0, fmt.Errorf("audit: failed to update chain anchor: %w", err)
+		}
+	}
+	if prunedThrough.Valid {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE audit_chain_state SET pruned_through_id = MAX(pruned_through_id, ?) WHERE id = 1`,
+			prunedThrough.Int64,
+		); err != nil {
+			return 0, fmt.Errorf("audit: failed to update pruned range: %w", err)
+		}
+	}
+
+	if err := tx.commit(ctx); err != nil {
+		return 0, fmt.Errorf("audit: failed to commit prune: %w", err)
+	}
+	return deleted, nil
+}
+
+// RunRetention 启动时及之后每隔 RetentionInterval 清理超过 retention 的记录，retention <= 0 表示永久保留
+func RunRetention(ctx context.Context, s *Store, retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+
+	prune := func() {
+		deleted, err := s.Prune(ctx, s.now().Add(-retention))
+		if err != nil {
+			slog.Warn("audit retention cleanup failed", "error", err)
+			return
+		}
+		if deleted > 0 {
+			slog.Info("audit retention cleanup completed", "deleted", deleted)
+		}
+	}
+
+	prune()
+	ticker := time.NewTicker(RetentionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			prune()
+		}
+	}
+}
+
+// immediateTx 以 BEGIN IMMEDIATE 开启的事务。
+// 登录链接、维护模式等 CLI 子命令在独立进程中写入同一张审计表，
+// 默认的 DEFERRED 事务先读链尾再升级写锁，在 WAL 下会因快照过期得到 SQLITE_BUSY；
+// 开始即持有写锁可让并发写入按 busy_timeout 排队，且读到的链尾在提交前不会变化。
+type immediateTx struct {
+	*sql.Conn
+	done bool
+}
+
+func (s *Store) beginImmediate(ctx context.Context) (*immediateTx, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return &immediateTx{Conn: conn}, nil
+}
+
+func (tx *immediateTx) commit(ctx context.Context) error {
+	if _, err := tx.ExecContext(ctx, "COMMIT"); err != nil {
+		return err
+	}
+	tx.done = true
+	return tx.Close()
+}
+
+// rollback 在未提交时回滚并归还连接，已提交时为空操作
+func (tx *immediateTx) rollback() {
+	if tx.done {
+		return
+	}
+	tx.done = true
+	_, _ = tx.ExecContext(context.Background(), "ROLLBACK")
+	_ = tx.Close()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEvent(row rowScanner) (*Event, error) {
+	var (
+		e       Event
+		action  string
+		outcome string
+	)
+	if err := row.Scan(
+		&e.ID,
+		&e.CreatedAt,
+		&e.Actor,
+		&e.ActorID,
+		&e.SessionID,
+		&e.IP,
+		&e.UserAgent,
+		&action,
+		&e.Target,
+		&outcome,
+		&e.Detail,
+		&e.PrevHash,
+		&e.Hash,
+	); err != nil {
+		return nil, fmt.Errorf("audit: failed to scan event: %w", err)
+	}
+	e.Action = Action(action)
+	e.Outcome = Outcome(outcome)
+	return &e, nil
+}
+
+func truncate(value string) string {
+	if len(value) <= maxFieldLength {
+		return value
+	}
+	return strings.ToValidUTF8(value[:maxFieldLength], "")
+}
@@ -0,0 +1,154 @@
+package audit
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"main/internal/database"
+)
+
+func newTestStore(t *testing.T, now time.Time) *Store {
+	t.Helper()
+
+	dbContainer, err := database.Open(t.Context(), database.Options{
+		Path: filepath.Join(t.TempDir(), "data.db"),
+	})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = dbContainer.Close()
+	})
+
+	store := NewStore(dbContainer.DB())
+	store.now = func() time.Time { return now }
+	return store
+}
+
+func TestQueryFiltersAndPaginates(t *testing.T) {
+	base := time.Unix(1_700_000_000, 0)
+	store := newTestStore(t, base)
+
+	for i := range 5 {
+		actor := "alice"
+		if i%2 == 1 {
+			actor = "bob"
+		}
+		if err := store.Record(t.Context(), Event{
+			CreatedAt: base.Add(time.Duration(i) * time.Minute).Unix(),
+			Actor:     actor,
+			Action:    ActionLogin,
+			Outcome:   OutcomeSuccess,
+		}); err != nil {
+			t.Fatalf("record event %d: %v", i, err)
+		}
+	}
+	if err := store.Record(t.Context(), Event{Actor: "alice", Action: ActionLogout, Outcome: OutcomeSuccess}); err != nil {
+		t.Fatalf("record logout: %v", err)
+	}
+
+	first, err := store.Query(t.Context(), Filter{Action: ActionLogin, Limit: 2})
+	if err != nil {
+		t.Fatalf("query first page: %v", err)
+	}
+	if len(first.Events) != 2 || first.NextCursor == "" || first.Events[0].CreatedAt < first.Events[1].CreatedAt {
+		t.Fatalf("unexpected first page: %+v", first)
+	}
+
+	second, err := store.Query(t.Context(), Filter{Action: ActionLogin, Limit: 2, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatalf("query second page: %v", err)
+	}
+	third, err := store.Query(t.Context(), Filter{Action: ActionLogin, Limit: 2, Cursor: second.NextCursor})
+	if err != nil {
+		t.Fatalf("query third page: %v", err)
+	}
+	if len(second.Events) != 2 || len(third.Events) != 1 || third.NextCursor != "" {
+		t.Fatalf("unexpected pagination: second=%+v third=%+v", second, third)
+	}
+
+	byActor, err := store.Query(t.Context(), Filter{Actor: "ALICE", Action: ActionLogin})
+	if err != nil || len(byActor.Events) != 3 {
+		t.Fatalf("expected 3 alice logins, got %+v (%v)", byActor, err)
+	}
+
+	ranged, err := store.Query(t.Context(), Filter{
+		Action: ActionLogin,
+		From:   base.Add(time.Minute),
+		To:     base.Add(3 * time.Minute),
+	})
+	if err != nil || len(ranged.Events) != 3 {
+		t.Fatalf("expected 3 events in range, got %+v (%v)", ranged, err)
+	}
+
+	if _, err := store.Query(t.Context(), Filter{Cursor: "abc"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestPruneRemovesExpiredEvents(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	store := newTestStore(t, now)
+
+	old := Event{CreatedAt: now.Add(-48 * time.Hour).Unix(), Action: ActionLogin, Outcome: OutcomeFailure}
+	recent := Event{Action: ActionLogin, Outcome: OutcomeSuccess}
+	for _, e := range []Event{old, recent} {
+		if err := store.Record(t.Context(), e); err != nil {
+			t.Fatalf("record event: %v", err)
+		}
+	}
+
+	deleted, err := store.Prune(t.Context(), now.Add(-24*time.Hour))
+	if err != nil || deleted != 1 {
+		t.Fatalf("expected 1 pruned event, got %d (%v)", deleted, err)
+	}
+
+	page, err := store.Query(t.Context(), Filter{})
+	if err != nil || len(page.Events) != 1 || page.Events[0].Outcome != OutcomeSuccess {
+		t.Fatalf("unexpected remaining events: %+v (%v)", page, err)
+	}
+}
+
+func TestRecordSerializesAcrossConnections(t *testing.T) {
+	// 两个独立的 DBContainer 模拟服务进程与 CLI 子命令同时写入同一数据库文件
+	path := filepath.Join(t.TempDir(), "data.db")
+	stores := make([]*Store, 2)
+	for i := range stores {
+		dbContainer, err := database.Open(t.Context(), database.Options{Path: path})
+		if err != nil {
+			t.Fatalf("open test database: %v", err)
+		}
+		t.Cleanup(func() {
+			_ = dbContainer.Close()
+		})
+		stores[i] = NewStore(dbContainer.DB())
+	}
+
+	const perStore = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, len(stores)*perStore)
+	for _, store := range stores {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perStore {
+				errs <- store.Record(t.Context(), Event{Action: ActionLogin, Outcome: OutcomeSuccess})
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("record event: %v", err)
+		}
+	}
+
+	result, err := stores[0].Verify(t.Context(), nil)
+	if err != nil || !result.OK || result.Checked != len(stores)*perStore {
+		t.Fatalf("expected intact chain of %d events, got %+v (%v)", len(stores)*perStore, result, err)
+	}
+}
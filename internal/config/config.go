@@ -3,22 +3,100 @@ package config
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+
+	"main/internal/mtls"
+	"main/internal/proxyauth"
+	"main/internal/ratelimit"
+	"main/internal/systemd"
 )
 
 // Config 应用配置结构
 type Config struct {
-	Port                   int    // 服务监听端口
-	DataDir                string // 数据持久化目录
-	LogLevel               string // 日志等级
-	DisableStaticAssetLogs bool   // 是否禁用前端静态资源访问日志
-	AuthKey                string // 管理员身份验证密钥，同时用于 Session 签名
-	CookieSecure           bool   // Session Cookie 是否启用 Secure
-	IsAutoAuthKey          bool   // AuthKey 是否自动生成
+	Port                   int      // 服务监听端口
+	DataDir                string   // 数据持久化目录
+	LogLevel               string   // 日志等级
+	DisableStaticAssetLogs bool     // 是否禁用前端静态资源访问日志
+	AuthKey                string   // 管理员身份验证密钥，同时用于 Session 签名
+	CookieSecure           bool     // Session Cookie 是否启用 Secure
+	CookieSameSite         string   // Session Cookie 的 SameSite：lax、strict 或 none（跨站部署前端时使用，要求 Secure）
+	TOTPIssuer             string   // TOTP 认证器中显示的签发方名称
+	CSRFTrustedOrigins     []string // 额外允许发起写请求的来源（跨域部署前端时使用）
+	CORSAllowedOrigins     []string // 允许跨域携带 Cookie 调用 API 的前端来源，同时视为 CSRF 可信来源
+	CORSMaxAge             int      // 预检请求结果缓存时间（秒）
+	AuditRetentionDays     int      // 审计日志保留天数，0 表示永久保留
+	AuditCheckpointMinutes int      // 审计哈希链签名检查点写入间隔（分钟），0 表示不写入
+	PublicURL              string   // 对外访问地址，用于拼接一次性登录链接，默认 http(s)://localhost:PORT
+	LoginLinkTTLMinutes    int      // 一次性登录链接有效期（分钟）
+	TrustedProxies         []string // 可信反向代理地址（CIDR 或 IP），用于解析客户端 IP 和代理头认证
+	IsAutoAuthKey          bool     // AuthKey 是否自动生成
+
+	ListenAddr            string        // 监听地址：host:port，或 unix:/path.sock 监听 Unix 域套接字；默认 :PORT，systemd 套接字激活时忽略
+	ListenHost            string        // LISTEN_ADDR 中的主机部分，为空表示所有网卡；重定向等附属监听器绑定同一地址
+	ListenSocketMode      os.FileMode   // Unix 域套接字文件权限
+	HTTPReadHeaderTimeout time.Duration // 读取请求头的超时，防御 slowloris
+	HTTPReadTimeout       time.Duration // 读取完整请求（含请求体）的超时
+	HTTPWriteTimeout      time.Duration // 写响应的超时，SSE 日志流不受限制
+	HTTPIdleTimeout       time.Duration // keep-alive 空闲连接的超时
+	HTTPMaxHeaderBytes    int           // 请求头大小上限
+	MaxRequestBodyBytes   int64         // /api 请求体大小上限
+	ShutdownTimeout       time.Duration // 收到退出信号后等待进行中请求完成的最长时间
+
+	CompressionEnabled   bool     // 是否按 Accept-Encoding 动态压缩 /api 响应
+	CompressionEncodings []string // 动态压缩可用的编码，客户端同等接受时按顺序优先
+	CompressionMinSize   int      // 响应体达到该字节数才压缩，SSE 流不受限制
+
+	RateLimitAPI        ratelimit.Limit // 每个客户端（已登录按会话，其余按 IP）对 /api 的总请求限额
+	RateLimitAPIIP      ratelimit.Limit // 每个 IP 对 /api 的总请求限额，覆盖同一出口 IP 后的全部会话
+	RateLimitAuth       ratelimit.Limit // 每个 IP 登录类接口的请求限额
+	RateLimitStats      ratelimit.Limit // 每个客户端仪表盘统计接口的请求限额
+	RateLimitMaxClients int             // 每个限流器同时跟踪的客户端数量上限
+
+	HSTSMaxAge            int    // HSTS max-age（秒），仅在启用 HTTPS 或 Secure Cookie 时发送，0 表示不发送
+	HSTSIncludeSubdomains bool   // HSTS 是否包含子域名
+	FrameAncestors        string // 允许嵌入页面的来源（CSP frame-ancestors），off 表示不限制
+	ReferrerPolicy        string // Referrer-Policy，off 表示不发送
+	PermissionsPolicy     string // Permissions-Policy，off 表示不发送
+	ContentSecurityPolicy string // Content-Security-Policy，{nonce} 替换为每个请求的随机值，off 表示不发送
+
+	TLSCertFile       string        // HTTPS 服务端证书，与 TLSKeyFile 同时设置时启用 HTTPS
+	TLSKeyFile        string        // HTTPS 服务端私钥
+	TLSReloadInterval time.Duration // 检查证书文件变化的间隔，0 表示不自动重新加载
+	HTTPRedirectPort  int           // 启用 HTTPS 时将明文 HTTP 重定向到 HTTPS 的监听端口，0 表示不监听
+	HTTP3Enabled      bool          // 启用 HTTPS 时是否同时在同一端口号的 UDP 上提供 HTTP/3
+	TLSClientCAFile   string        // 客户端证书 CA，设置后启用双向 TLS 认证
+	TLSClientAuth     string        // 客户端证书校验模式：request 或 require
+	MTLSUsernameField string        // 作为用户名的证书字段：cn、dns 或 email
+	MTLSDefaultRole   string        // 证书用户不存在时自动创建使用的角色，为空则只允许已存在的用户
+
+	ProxyAuthEnabled     bool   // 是否信任可信代理注入的身份请求头
+	ProxyAuthUserHeader  string // 用户名请求头
+	ProxyAuthEmailHeader string // 邮箱请求头，用户名请求头为空时作为用户名
+	ProxyAuthDefaultRole string // 自动创建用户时分配的角色
+
+	OIDCEnabled        bool     // 是否启用 OIDC 单点登录
+	OIDCIssuerURL      string   // OIDC Provider 的 Issuer 地址，用于自动发现
+	OIDCClientID       string   // OIDC 客户端 ID
+	OIDCClientSecret   string   // OIDC 客户端密钥，公共客户端可为空
+	OIDCRedirectURL    string   // 回调地址，需指向 /api/v1/auth/oidc/callback
+	OIDCScopes         []string // 申请的 scope，openid 会自动补充
+	OIDCUsernameClaim  string   // 作为本地用户名的 claim
+	OIDCGroupsClaim    string   // 用户组 claim
+	OIDCAdminGroups    []string // 映射为 admin 的用户组
+	OIDCOperatorGroups []string // 映射为 operator 的用户组
+	OIDCViewerGroups   []string // 映射为 viewer 的用户组
+	OIDCDefaultRole    string   // 未匹配任何用户组时的角色，为空则拒绝登录
 }
 
 // Load 从环境变量加载配置
@@ -30,11 +108,218 @@ func Load() (*Config, error) {
 
 	cfg := &Config{
 		Port:                   getEnvAsInt("PORT", 8080),
+		ListenAddr:             getEnv("LISTEN_ADDR", ""),
 		DataDir:                getEnv("DATA_DIR", ".data"),
 		LogLevel:               getEnv("LOG_LEVEL", "info"),
 		DisableStaticAssetLogs: getEnvAsBool("DISABLE_STATIC_ASSET_LOGS", false),
 		AuthKey:                getEnv("AUTH_KEY", ""),
-		CookieSecure:           getEnvAsBool("COOKIE_SECURE", false),
+		CookieSameSite:         getEnv("COOKIE_SAMESITE", "lax"),
+		TOTPIssuer:             getEnv("TOTP_ISSUER", "Vue-Go Session"),
+		CSRFTrustedOrigins:     getEnvAsList("CSRF_TRUSTED_ORIGINS", nil),
+		CORSAllowedOrigins:     getEnvAsList("CORS_ALLOWED_ORIGINS", nil),
+		CORSMaxAge:             getEnvAsInt("CORS_MAX_AGE", 600),
+		AuditRetentionDays:     getEnvAsInt("AUDIT_RETENTION_DAYS", 90),
+		AuditCheckpointMinutes: getEnvAsInt("AUDIT_CHECKPOINT_MINUTES", 60),
+		PublicURL:              getEnv("PUBLIC_URL", ""),
+		LoginLinkTTLMinutes:    getEnvAsInt("LOGIN_LINK_TTL_MINUTES", 15),
+		TrustedProxies:         getEnvAsList("TRUSTED_PROXIES", nil),
+
+		HTTPReadHeaderTimeout: getEnvAsDuration("HTTP_READ_HEADER_TIMEOUT", 10*time.Second),
+		HTTPReadTimeout:       getEnvAsDuration("HTTP_READ_TIMEOUT", 30*time.Second),
+		HTTPWriteTimeout:      getEnvAsDuration("HTTP_WRITE_TIMEOUT", 60*time.Second),
+		HTTPIdleTimeout:       getEnvAsDuration("HTTP_IDLE_TIMEOUT", 120*time.Second),
+		HTTPMaxHeaderBytes:    getEnvAsInt("HTTP_MAX_HEADER_BYTES", 64<<10),
+		MaxRequestBodyBytes:   int64(getEnvAsInt("MAX_REQUEST_BODY_BYTES", 1<<20)),
+		ShutdownTimeout:       getEnvAsDuration("SHUTDOWN_TIMEOUT", 8*time.Second),
+
+		CompressionEnabled:   getEnvAsBool("COMPRESSION_ENABLED", true),
+		CompressionEncodings: getEnvAsList("COMPRESSION_ENCODINGS", []string{EncodingBrotli, EncodingGzip}),
+		CompressionMinSize:   getEnvAsInt("COMPRESSION_MIN_SIZE", 1024),
+
+		RateLimitMaxClients: getEnvAsInt("RATE_LIMIT_MAX_CLIENTS", 10000),
+
+		HSTSMaxAge:            getEnvAsInt("HSTS_MAX_AGE", 31536000),
+		HSTSIncludeSubdomains: getEnvAsBool("HSTS_INCLUDE_SUBDOMAINS", false),
+		FrameAncestors:        getEnvAsOptional("FRAME_ANCESTORS", "'none'"),
+		ReferrerPolicy:        getEnvAsOptional("REFERRER_POLICY", "strict-origin-when-cross-origin"),
+		PermissionsPolicy:     getEnvAsOptional("PERMISSIONS_POLICY", "camera=(), microphone=(), geolocation=(), payment=(), usb=()"),
+		ContentSecurityPolicy: getEnvAsOptional("CONTENT_SECURITY_POLICY", DefaultContentSecurityPolicy),
+
+		TLSCertFile:       getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:        getEnv("TLS_KEY_FILE", ""),
+		TLSReloadInterval: getEnvAsDuration("TLS_RELOAD_INTERVAL", time.Minute),
+		HTTPRedirectPort:  getEnvAsInt("HTTP_REDIRECT_PORT", 0),
+		HTTP3Enabled:      getEnvAsBool("HTTP3_ENABLED", false),
+		TLSClientCAFile:   getEnv("TLS_CLIENT_CA_FILE", ""),
+		TLSClientAuth:     getEnv("TLS_CLIENT_AUTH", mtls.ClientAuthRequest),
+		MTLSUsernameField: getEnv("MTLS_USERNAME_FIELD", mtls.UsernameFieldCN),
+		MTLSDefaultRole:   getEnv("MTLS_DEFAULT_ROLE", ""),
+
+		ProxyAuthEnabled:     getEnvAsBool("PROXY_AUTH_ENABLED", false),
+		ProxyAuthUserHeader:  getEnv("PROXY_AUTH_USER_HEADER", proxyauth.DefaultUserHeader),
+		ProxyAuthEmailHeader: getEnv("PROXY_AUTH_EMAIL_HEADER", proxyauth.DefaultEmailHeader),
+		ProxyAuthDefaultRole: getEnv("PROXY_AUTH_DEFAULT_ROLE", "viewer"),
+
+		OIDCEnabled:        getEnvAsBool("OIDC_ENABLED", false),
+		OIDCIssuerURL:      getEnv("OIDC_ISSUER_URL", ""),
+		OIDCClientID:       getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret:   getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:    getEnv("OIDC_REDIRECT_URL", ""),
+		OIDCScopes:         getEnvAsList("OIDC_SCOPES", []string{"openid", "profile", "email"}),
+		OIDCUsernameClaim:  getEnv("OIDC_USERNAME_CLAIM", "preferred_username"),
+		OIDCGroupsClaim:    getEnv("OIDC_GROUPS_CLAIM", "groups"),
+		OIDCAdminGroups:    getEnvAsList("OIDC_ADMIN_GROUPS", nil),
+		OIDCOperatorGroups: getEnvAsList("OIDC_OPERATOR_GROUPS", nil),
+		OIDCViewerGroups:   getEnvAsList("OIDC_VIEWER_GROUPS", nil),
+		OIDCDefaultRole:    getEnv("OIDC_DEFAULT_ROLE", ""),
+	}
+
+	if cfg.OIDCEnabled && (cfg.OIDCIssuerURL == "" || cfg.OIDCClientID == "" || cfg.OIDCRedirectURL == "") {
+		return nil, errors.New("OIDC_ENABLED requires OIDC_ISSUER_URL, OIDC_CLIENT_ID and OIDC_REDIRECT_URL")
+	}
+	switch cfg.OIDCDefaultRole {
+	case "", "admin", "operator", "viewer":
+	default:
+		return nil, fmt.Errorf("invalid OIDC_DEFAULT_ROLE %q", cfg.OIDCDefaultRole)
+	}
+
+	if _, err := proxyauth.ParsePrefixes(cfg.TrustedProxies); err != nil {
+		return nil, err
+	}
+	if cfg.ProxyAuthEnabled && len(cfg.TrustedProxies) == 0 {
+		return nil, errors.New("PROXY_AUTH_ENABLED requires TRUSTED_PROXIES")
+	}
+	switch cfg.ProxyAuthDefaultRole {
+	case "admin", "operator", "viewer":
+	default:
+		return nil, fmt.Errorf("invalid PROXY_AUTH_DEFAULT_ROLE %q", cfg.ProxyAuthDefaultRole)
+	}
+
+	if err := parseListenAddr(cfg); err != nil {
+		return nil, err
+	}
+
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return nil, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if cfg.TLSReloadInterval < 0 {
+		return nil, fmt.Errorf("invalid TLS_RELOAD_INTERVAL %s", cfg.TLSReloadInterval)
+	}
+	// 重定向与 HTTP/3 监听器绑定 LISTEN_ADDR 的主机地址；主监听器为 Unix 域套接字或由 systemd 传入时
+	// 没有可沿用的地址，另开 TCP/UDP 端口会暴露本应只通过套接字访问的服务
+	tcpListener := !IsUnixListenAddr(cfg.ListenAddr) && !systemd.Activated()
+	if cfg.HTTPRedirectPort != 0 {
+		if cfg.TLSCertFile == "" {
+			return nil, errors.New("HTTP_REDIRECT_PORT requires TLS_CERT_FILE and TLS_KEY_FILE")
+		}
+		if !tcpListener {
+			return nil, errors.New("HTTP_REDIRECT_PORT requires a TCP LISTEN_ADDR without systemd socket activation")
+		}
+		if cfg.HTTPRedirectPort < 0 || cfg.HTTPRedirectPort > 65535 || cfg.HTTPRedirectPort == cfg.Port {
+			return nil, fmt.Errorf("invalid HTTP_REDIRECT_PORT %d", cfg.HTTPRedirectPort)
+		}
+	}
+	if cfg.HTTP3Enabled && cfg.TLSCertFile == "" {
+		return nil, errors.New("HTTP3_ENABLED requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	if cfg.HTTP3Enabled && !tcpListener {
+		return nil, errors.New("HTTP3_ENABLED requires a TCP LISTEN_ADDR without systemd socket activation")
+	}
+	// 直接提供 HTTPS 时默认启用 Secure Cookie，仍可显式设置 COOKIE_SECURE=false
+	cfg.CookieSecure = getEnvAsBool("COOKIE_SECURE", cfg.TLSCertFile != "")
+	if cfg.TLSClientCAFile != "" && cfg.TLSCertFile == "" {
+		return nil, errors.New("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	if _, err := mtls.ParseClientAuth(cfg.TLSClientAuth); err != nil {
+		return nil, err
+	}
+	if err := mtls.ValidateUsernameField(cfg.MTLSUsernameField); err != nil {
+		return nil, err
+	}
+	switch cfg.MTLSDefaultRole {
+	case "", "admin", "operator", "viewer":
+	default:
+		return nil, fmt.Errorf("invalid MTLS_DEFAULT_ROLE %q", cfg.MTLSDefaultRole)
+	}
+
+	timeouts := map[string]time.Duration{
+		"HTTP_READ_HEADER_TIMEOUT": cfg.HTTPReadHeaderTimeout,
+		"HTTP_READ_TIMEOUT":        cfg.HTTPReadTimeout,
+		"HTTP_WRITE_TIMEOUT":       cfg.HTTPWriteTimeout,
+		"HTTP_IDLE_TIMEOUT":        cfg.HTTPIdleTimeout,
+		"SHUTDOWN_TIMEOUT":         cfg.ShutdownTimeout,
+	}
+	for key, timeout := range timeouts {
+		if timeout <= 0 {
+			return nil, fmt.Errorf("invalid %s %s", key, timeout)
+		}
+	}
+	if cfg.HTTPMaxHeaderBytes <= 0 {
+		return nil, fmt.Errorf("invalid HTTP_MAX_HEADER_BYTES %d", cfg.HTTPMaxHeaderBytes)
+	}
+	if cfg.MaxRequestBodyBytes <= 0 {
+		return nil, fmt.Errorf("invalid MAX_REQUEST_BODY_BYTES %d", cfg.MaxRequestBodyBytes)
+	}
+	for _, encoding := range cfg.CompressionEncodings {
+		if !slices.Contains(CompressionEncodings, encoding) {
+			return nil, fmt.Errorf("invalid COMPRESSION_ENCODINGS %q, supported: %s",
+				encoding, strings.Join(CompressionEncodings, ", "))
+		}
+	}
+	if cfg.CompressionMinSize < 0 {
+		return nil, fmt.Errorf("invalid COMPRESSION_MIN_SIZE %d", cfg.CompressionMinSize)
+	}
+
+	rateLimits := []struct {
+		key    string
+		value  string
+		target *ratelimit.Limit
+	}{
+		{"RATE_LIMIT_API", "600/m,120", &cfg.RateLimitAPI},
+		{"RATE_LIMIT_API_IP", "3000/m,600", &cfg.RateLimitAPIIP},
+		{"RATE_LIMIT_AUTH", "10/m,5", &cfg.RateLimitAuth},
+		{"RATE_LIMIT_STATS", "30/m,10", &cfg.RateLimitStats},
+	}
+	for _, rl := range rateLimits {
+		limit, err := ratelimit.ParseLimit(getEnv(rl.key, rl.value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", rl.key, err)
+		}
+		*rl.target = limit
+	}
+	if cfg.RateLimitMaxClients <= 0 {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_MAX_CLIENTS %d", cfg.RateLimitMaxClients)
+	}
+
+	sameSite, err := ParseSameSite(cfg.CookieSameSite)
+	if err != nil {
+		return nil, fmt.Errorf("invalid COOKIE_SAMESITE %q", cfg.CookieSameSite)
+	}
+	if sameSite == http.SameSiteNoneMode && !cfg.CookieSecure {
+		return nil, errors.New("COOKIE_SAMESITE=none requires COOKIE_SECURE=true")
+	}
+	for _, origin := range cfg.CORSAllowedOrigins {
+		if err := validateOrigin(origin); err != nil {
+			return nil, fmt.Errorf("invalid CORS_ALLOWED_ORIGINS: %w", err)
+		}
+	}
+	if cfg.CORSMaxAge < 0 {
+		return nil, fmt.Errorf("invalid CORS_MAX_AGE %d", cfg.CORSMaxAge)
+	}
+
+	if cfg.HSTSMaxAge < 0 {
+		return nil, fmt.Errorf("invalid HSTS_MAX_AGE %d", cfg.HSTSMaxAge)
+	}
+
+	if cfg.LoginLinkTTLMinutes <= 0 {
+		return nil, fmt.Errorf("invalid LOGIN_LINK_TTL_MINUTES %d", cfg.LoginLinkTTLMinutes)
+	}
+	if cfg.PublicURL == "" {
+		scheme := "http"
+		if cfg.TLSCertFile != "" {
+			scheme = "https"
+		}
+		cfg.PublicURL = fmt.Sprintf("%s://localhost:%d", scheme, cfg.Port)
 	}
 
 	// 如果 AUTH_KEY 未设置，生成随机 12 位字符串
@@ -46,6 +331,60 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// UnixSocketPrefix LISTEN_ADDR 中表示 Unix 域套接字的前缀
+const UnixSocketPrefix = "unix:"
+
+// IsUnixListenAddr 判断监听地址是否为 Unix 域套接字
+func IsUnixListenAddr(addr string) bool {
+	return strings.HasPrefix(addr, UnixSocketPrefix)
+}
+
+// parseListenAddr 校验 LISTEN_ADDR 与 LISTEN_SOCKET_MODE。
+// TCP 地址中的端口覆盖 PORT，重定向、HTTP/3 与默认 PUBLIC_URL 仍按 PORT 计算
+func parseListenAddr(cfg *Config) error {
+	mode, err := strconv.ParseUint(getEnv("LISTEN_SOCKET_MODE", "0660"), 8, 32)
+	if err != nil || mode > 0o777 {
+		return fmt.Errorf("invalid LISTEN_SOCKET_MODE %q", os.Getenv("LISTEN_SOCKET_MODE"))
+	}
+	cfg.ListenSocketMode = os.FileMode(mode)
+
+	if cfg.ListenAddr == "" {
+		cfg.ListenAddr = fmt.Sprintf(":%d", cfg.Port)
+		return nil
+	}
+	if IsUnixListenAddr(cfg.ListenAddr) {
+		if strings.TrimPrefix(cfg.ListenAddr, UnixSocketPrefix) == "" {
+			return errors.New("LISTEN_ADDR unix: requires a socket path")
+		}
+		return nil
+	}
+
+	host, portStr, err := net.SplitHostPort(cfg.ListenAddr)
+	if err != nil {
+		return fmt.Errorf("invalid LISTEN_ADDR %q: %w", cfg.ListenAddr, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return fmt.Errorf("invalid LISTEN_ADDR %q: port must be 1-65535", cfg.ListenAddr)
+	}
+	cfg.Port = port
+	cfg.ListenHost = host
+	return nil
+}
+
+// validateOrigin 来源必须是不带路径的 scheme://host[:port]；携带凭据的 CORS 不允许通配符
+func validateOrigin(origin string) error {
+	if origin == "*" {
+		return errors.New("wildcard origin is not allowed with credentials")
+	}
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") ||
+		strings.TrimRight(parsed.Path, "/") != "" || parsed.RawQuery != "" || parsed.Fragment != "" {
+		return fmt.Errorf("origin %q must be scheme://host[:port]", origin)
+	}
+	return nil
+}
+
 // getEnv 获取环境变量，如果不存在则返回默认值
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -54,6 +393,15 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvAsOptional 与 getEnv 相同，但值为 off 时返回空字符串，用于关闭默认开启的功能
+func getEnvAsOptional(key, defaultValue string) string {
+	value := getEnv(key, defaultValue)
+	if strings.EqualFold(value, "off") {
+		return ""
+	}
+	return value
+}
+
 // getEnvAsInt 获取整数类型的环境变量，如果不存在或解析失败则返回默认值
 func getEnvAsInt(key string, defaultValue int) int {
 	valueStr := os.Getenv(key)
@@ -69,6 +417,21 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return value
 }
 
+// getEnvAsDuration 获取时长类型环境变量（如 30s、2m），如果不存在或解析失败则返回默认值
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := time.ParseDuration(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
 // getEnvAsBool 获取布尔类型环境变量，支持 true/false（不区分大小写）
 func getEnvAsBool(key string, defaultValue bool) bool {
 	valueStr := os.Getenv(key)
@@ -84,6 +447,22 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	return value
 }
 
+// getEnvAsList 获取逗号分隔的列表环境变量，忽略空白项
+func getEnvAsList(key string, defaultValue []string) []string {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	values := make([]string, 0)
+	for item := range strings.SplitSeq(valueStr, ",") {
+		if trimmed := strings.TrimSpace(item); trimmed != "" {
+			values = append(values, trimmed)
+		}
+	}
+	return values
+}
+
 // generateRandomKey 生成指定长度的随机十六进制字符串
 func generateRandomKey(length int) string {
 	// 每个字节生成 2 个十六进制字符，所以需要 length/2 个字节
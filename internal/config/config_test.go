@@ -1,7 +1,11 @@
 package config_test
 
 import (
+	"os"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"main/internal/config"
 )
@@ -48,3 +52,269 @@ func TestLoadParsesDisableStaticAssetLogs(t *testing.T) {
 		t.Fatal("expected DisableStaticAssetLogs=true when env is true")
 	}
 }
+
+func TestLoadRequiresTrustedProxiesForProxyAuth(t *testing.T) {
+	t.Setenv("PROXY_AUTH_ENABLED", "true")
+	t.Setenv("TRUSTED_PROXIES", "")
+
+	if _, err := config.Load(); err == nil {
+		t.Fatal("expected error when PROXY_AUTH_ENABLED is set without TRUSTED_PROXIES")
+	}
+
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, not-a-cidr")
+	if _, err := config.Load(); err == nil {
+		t.Fatal("expected error for invalid TRUSTED_PROXIES entry")
+	}
+
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 127.0.0.1")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(cfg.TrustedProxies) != 2 || cfg.ProxyAuthDefaultRole != "viewer" {
+		t.Fatalf("unexpected proxy config: %+v", cfg.TrustedProxies)
+	}
+}
+
+func TestLoadValidatesTLSSettings(t *testing.T) {
+	t.Setenv("TLS_CERT_FILE", "server.crt")
+	t.Setenv("TLS_KEY_FILE", "")
+	if _, err := config.Load(); err == nil {
+		t.Fatal("expected error when TLS_KEY_FILE is missing")
+	}
+
+	t.Setenv("TLS_CERT_FILE", "")
+	t.Setenv("TLS_CLIENT_CA_FILE", "client-ca.crt")
+	if _, err := config.Load(); err == nil {
+		t.Fatal("expected error when TLS_CLIENT_CA_FILE is set without server certificate")
+	}
+
+	t.Setenv("TLS_CERT_FILE", "server.crt")
+	t.Setenv("TLS_KEY_FILE", "server.key")
+	t.Setenv("TLS_CLIENT_AUTH", "optional")
+	if _, err := config.Load(); err == nil {
+		t.Fatal("expected error for invalid TLS_CLIENT_AUTH")
+	}
+
+	t.Setenv("TLS_CLIENT_AUTH", "require")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.TLSClientAuth != "require" || cfg.MTLSUsernameField != "cn" {
+		t.Fatalf("unexpected tls config: auth=%q field=%q", cfg.TLSClientAuth, cfg.MTLSUsernameField)
+	}
+}
+
+func TestLoadTLSDefaults(t *testing.T) {
+	if cfg, err := config.Load(); err != nil || cfg.CookieSecure {
+		t.Fatalf("expected insecure cookie without tls, got secure=%v err=%v", cfg.CookieSecure, err)
+	}
+
+	t.Setenv("TLS_CERT_FILE", "server.crt")
+	t.Setenv("TLS_KEY_FILE", "server.key")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !cfg.CookieSecure || cfg.TLSReloadInterval != time.Minute || cfg.HTTPRedirectPort != 0 {
+		t.Fatalf("unexpected tls defaults: secure=%v reload=%s redirect=%d", cfg.CookieSecure, cfg.TLSReloadInterval, cfg.HTTPRedirectPort)
+	}
+
+	t.Setenv("COOKIE_SECURE", "false")
+	t.Setenv("HTTP_REDIRECT_PORT", "80")
+	if cfg, err = config.Load(); err != nil || cfg.CookieSecure || cfg.HTTPRedirectPort != 80 {
+		t.Fatalf("expected explicit COOKIE_SECURE to win, got secure=%v err=%v", cfg.CookieSecure, err)
+	}
+
+	t.Setenv("HTTP_REDIRECT_PORT", "8080")
+	if _, err := config.Load(); err == nil {
+		t.Fatal("expected redirect port equal to PORT to be rejected")
+	}
+
+	t.Setenv("HTTP_REDIRECT_PORT", "80")
+	t.Setenv("TLS_CERT_FILE", "")
+	t.Setenv("TLS_KEY_FILE", "")
+	if _, err := config.Load(); err == nil {
+		t.Fatal("expected HTTP_REDIRECT_PORT without tls to be rejected")
+	}
+
+	t.Setenv("HTTP_REDIRECT_PORT", "")
+	t.Setenv("HTTP3_ENABLED", "true")
+	if _, err := config.Load(); err == nil {
+		t.Fatal("expected HTTP3_ENABLED without tls to be rejected")
+	}
+}
+
+func TestLoadSecurityHeaderSettings(t *testing.T) {
+	t.Setenv("REFERRER_POLICY", "off")
+	t.Setenv("CONTENT_SECURITY_POLICY", "")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.ReferrerPolicy != "" {
+		t.Fatalf("expected off to disable Referrer-Policy, got %q", cfg.ReferrerPolicy)
+	}
+	if cfg.FrameAncestors != "'none'" || !strings.Contains(cfg.ContentSecurityPolicy, "{nonce}") {
+		t.Fatalf("unexpected defaults: frame=%q csp=%q", cfg.FrameAncestors, cfg.ContentSecurityPolicy)
+	}
+
+	t.Setenv("HSTS_MAX_AGE", "-1")
+	if _, err := config.Load(); err == nil {
+		t.Fatal("expected error for negative HSTS_MAX_AGE")
+	}
+}
+
+func TestLoadValidatesCORSSettings(t *testing.T) {
+	t.Setenv("COOKIE_SAMESITE", "none")
+	t.Setenv("COOKIE_SECURE", "false")
+	if _, err := config.Load(); err == nil {
+		t.Fatal("expected error when COOKIE_SAMESITE=none without COOKIE_SECURE")
+	}
+
+	t.Setenv("COOKIE_SECURE", "true")
+	for _, origins := range []string{"*", "https://console.example.com/app", "console.example.com"} {
+		t.Setenv("CORS_ALLOWED_ORIGINS", origins)
+		if _, err := config.Load(); err == nil {
+			t.Fatalf("expected error for CORS_ALLOWED_ORIGINS=%q", origins)
+		}
+	}
+
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://console.example.com, http://localhost:5173/")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(cfg.CORSAllowedOrigins) != 2 || cfg.CORSMaxAge != 600 {
+		t.Fatalf("unexpected cors config: %+v %d", cfg.CORSAllowedOrigins, cfg.CORSMaxAge)
+	}
+}
+
+func TestLoadParsesRateLimits(t *testing.T) {
+	t.Setenv("RATE_LIMIT_STATS", "off")
+	t.Setenv("RATE_LIMIT_AUTH", "5/m")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.RateLimitStats.Enabled() || cfg.RateLimitAuth.Requests != 5 || !cfg.RateLimitAPI.Enabled() ||
+		cfg.RateLimitAPIIP.Requests != 3000 {
+		t.Fatalf("unexpected rate limits: api=%+v auth=%+v stats=%+v", cfg.RateLimitAPI, cfg.RateLimitAuth, cfg.RateLimitStats)
+	}
+
+	t.Setenv("RATE_LIMIT_API", "fast")
+	if _, err := config.Load(); err == nil {
+		t.Fatal("expected error for invalid RATE_LIMIT_API")
+	}
+}
+
+func TestLoadParsesHTTPServerLimits(t *testing.T) {
+	t.Setenv("HTTP_READ_HEADER_TIMEOUT", "5s")
+	t.Setenv("HTTP_WRITE_TIMEOUT", "not-a-duration")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.HTTPReadHeaderTimeout != 5*time.Second || cfg.HTTPWriteTimeout != time.Minute || cfg.MaxRequestBodyBytes != 1<<20 {
+		t.Fatalf("unexpected http limits: %+v", cfg)
+	}
+
+	t.Setenv("HTTP_IDLE_TIMEOUT", "0s")
+	if _, err := config.Load(); err == nil {
+		t.Fatal("expected error for zero HTTP_IDLE_TIMEOUT")
+	}
+}
+
+func TestLoadParsesListenAddr(t *testing.T) {
+	t.Setenv("LISTEN_ADDR", "127.0.0.1:9090")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Port != 9090 || cfg.ListenHost != "127.0.0.1" || cfg.PublicURL != "http://localhost:9090" {
+		t.Fatalf("expected host and port from LISTEN_ADDR, got %q %d %q", cfg.ListenHost, cfg.Port, cfg.PublicURL)
+	}
+	if cfg.ListenSocketMode != 0o660 {
+		t.Fatalf("unexpected default socket mode %s", cfg.ListenSocketMode)
+	}
+
+	t.Setenv("LISTEN_ADDR", "unix:/run/app/app.sock")
+	t.Setenv("LISTEN_SOCKET_MODE", "0600")
+	cfg, err = config.Load()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.ListenAddr != "unix:/run/app/app.sock" || cfg.ListenSocketMode != 0o600 {
+		t.Fatalf("unexpected unix listen config %q %s", cfg.ListenAddr, cfg.ListenSocketMode)
+	}
+
+	for key, value := range map[string]string{
+		"LISTEN_ADDR":        "unix:",
+		"LISTEN_SOCKET_MODE": "0999",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			if _, err := config.Load(); err == nil {
+				t.Fatalf("expected error for %s=%q", key, value)
+			}
+		})
+	}
+	t.Setenv("LISTEN_SOCKET_MODE", "")
+	for _, addr := range []string{"localhost", ":0", ":70000"} {
+		t.Setenv("LISTEN_ADDR", addr)
+		if _, err := config.Load(); err == nil {
+			t.Fatalf("expected error for LISTEN_ADDR=%q", addr)
+		}
+	}
+}
+
+func TestLoadRejectsExtraListenersWithoutTCPListenAddr(t *testing.T) {
+	t.Setenv("TLS_CERT_FILE", "server.crt")
+	t.Setenv("TLS_KEY_FILE", "server.key")
+
+	activated := map[string]string{"LISTEN_PID": strconv.Itoa(os.Getpid()), "LISTEN_FDS": "1"}
+	unixSocket := map[string]string{"LISTEN_ADDR": "unix:/run/app/app.sock"}
+	for name, listener := range map[string]map[string]string{"unix": unixSocket, "systemd": activated} {
+		for key, value := range map[string]string{"HTTP_REDIRECT_PORT": "80", "HTTP3_ENABLED": "true"} {
+			t.Run(name+"/"+key, func(t *testing.T) {
+				for k, v := range listener {
+					t.Setenv(k, v)
+				}
+				t.Setenv(key, value)
+				if _, err := config.Load(); err == nil {
+					t.Fatalf("expected %s=%s to be rejected with %s listener", key, value, name)
+				}
+			})
+		}
+	}
+}
+
+func TestLoadParsesCompressionSettings(t *testing.T) {
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !cfg.CompressionEnabled || strings.Join(cfg.CompressionEncodings, ",") != "br,gzip" || cfg.CompressionMinSize != 1024 {
+		t.Fatalf("unexpected compression defaults: %t %v %d", cfg.CompressionEnabled, cfg.CompressionEncodings, cfg.CompressionMinSize)
+	}
+
+	t.Setenv("COMPRESSION_ENCODINGS", "zstd, br")
+	if cfg, err = config.Load(); err != nil || strings.Join(cfg.CompressionEncodings, ",") != "zstd,br" {
+		t.Fatalf("expected zstd,br, got %v (%v)", cfg, err)
+	}
+
+	t.Setenv("COMPRESSION_ENCODINGS", "deflate")
+	if _, err := config.Load(); err == nil {
+		t.Fatal("expected error for unsupported COMPRESSION_ENCODINGS")
+	}
+	t.Setenv("COMPRESSION_ENCODINGS", "")
+	t.Setenv("COMPRESSION_MIN_SIZE", "-1")
+	if _, err := config.Load(); err == nil {
+		t.Fatal("expected error for negative COMPRESSION_MIN_SIZE")
+	}
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// 本文件定义配置项的取值与默认值，middleware、session 等包从这里引用，config 不依赖它们
+
+// CSPNoncePlaceholder Content-Security-Policy 中的占位符，每个请求替换为新的 nonce
+const CSPNoncePlaceholder = "{nonce}"
+
+// DefaultContentSecurityPolicy 前端 SPA 的默认策略：脚本只允许同源文件与带 nonce 的标签，
+// 样式保留 'unsafe-inline' 以兼容组件库运行时注入的样式
+const DefaultContentSecurityPolicy = "default-src 'self'; " +
+	"script-src 'self' 'nonce-" + CSPNoncePlaceholder + "'; " +
+	"style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; " +
+	"font-src 'self'; " +
+	"connect-src 'self'; " +
+	"object-src 'none'; " +
+	"base-uri 'self'; " +
+	"form-action 'self'"
+
+// 动态压缩支持的编码
+const (
+	EncodingBrotli = "br"
+	EncodingZstd   = "zstd"
+	EncodingGzip   = "gzip"
+)
+
+// CompressionEncodings 动态压缩支持的全部编码
+var CompressionEncodings = []string{EncodingBrotli, EncodingZstd, EncodingGzip}
+
+// ParseSameSite 解析 lax、strict 或 none（不区分大小写）
+func ParseSameSite(value string) (http.SameSite, error) {
+	switch strings.ToLower(value) {
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("config: invalid SameSite mode %q", value)
+	}
+}
@@ -110,6 +110,17 @@ func (c *DBContainer) Path() string {
 	return c.path
 }
 
+// Checkpoint 将 WAL 中的内容写回主数据库文件并截断 WAL，关闭前调用可让数据库文件自包含
+func (c *DBContainer) Checkpoint(ctx context.Context) error {
+	if c == nil || c.db == nil {
+		return nil
+	}
+	if _, err := c.db.ExecContext(normalizeContext(ctx), "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("db: failed to checkpoint wal: %w", err)
+	}
+	return nil
+}
+
 // Close 关闭数据库连接
 func (c *DBContainer) Close() error {
 	if c == nil || c.db == nil {
@@ -13,12 +13,19 @@ type Migration struct {
 	Up      func(ctx context.Context, tx *sql.Tx) error
 }
 
-// migrations 模板项目默认不包含业务表迁移。
-// 使用模板时按需在此追加迁移，并确保 Version 递增且唯一。
-var migrations = []Migration{}
+// migrations 按 Version 递增顺序追加，已发布的迁移不可修改。
+var migrations = []Migration{
+	{Version: 1, Up: createUsersTable},
+	{Version: 2, Up: addUserTOTP},
+	{Version: 3, Up: addUserOIDCSubject},
+	{Version: 4, Up: createAPITokensTable},
+	{Version: 5, Up: createAuditEventsTable},
+	{Version: 6, Up: addAuditHashChain},
+	{Version: 7, Up: addUserExternalAuth},
+	{Version: 8, Up: createLoginLinkUsesTable},
+}
 
 // RunMigrations 执行所有未应用的迁移。
-// 模板默认 migrations 为空，将直接返回 nil，不会创建任何表。
 func RunMigrations(ctx context.Context, db *sql.DB) error {
 	ctx = normalizeContext(ctx)
 
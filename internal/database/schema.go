@@ -0,0 +1,126 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+func createUsersTable(ctx context.Context, tx *sql.Tx) error {
+	const ddl = `
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    password_hash TEXT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('admin', 'operator', 'viewer')),
+    disabled INTEGER NOT NULL DEFAULT 0,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);`
+	_, err := tx.ExecContext(ctx, ddl)
+	return err
+}
+
+func addUserTOTP(ctx context.Context, tx *sql.Tx) error {
+	const ddl = `
+ALTER TABLE users ADD COLUMN totp_secret TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN totp_enabled INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN totp_last_step INTEGER NOT NULL DEFAULT 0;
+CREATE TABLE user_recovery_codes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at INTEGER,
+    created_at INTEGER NOT NULL,
+    UNIQUE (user_id, code_hash)
+);`
+	_, err := tx.ExecContext(ctx, ddl)
+	return err
+}
+
+// addUserOIDCSubject SSO 账号按 issuer + subject 关联，subject 只在同一 issuer 内唯一
+func addUserOIDCSubject(ctx context.Context, tx *sql.Tx) error {
+	const ddl = `
+ALTER TABLE users ADD COLUMN oidc_issuer TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN oidc_subject TEXT NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX idx_users_oidc_identity ON users(oidc_issuer, oidc_subject) WHERE oidc_subject <> '';`
+	_, err := tx.ExecContext(ctx, ddl)
+	return err
+}
+
+func createAPITokensTable(ctx context.Context, tx *sql.Tx) error {
+	const ddl = `
+CREATE TABLE api_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    token_prefix TEXT NOT NULL,
+    scopes TEXT NOT NULL,
+    expires_at INTEGER,
+    last_used_at INTEGER,
+    created_at INTEGER NOT NULL
+);
+CREATE INDEX idx_api_tokens_user_id ON api_tokens(user_id);`
+	_, err := tx.ExecContext(ctx, ddl)
+	return err
+}
+
+func createAuditEventsTable(ctx context.Context, tx *sql.Tx) error {
+	const ddl = `
+CREATE TABLE audit_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at INTEGER NOT NULL,
+    actor TEXT NOT NULL DEFAULT '',
+    actor_id INTEGER NOT NULL DEFAULT 0,
+    session_id TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    action TEXT NOT NULL,
+    target TEXT NOT NULL DEFAULT '',
+    outcome TEXT NOT NULL CHECK (outcome IN ('success', 'failure')),
+    detail TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX idx_audit_events_created_at ON audit_events(created_at);
+CREATE INDEX idx_audit_events_actor ON audit_events(actor);
+CREATE INDEX idx_audit_events_action ON audit_events(action);`
+	_, err := tx.ExecContext(ctx, ddl)
+	return err
+}
+
+// addAuditHashChain 为审计记录加入哈希链。audit_chain_state 记录链的起点：
+// anchor_hash 为第一条现存记录应有的 prev_hash，pruned_through_id 为保留期清理删除到的最大 ID，
+// 两者均随保留期清理更新
+func addAuditHashChain(ctx context.Context, tx *sql.Tx) error {
+	const ddl = `
+ALTER TABLE audit_events ADD COLUMN prev_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE audit_events ADD COLUMN hash TEXT NOT NULL DEFAULT '';
+CREATE TABLE audit_chain_state (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    anchor_hash TEXT NOT NULL DEFAULT '',
+    pruned_through_id INTEGER NOT NULL DEFAULT 0
+);
+INSERT INTO audit_chain_state(id) VALUES (1);`
+	_, err := tx.ExecContext(ctx, ddl)
+	return err
+}
+
+// addUserExternalAuth 标记允许可信代理或客户端证书按用户名登录的账号
+func addUserExternalAuth(ctx context.Context, tx *sql.Tx) error {
+	const ddl = `
+ALTER TABLE users ADD COLUMN external_auth INTEGER NOT NULL DEFAULT 0;`
+	_, err := tx.ExecContext(ctx, ddl)
+	return err
+}
+
+func createLoginLinkUsesTable(ctx context.Context, tx *sql.Tx) error {
+	const ddl = `
+CREATE TABLE login_link_uses (
+    nonce TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    expires_at INTEGER NOT NULL,
+    used_at INTEGER NOT NULL
+);
+CREATE INDEX idx_login_link_uses_expires_at ON login_link_uses(expires_at);`
+	_, err := tx.ExecContext(ctx, ddl)
+	return err
+}
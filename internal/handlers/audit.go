@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apierror"
+	"main/internal/audit"
+	"main/internal/requestid"
+)
+
+// AuditHandler 审计日志查询处理器
+type AuditHandler struct {
+	audit       *audit.Store
+	checkpoints *audit.Checkpoints
+}
+
+// NewAuditHandler 创建审计日志处理器，checkpoints 为 nil 时校验不核对检查点
+func NewAuditHandler(auditLog *audit.Store, checkpoints *audit.Checkpoints) *AuditHandler {
+	return &AuditHandler{
+		audit:       auditLog,
+		checkpoints: checkpoints,
+	}
+}
+
+// ListEvents 按时间范围、操作者和事件类型查询审计日志，使用 cursor 翻页
+func (h *AuditHandler) ListEvents(c *gin.Context) {
+	from, okFrom := parseAuditTime(c.Query("from"))
+	to, okTo := parseAuditTime(c.Query("to"))
+	if !okFrom || !okTo {
+		apierror.Respond(c, apierror.CodeInvalidTime)
+		return
+	}
+
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value <= 0 {
+			apierror.Respond(c, apierror.CodeInvalidLimit)
+			return
+		}
+		limit = value
+	}
+
+	page, err := h.audit.Query(c.Request.Context(), audit.Filter{
+		From:   from,
+		To:     to,
+		Actor:  c.Query("actor"),
+		Action: audit.Action(c.Query("action")),
+		Cursor: c.Query("cursor"),
+		Limit:  limit,
+	})
+	if err != nil {
+		if errors.Is(err, audit.ErrInvalidCursor) {
+			apierror.Respond(c, apierror.CodeInvalidCursor)
+			return
+		}
+		requestid.Logger(c).Error("failed to query audit events", "error", err)
+		apierror.Respond(c, apierror.CodeInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// Verify 校验审计哈希链与签名检查点，返回第一处断链位置
+func (h *AuditHandler) Verify(c *gin.Context) {
+	result, err := h.audit.Verify(c.Request.Context(), h.checkpoints)
+	if err != nil {
+		requestid.Logger(c).Error("failed to verify audit chain", "error", err)
+		apierror.Respond(c, apierror.CodeInternal)
+		return
+	}
+
+	if !result.OK {
+		requestid.Logger(c).Warn(
+			"audit chain verification failed",
+			"broken_at",
+			result.BrokenAt,
+			"reason",
+			result.Reason,
+		)
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// parseAuditTime 支持 RFC 3339 与 Unix 秒，空字符串表示不限制
+func parseAuditTime(raw string) (time.Time, bool) {
+	if raw == "" {
+		return time.Time{}, true
+	}
+	if seconds, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(seconds, 0), true
+	}
+	parsed, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return parsed, true
+}
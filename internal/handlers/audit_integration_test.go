@@ -0,0 +1,119 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+type auditPageResponse struct {
+	Events []struct {
+		Actor     string `json:"actor"`
+		SessionID string `json:"session_id"`
+		IP        string `json:"ip"`
+		UserAgent string `json:"user_agent"`
+		Action    string `json:"action"`
+		Target    string `json:"target"`
+		Outcome   string `json:"outcome"`
+		Detail    string `json:"detail"`
+	} `json:"events"`
+	NextCursor string `json:"next_cursor"`
+}
+
+func queryAudit(t *testing.T, admin *testSession, query string) auditPageResponse {
+	t.Helper()
+
+	recorder := admin.do(http.MethodGet, "/api/audit"+query, nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected audit query status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var page auditPageResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &page); err != nil {
+		t.Fatalf("failed to parse audit response: %v", err)
+	}
+	return page
+}
+
+func TestAuditRecordsAuthenticationEvents(t *testing.T) {
+	env := newTestAPI(t, nil)
+
+	performRequestWithHeaders(env.router, http.MethodPost, "/api/login", loginBody("admin", "wrong-password"), map[string]string{
+		"User-Agent": "audit-test/1.0",
+	})
+	performRequest(env.router, http.MethodGet, "/api/audit", nil)
+	adminSession := env.loginAs(t, "admin", testAdminPassword)
+
+	body, _ := json.Marshal(map[string]string{"username": "olivia", "password": "operator-password", "role": "operator"})
+	if recorder := adminSession.do(http.MethodPost, "/api/users", body); recorder.Code != http.StatusCreated {
+		t.Fatalf("expected create user status 201, got %d", recorder.Code)
+	}
+
+	failures := queryAudit(t, adminSession, "?action=login&actor=admin")
+	var sawFailure, sawSuccess bool
+	for _, event := range failures.Events {
+		switch event.Outcome {
+		case "failure":
+			sawFailure = event.UserAgent == "audit-test/1.0" && event.IP != "" && event.Detail == "invalid credentials"
+		case "success":
+			sawSuccess = event.SessionID != "" && event.Detail == "password"
+		}
+	}
+	if !sawFailure || !sawSuccess {
+		t.Fatalf("expected failed and successful login events, got %+v", failures.Events)
+	}
+
+	unauthorized := queryAudit(t, adminSession, "?action=request.unauthorized")
+	if len(unauthorized.Events) != 1 || unauthorized.Events[0].Target != "GET /api/audit" {
+		t.Fatalf("expected unauthorized audit query to be recorded, got %+v", unauthorized.Events)
+	}
+
+	created := queryAudit(t, adminSession, "?action=user.create")
+	if len(created.Events) != 1 || created.Events[0].Actor != "admin" || created.Events[0].Target == "" {
+		t.Fatalf("expected user creation to be attributed to admin, got %+v", created.Events)
+	}
+
+	paged := queryAudit(t, adminSession, "?limit=1")
+	if len(paged.Events) != 1 || paged.NextCursor == "" {
+		t.Fatalf("expected paginated response, got %+v", paged)
+	}
+	next := queryAudit(t, adminSession, "?limit=1&cursor="+paged.NextCursor)
+	if len(next.Events) != 1 || next.Events[0] == paged.Events[0] {
+		t.Fatalf("expected next page to advance, got %+v", next)
+	}
+}
+
+func TestAuditRejectsInvalidFilters(t *testing.T) {
+	env := newTestAPI(t, nil)
+	adminSession := env.loginAs(t, "admin", testAdminPassword)
+
+	for _, query := range []string{"?from=yesterday", "?cursor=abc", "?limit=-1"} {
+		if recorder := adminSession.do(http.MethodGet, "/api/audit"+query, nil); recorder.Code != http.StatusBadRequest {
+			t.Fatalf("expected %s to be rejected, got %d", query, recorder.Code)
+		}
+	}
+
+	if recorder := adminSession.do(http.MethodGet, "/api/audit?from=2020-01-01T00:00:00Z&to=4102444800", nil); recorder.Code != http.StatusOK {
+		t.Fatalf("expected valid time range to succeed, got %d", recorder.Code)
+	}
+}
+
+func TestAuditVerifyReportsIntactChain(t *testing.T) {
+	env := newTestAPI(t, nil)
+	performRequest(env.router, http.MethodPost, "/api/login", loginBody("admin", "wrong-password"))
+	adminSession := env.loginAs(t, "admin", testAdminPassword)
+
+	recorder := adminSession.do(http.MethodGet, "/api/audit/verify", nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected verify status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var result struct {
+		OK      bool `json:"ok"`
+		Checked int  `json:"checked"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to parse verify response: %v", err)
+	}
+	if !result.OK || result.Checked != 2 {
+		t.Fatalf("expected intact chain with 2 events, got %+v", result)
+	}
+}
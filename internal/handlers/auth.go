@@ -1,163 +1,357 @@
 package handlers
 
 import (
-	"crypto/rand"
-	"encoding/hex"
-	"log/slog"
+	"errors"
 	"net/http"
 	"time"
 
 	ginsessions "github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 
+	"main/internal/apierror"
+	"main/internal/audit"
+	"main/internal/requestid"
 	"main/internal/session"
+	"main/internal/user"
 )
 
 // AuthHandler 认证处理器
 type AuthHandler struct {
-	authKey      string
-	cookieSecure bool
+	users   *user.Store
+	audit   *audit.Store
+	cookies session.CookiePolicy
 }
 
 // NewAuthHandler 创建认证处理器
-func NewAuthHandler(authKey string, cookieSecure bool) *AuthHandler {
+func NewAuthHandler(users *user.Store, auditLog *audit.Store, cookies session.CookiePolicy) *AuthHandler {
 	return &AuthHandler{
-		authKey:      authKey,
-		cookieSecure: cookieSecure,
+		users:   users,
+		audit:   auditLog,
+		cookies: cookies,
 	}
 }
 
 // LoginRequest 登录请求结构
 type LoginRequest struct {
-	AuthKey string `json:"auth_key" binding:"required"`
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
 }
 
 // LoginResponse 登录响应结构
 type LoginResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success     bool   `json:"success"`
+	Message     string `json:"message"`
+	MFARequired bool   `json:"mfa_required,omitempty"`
 }
 
 // Login 处理登录请求
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		slog.Warn("invalid login request", "error", err)
-		c.JSON(http.StatusBadRequest, LoginResponse{
-			Success: false,
-			Message: "请求格式错误",
-		})
+		requestid.Logger(c).Warn("invalid login request", "error", err)
+		apierror.RespondBindError(c, err)
 		return
 	}
 
-	// 验证 AUTH_KEY
-	if req.AuthKey != h.authKey {
-		slog.Warn("login failed: invalid auth key", "remote_addr", c.ClientIP())
-		c.JSON(http.StatusUnauthorized, LoginResponse{
-			Success: false,
-			Message: "认证失败，请检查令牌是否正确",
-		})
+	u, err := h.users.Authenticate(c.Request.Context(), req.Username, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrInvalidCredentials):
+			requestid.Logger(c).Warn("login failed: invalid credentials", "username", req.Username, "remote_addr", c.ClientIP())
+			h.audit.RecordRequest(c, audit.Event{
+				Actor:   req.Username,
+				Action:  audit.ActionLogin,
+				Outcome: audit.OutcomeFailure,
+				Detail:  "invalid credentials",
+			})
+			apierror.Respond(c, apierror.CodeInvalidCredentials)
+		case errors.Is(err, user.ErrDisabled):
+			requestid.Logger(c).Warn("login failed: account disabled", "username", req.Username, "remote_addr", c.ClientIP())
+			h.audit.RecordRequest(c, audit.Event{
+				Actor:   req.Username,
+				Action:  audit.ActionLogin,
+				Outcome: audit.OutcomeFailure,
+				Detail:  "account disabled",
+			})
+			apierror.Respond(c, apierror.CodeAccountDisabled)
+		default:
+			requestid.Logger(c).Error("failed to authenticate user", "username", req.Username, "error", err)
+			apierror.Respond(c, apierror.CodeInternal)
+		}
 		return
 	}
 
-	sessionID, err := generateSessionID()
-	if err != nil {
-		slog.Error("failed to generate session ID", "error", err)
-		c.JSON(http.StatusInternalServerError, LoginResponse{
-			Success: false,
-			Message: "服务器内部错误",
-		})
+	sess := ginsessions.Default(c)
+	if u.TOTPEnabled {
+		h.beginMFA(c, sess, u)
+		return
+	}
+
+	h.completeLogin(c, sess, u, "password")
+}
+
+// LoginTOTPRequest 两步验证请求，验证码与恢复码二选一
+type LoginTOTPRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// LoginTOTP 处理登录第二步：校验 TOTP 验证码或恢复码
+func (h *AuthHandler) LoginTOTP(c *gin.Context) {
+	var req LoginTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBindError(c, err)
+		return
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		apierror.Respond(c, apierror.CodeInvalidRequest)
 		return
 	}
 
 	sess := ginsessions.Default(c)
-	now := time.Now().Unix()
-	sess.Set("authenticated", true)
-	sess.Set("session_id", sessionID)
-	sess.Set("login_at", now)
-	sess.Set("last_seen_at", now)
-	session.SetCookieOptions(sess, h.cookieSecure, session.SessionMaxAgeSeconds)
-	if err := sess.Save(); err != nil {
-		slog.Error("failed to save session", "error", err)
-		c.JSON(http.StatusInternalServerError, LoginResponse{
-			Success: false,
-			Message: "服务器内部错误",
+	userID, ok := sess.Get("mfa_user_id").(int64)
+	startedAt, _ := sess.Get("mfa_started_at").(int64)
+	attempts, _ := sess.Get("mfa_attempts").(int)
+	if !ok || time.Since(time.Unix(startedAt, 0)) > session.MFAPendingTTL || attempts >= session.MFAMaxFailedAttempts {
+		clearInvalidSessionCookie(c, sess, h.cookies)
+		apierror.Respond(c, apierror.CodeMFAExpired)
+		return
+	}
+
+	u, err := h.users.GetByID(c.Request.Context(), userID)
+	if err != nil && !errors.Is(err, user.ErrNotFound) {
+		requestid.Logger(c).Error("failed to load mfa user", "user_id", userID, "error", err)
+		apierror.Respond(c, apierror.CodeInternal)
+		return
+	}
+	if u == nil || u.Disabled {
+		clearInvalidSessionCookie(c, sess, h.cookies)
+		apierror.Respond(c, apierror.CodeMFAExpired)
+		return
+	}
+
+	method := "totp"
+	if req.Code != "" {
+		err = h.users.VerifyTOTP(c.Request.Context(), u.ID, req.Code)
+	} else {
+		method = "recovery_code"
+		err = h.users.ConsumeRecoveryCode(c.Request.Context(), u.ID, req.RecoveryCode)
+	}
+	if err != nil {
+		if !errors.Is(err, user.ErrInvalidTOTPCode) && !errors.Is(err, user.ErrTOTPNotEnrolled) {
+			requestid.Logger(c).Error("failed to verify second factor", "username", u.Username, "error", err)
+			apierror.Respond(c, apierror.CodeInternal)
+			return
+		}
+
+		sess.Set("mfa_attempts", attempts+1)
+		if err := sess.Save(); err != nil {
+			requestid.Logger(c).Warn("failed to save mfa attempts", "error", err)
+		}
+		requestid.Logger(c).Warn(
+			"login failed: invalid second factor",
+			"username",
+			u.Username,
+			"method",
+			method,
+			"attempts",
+			attempts+1,
+			"remote_addr",
+			c.ClientIP(),
+		)
+		h.audit.RecordRequest(c, audit.Event{
+			Actor:   u.Username,
+			ActorID: u.ID,
+			Action:  audit.ActionLoginMFA,
+			Outcome: audit.OutcomeFailure,
+			Detail:  "invalid " + method,
 		})
+		apierror.Respond(c, apierror.CodeInvalidMFACode)
 		return
 	}
 
-	slog.Info("user logged in", "session_id", sessionID, "remote_addr", c.ClientIP())
+	h.completeLogin(c, sess, u, method)
+}
+
+// beginMFA 写入仅完成密码验证的半认证会话，等待第二步验证
+func (h *AuthHandler) beginMFA(c *gin.Context, sess ginsessions.Session, u *user.User) {
+	if err := h.startMFA(sess, u); err != nil {
+		requestid.Logger(c).Error("failed to save mfa session", "error", err)
+		apierror.Respond(c, apierror.CodeInternal)
+		return
+	}
+
+	requestid.Logger(c).Info("login requires second factor", "username", u.Username, "remote_addr", c.ClientIP())
 
+	c.JSON(http.StatusOK, LoginResponse{
+		Success:     false,
+		MFARequired: true,
+		Message:     apierror.Localize(c, apierror.NoticeMFARequired),
+	})
+}
+
+// startMFA 清空旧会话数据并写入半认证会话字段，由 LoginTOTP 完成登录
+func (h *AuthHandler) startMFA(sess ginsessions.Session, u *user.User) error {
+	sess.Clear()
+	sess.Set("mfa_user_id", u.ID)
+	sess.Set("mfa_started_at", time.Now().Unix())
+	sess.Set("mfa_attempts", 0)
+	session.SetCookieOptions(sess, h.cookies, session.MFAPendingMaxAge)
+	return sess.Save()
+}
+
+func (h *AuthHandler) completeLogin(c *gin.Context, sess ginsessions.Session, u *user.User, method string) {
+	sessionID, err := h.startSession(sess, u)
+	if err != nil {
+		requestid.Logger(c).Error("failed to start session", "error", err)
+		apierror.Respond(c, apierror.CodeInternal)
+		return
+	}
+
+	requestid.Logger(c).Info(
+		"user logged in",
+		"session_id",
+		sessionID,
+		"username",
+		u.Username,
+		"role",
+		u.Role,
+		"method",
+		method,
+		"remote_addr",
+		c.ClientIP(),
+	)
+	h.audit.RecordRequest(c, audit.Event{
+		Actor:     u.Username,
+		ActorID:   u.ID,
+		SessionID: sessionID,
+		Action:    audit.ActionLogin,
+		Outcome:   audit.OutcomeSuccess,
+		Detail:    method,
+	})
+
+	c.Header(session.CSRFHeaderName, session.CSRFToken(sess))
 	c.JSON(http.StatusOK, LoginResponse{
 		Success: true,
-		Message: "登录成功",
+		Message: apierror.Localize(c, apierror.NoticeLoginSucceeded),
 	})
 }
 
+// startSession 清空旧会话数据并写入已认证会话字段，返回新的会话 ID
+func (h *AuthHandler) startSession(sess ginsessions.Session, u *user.User) (string, error) {
+	return session.Start(sess, u.ID, u.Username, h.cookies)
+}
+
+// SessionUser 会话中的用户信息
+type SessionUser struct {
+	ID       int64     `json:"id"`
+	Username string    `json:"username"`
+	Role     user.Role `json:"role"`
+}
+
 // SessionStatusResponse 会话状态响应
 type SessionStatusResponse struct {
-	Authenticated bool   `json:"authenticated"`
-	Message       string `json:"message,omitempty"`
+	Authenticated bool         `json:"authenticated"`
+	User          *SessionUser `json:"user,omitempty"`
+	CSRFToken     string       `json:"csrf_token,omitempty"`
+}
+
+// LogoutResponse 登出响应
+type LogoutResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
 }
 
 // Session 验证当前会话是否有效
 func (h *AuthHandler) Session(c *gin.Context) {
 	sess := ginsessions.Default(c)
 	authenticated, ok := sess.Get("authenticated").(bool)
-	if !ok || !authenticated {
-		clearInvalidSessionCookie(sess, h.cookieSecure)
-		c.JSON(http.StatusUnauthorized, SessionStatusResponse{
-			Authenticated: false,
-			Message:       "未授权，请先登录",
-		})
+	userID, hasUser := sess.Get("user_id").(int64)
+	if !ok || !authenticated || !hasUser {
+		h.rejectSession(c, sess)
 		return
 	}
 
+	u, err := h.users.GetByID(c.Request.Context(), userID)
+	if err != nil && !errors.Is(err, user.ErrNotFound) {
+		requestid.Logger(c).Error("failed to load session user", "user_id", userID, "error", err)
+		apierror.Respond(c, apierror.CodeInternal)
+		return
+	}
+	if u == nil || u.Disabled {
+		h.rejectSession(c, sess)
+		return
+	}
+
+	// 升级前创建的会话没有 CSRF 令牌，在此补发
+	csrfToken := session.CSRFToken(sess)
+	if csrfToken == "" {
+		if csrfToken, err = session.RotateCSRFToken(sess); err != nil {
+			requestid.Logger(c).Error("failed to issue csrf token", "error", err)
+			apierror.Respond(c, apierror.CodeInternal)
+			return
+		}
+	}
+
 	sess.Set("last_seen_at", time.Now().Unix())
-	session.SetCookieOptions(sess, h.cookieSecure, session.SessionMaxAgeSeconds)
+	session.SetCookieOptions(sess, h.cookies, session.SessionMaxAgeSeconds)
 	if err := sess.Save(); err != nil {
-		slog.Warn("failed to refresh session", "error", err)
+		requestid.Logger(c).Warn("failed to refresh session", "error", err)
 	}
 
+	c.Header(session.CSRFHeaderName, csrfToken)
 	c.JSON(http.StatusOK, SessionStatusResponse{
 		Authenticated: true,
+		User: &SessionUser{
+			ID:       u.ID,
+			Username: u.Username,
+			Role:     u.Role,
+		},
+		CSRFToken: csrfToken,
 	})
 }
 
+func (h *AuthHandler) rejectSession(c *gin.Context, sess ginsessions.Session) {
+	if _, pending := sess.Get("mfa_user_id").(int64); !pending {
+		clearInvalidSessionCookie(c, sess, h.cookies)
+	}
+	apierror.Respond(c, apierror.CodeUnauthorized)
+}
+
 // Logout 处理登出请求
 func (h *AuthHandler) Logout(c *gin.Context) {
 	sess := ginsessions.Default(c)
 	sessionID, _ := sess.Get("session_id").(string)
+	username, _ := sess.Get("username").(string)
+	userID, _ := sess.Get("user_id").(int64)
 
-	session.ExpireCookie(sess, h.cookieSecure)
+	session.ExpireCookie(sess, h.cookies)
 	if err := sess.Save(); err != nil {
-		slog.Error("failed to clear session", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "服务器内部错误",
-		})
+		requestid.Logger(c).Error("failed to clear session", "error", err)
+		apierror.Respond(c, apierror.CodeInternal)
 		return
 	}
 
-	slog.Info("user logged out", "session_id", sessionID, "remote_addr", c.ClientIP())
+	requestid.Logger(c).Info("user logged out", "session_id", sessionID, "username", username, "remote_addr", c.ClientIP())
+	if username != "" {
+		h.audit.RecordRequest(c, audit.Event{
+			Actor:     username,
+			ActorID:   userID,
+			SessionID: sessionID,
+			Action:    audit.ActionLogout,
+			Outcome:   audit.OutcomeSuccess,
+		})
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "登出成功",
+	c.JSON(http.StatusOK, LogoutResponse{
+		Success: true,
+		Message: apierror.Localize(c, apierror.NoticeLoggedOut),
 	})
 }
 
-func clearInvalidSessionCookie(sess ginsessions.Session, secure bool) {
-	session.ExpireCookie(sess, secure)
+func clearInvalidSessionCookie(c *gin.Context, sess ginsessions.Session, cookies session.CookiePolicy) {
+	session.ExpireCookie(sess, cookies)
 	if err := sess.Save(); err != nil {
-		slog.Warn("failed to clear invalid session", "error", err)
-	}
-}
-
-func generateSessionID() (string, error) {
-	buf := make([]byte, 16)
-	if _, err := rand.Read(buf); err != nil {
-		return "", err
+		requestid.Logger(c).Warn("failed to clear invalid session", "error", err)
 	}
-	return hex.EncodeToString(buf), nil
 }
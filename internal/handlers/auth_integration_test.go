@@ -8,16 +8,9 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/gin-contrib/sessions"
-	"github.com/gin-contrib/sessions/memstore"
 	"github.com/gin-gonic/gin"
-
-	"main/internal/handlers"
-	"main/internal/middleware"
 )
 
-const testSessionMaxAgeSeconds = 7 * 24 * 60 * 60
-
 type loginResponse struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
@@ -26,39 +19,18 @@ type loginResponse struct {
 type sessionStatusResponse struct {
 	Authenticated bool   `json:"authenticated"`
 	Message       string `json:"message,omitempty"`
+	User          *struct {
+		Username string `json:"username"`
+		Role     string `json:"role"`
+	} `json:"user,omitempty"`
 }
 
-func newAuthTestRouter(authKey string) *gin.Engine {
-	gin.SetMode(gin.TestMode)
-
-	store := memstore.NewStore([]byte("0123456789abcdef0123456789abcdef"))
-	store.Options(sessions.Options{
-		Path:     "/",
-		MaxAge:   testSessionMaxAgeSeconds,
-		HttpOnly: true,
-		Secure:   false,
-		SameSite: http.SameSiteLaxMode,
+func loginBody(username, password string) []byte {
+	body, _ := json.Marshal(map[string]string{
+		"username": username,
+		"password": password,
 	})
-
-	authHandler := handlers.NewAuthHandler(authKey, false)
-
-	router := gin.New()
-	router.Use(sessions.Sessions("session_id", store))
-
-	api := router.Group("/api")
-	{
-		api.POST("/login", authHandler.Login)
-		api.GET("/session", authHandler.Session)
-		api.POST("/logout", authHandler.Logout)
-
-		protected := api.Group("")
-		protected.Use(middleware.AuthMiddleware(false))
-		protected.GET("/protected", func(c *gin.Context) {
-			c.JSON(http.StatusOK, gin.H{"ok": true})
-		})
-	}
-
-	return router
+	return body
 }
 
 func performRequest(
@@ -67,11 +39,25 @@ func performRequest(
 	path string,
 	body []byte,
 	cookies ...*http.Cookie,
+) *httptest.ResponseRecorder {
+	return performRequestWithHeaders(router, method, path, body, nil, cookies...)
+}
+
+func performRequestWithHeaders(
+	router *gin.Engine,
+	method string,
+	path string,
+	body []byte,
+	headers map[string]string,
+	cookies ...*http.Cookie,
 ) *httptest.ResponseRecorder {
 	req := httptest.NewRequest(method, path, bytes.NewReader(body))
 	if len(body) > 0 {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
 	for _, cookie := range cookies {
 		if cookie != nil {
 			req.AddCookie(cookie)
@@ -93,8 +79,8 @@ func findCookieByName(cookies []*http.Cookie, name string) *http.Cookie {
 }
 
 func TestLoginSetsSessionCookie(t *testing.T) {
-	router := newAuthTestRouter("top-secret-auth-key")
-	recorder := performRequest(router, http.MethodPost, "/api/login", []byte(`{"auth_key":"top-secret-auth-key"}`))
+	env := newTestAPI(t, nil)
+	recorder := performRequest(env.router, http.MethodPost, "/api/login", loginBody("admin", testAdminPassword))
 	if recorder.Code != http.StatusOK {
 		t.Fatalf("expected status 200, got %d", recorder.Code)
 	}
@@ -121,21 +107,21 @@ func TestLoginSetsSessionCookie(t *testing.T) {
 }
 
 func TestProtectedEndpointRequiresSession(t *testing.T) {
-	router := newAuthTestRouter("top-secret-auth-key")
-	recorder := performRequest(router, http.MethodGet, "/api/protected", nil)
+	env := newTestAPI(t, nil)
+	recorder := performRequest(env.router, http.MethodGet, "/api/dashboard/stats", nil)
 	if recorder.Code != http.StatusUnauthorized {
 		t.Fatalf("expected status 401, got %d", recorder.Code)
 	}
 }
 
 func TestLoginAllowsProtectedAndSessionEndpoints(t *testing.T) {
-	router := newAuthTestRouter("top-secret-auth-key")
+	env := newTestAPI(t, nil)
 
 	loginRecorder := performRequest(
-		router,
+		env.router,
 		http.MethodPost,
 		"/api/login",
-		[]byte(`{"auth_key":"top-secret-auth-key"}`),
+		loginBody("admin", testAdminPassword),
 	)
 	if loginRecorder.Code != http.StatusOK {
 		t.Fatalf("expected login status 200, got %d", loginRecorder.Code)
@@ -147,9 +133,9 @@ func TestLoginAllowsProtectedAndSessionEndpoints(t *testing.T) {
 	}
 
 	protectedRecorder := performRequest(
-		router,
+		env.router,
 		http.MethodGet,
-		"/api/protected",
+		"/api/dashboard/stats",
 		nil,
 		sessionCookie,
 	)
@@ -158,7 +144,7 @@ func TestLoginAllowsProtectedAndSessionEndpoints(t *testing.T) {
 	}
 
 	sessionRecorder := performRequest(
-		router,
+		env.router,
 		http.MethodGet,
 		"/api/session",
 		nil,
@@ -175,11 +161,31 @@ func TestLoginAllowsProtectedAndSessionEndpoints(t *testing.T) {
 	if !sessionResponse.Authenticated {
 		t.Fatalf("expected authenticated session response, got %+v", sessionResponse)
 	}
+	if sessionResponse.User == nil || sessionResponse.User.Username != "admin" || sessionResponse.User.Role != "admin" {
+		t.Fatalf("expected session user admin/admin, got %+v", sessionResponse.User)
+	}
+}
+
+func TestLoginRejectsWrongPassword(t *testing.T) {
+	env := newTestAPI(t, nil)
+
+	for _, body := range [][]byte{
+		loginBody("admin", "wrong-password"),
+		loginBody("nobody", testAdminPassword),
+	} {
+		recorder := performRequest(env.router, http.MethodPost, "/api/login", body)
+		if recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("expected status 401 for %s, got %d", body, recorder.Code)
+		}
+		if findCookieByName(recorder.Result().Cookies(), "session_id") != nil {
+			t.Fatalf("expected no session cookie for %s", body)
+		}
+	}
 }
 
 func TestSessionEndpointReturnsUnauthorizedWithoutCookie(t *testing.T) {
-	router := newAuthTestRouter("top-secret-auth-key")
-	recorder := performRequest(router, http.MethodGet, "/api/session", nil)
+	env := newTestAPI(t, nil)
+	recorder := performRequest(env.router, http.MethodGet, "/api/session", nil)
 	if recorder.Code != http.StatusUnauthorized {
 		t.Fatalf("expected status 401, got %d", recorder.Code)
 	}
@@ -191,8 +197,8 @@ func TestSessionEndpointReturnsUnauthorizedWithoutCookie(t *testing.T) {
 }
 
 func TestProtectedEndpointClearsCookieWhenUnauthorized(t *testing.T) {
-	router := newAuthTestRouter("top-secret-auth-key")
-	recorder := performRequest(router, http.MethodGet, "/api/protected", nil)
+	env := newTestAPI(t, nil)
+	recorder := performRequest(env.router, http.MethodGet, "/api/dashboard/stats", nil)
 	if recorder.Code != http.StatusUnauthorized {
 		t.Fatalf("expected status 401, got %d", recorder.Code)
 	}
@@ -204,41 +210,15 @@ func TestProtectedEndpointClearsCookieWhenUnauthorized(t *testing.T) {
 }
 
 func TestLogoutInvalidatesSession(t *testing.T) {
-	router := newAuthTestRouter("top-secret-auth-key")
-
-	loginRecorder := performRequest(
-		router,
-		http.MethodPost,
-		"/api/login",
-		[]byte(`{"auth_key":"top-secret-auth-key"}`),
-	)
-	if loginRecorder.Code != http.StatusOK {
-		t.Fatalf("expected login status 200, got %d", loginRecorder.Code)
-	}
+	env := newTestAPI(t, nil)
+	admin := env.loginAs(t, "admin", testAdminPassword)
 
-	sessionCookie := findCookieByName(loginRecorder.Result().Cookies(), "session_id")
-	if sessionCookie == nil {
-		t.Fatal("expected session_id cookie in login response")
-	}
-
-	logoutRecorder := performRequest(
-		router,
-		http.MethodPost,
-		"/api/logout",
-		nil,
-		sessionCookie,
-	)
+	logoutRecorder := admin.do(http.MethodPost, "/api/logout", nil)
 	if logoutRecorder.Code != http.StatusOK {
 		t.Fatalf("expected logout status 200, got %d", logoutRecorder.Code)
 	}
 
-	protectedRecorder := performRequest(
-		router,
-		http.MethodGet,
-		"/api/protected",
-		nil,
-		sessionCookie,
-	)
+	protectedRecorder := admin.do(http.MethodGet, "/api/dashboard/stats", nil)
 	if protectedRecorder.Code != http.StatusUnauthorized {
 		t.Fatalf("expected protected endpoint status 401 after logout, got %d", protectedRecorder.Code)
 	}
@@ -0,0 +1,38 @@
+package handlers_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"main/internal/config"
+)
+
+func TestBodyLimitRejectsOversizedLogin(t *testing.T) {
+	router := newTestAPI(t, func(cfg *config.Config) {
+		cfg.MaxRequestBodyBytes = 1024
+	}).router
+
+	if recorder := performRequest(router, http.MethodPost, "/api/login", loginBody("admin", testAdminPassword)); recorder.Code != http.StatusOK {
+		t.Fatalf("expected small login body to succeed, got %d", recorder.Code)
+	}
+
+	oversized := loginBody("admin", strings.Repeat("x", 2048))
+	recorder := performRequest(router, http.MethodPost, "/api/login", oversized)
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for declared oversized body, got %d", recorder.Code)
+	}
+
+	// 分块传输不声明长度，读取超限时同样返回 413
+	req := httptest.NewRequest(http.MethodPost, "/api/login", io.MultiReader(bytes.NewReader(oversized)))
+	req.ContentLength = -1
+	req.Header.Set("Content-Type", "application/json")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for chunked oversized body, got %d", recorder.Code)
+	}
+}
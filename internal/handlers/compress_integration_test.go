@@ -0,0 +1,154 @@
+package handlers_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+
+	"main/internal/config"
+	"main/internal/stream"
+)
+
+func newCompressTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+
+	return newTestAPI(t, func(cfg *config.Config) {
+		cfg.CompressionEnabled = true
+		cfg.CompressionEncodings = config.CompressionEncodings
+		cfg.CompressionMinSize = 1024
+	})
+}
+
+func decodeBody(t *testing.T, encoding string, body io.Reader) io.Reader {
+	t.Helper()
+	switch encoding {
+	case "br":
+		return brotli.NewReader(body)
+	case "zstd":
+		zr, err := zstd.NewReader(body)
+		if err != nil {
+			t.Fatalf("zstd reader: %v", err)
+		}
+		t.Cleanup(zr.Close)
+		return zr
+	case "gzip":
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			t.Fatalf("gzip reader: %v", err)
+		}
+		return zr
+	}
+	return body
+}
+
+func TestCompressNegotiatesEncoding(t *testing.T) {
+	router := newCompressTestAPI(t).router
+	// OpenAPI 文档远大于压缩阈值
+	want := performRequest(router, http.MethodGet, "/api/openapi.json", nil).Body.Bytes()
+
+	cases := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{"gzip, deflate, br, zstd", "br"},
+		{"gzip;q=1.0, br;q=0.5", "gzip"},
+		{"zstd", "zstd"},
+		{"*", "br"},
+		{"br;q=0, *;q=0.1", "zstd"},
+		{"deflate", ""},
+		{"", ""},
+	}
+	for _, tc := range cases {
+		recorder := performRequestWithHeaders(router, http.MethodGet, "/api/openapi.json", nil,
+			map[string]string{"Accept-Encoding": tc.acceptEncoding})
+
+		if got := recorder.Header().Get("Content-Encoding"); got != tc.want {
+			t.Fatalf("Accept-Encoding %q: expected encoding %q, got %q", tc.acceptEncoding, tc.want, got)
+		}
+		if got := recorder.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Fatalf("Accept-Encoding %q: expected Vary: Accept-Encoding, got %q", tc.acceptEncoding, got)
+		}
+		body, err := io.ReadAll(decodeBody(t, tc.want, recorder.Body))
+		if err != nil || !bytes.Equal(body, want) {
+			t.Fatalf("Accept-Encoding %q: decoded body mismatch (%v)", tc.acceptEncoding, err)
+		}
+		if tc.want != "" && recorder.Body.Len() >= len(want) {
+			t.Fatalf("Accept-Encoding %q: expected compressed body to be smaller", tc.acceptEncoding)
+		}
+	}
+}
+
+func TestCompressSkipsSmallResponses(t *testing.T) {
+	router := newCompressTestAPI(t).router
+
+	recorder := performRequestWithHeaders(router, http.MethodGet, "/api/auth/methods", nil,
+		map[string]string{"Accept-Encoding": "gzip"})
+	if recorder.Header().Get("Content-Encoding") != "" || recorder.Body.String() != `{"password":true,"oidc":false}` {
+		t.Fatalf("expected uncompressed small response, got %v %q", recorder.Header(), recorder.Body.String())
+	}
+	// 是否压缩取决于 Accept-Encoding，未压缩的响应同样需要 Vary，避免缓存混用
+	if got := recorder.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Fatalf("expected Vary: Accept-Encoding, got %q", got)
+	}
+}
+
+func TestCompressFlushesEachSSEEvent(t *testing.T) {
+	env := newCompressTestAPI(t)
+	adminCookie := env.loginAs(t, "admin", testAdminPassword).cookie
+	srv := httptest.NewServer(env.router)
+	t.Cleanup(srv.Close)
+
+	for _, encoding := range []string{"br", "zstd", "gzip"} {
+		t.Run(encoding, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, srv.URL+"/api/logs/stream?history=0", nil)
+			if err != nil {
+				t.Fatalf("build request: %v", err)
+			}
+			req.AddCookie(adminCookie)
+			// 显式设置后 Transport 不再自动解压，可以检查原始编码
+			req.Header.Set("Accept-Encoding", encoding)
+			resp, err := srv.Client().Do(req)
+			if err != nil {
+				t.Fatalf("open stream: %v", err)
+			}
+			defer resp.Body.Close()
+			if got := resp.Header.Get("Content-Encoding"); got != encoding {
+				t.Fatalf("expected %s stream, got %q", encoding, got)
+			}
+
+			events := bufio.NewScanner(decodeBody(t, encoding, resp.Body))
+			// 连接保持打开，每条日志都必须在压缩流刷新后立即可读
+			for _, message := range []string{"first", "second"} {
+				env.logs.Broadcast(stream.LogEntry{Time: time.Now().Format(time.RFC3339), Level: "INFO", Message: message})
+
+				received := make(chan string, 1)
+				go func() {
+					for events.Scan() {
+						if data, ok := strings.CutPrefix(events.Text(), "data:"); ok {
+							received <- data
+							return
+						}
+					}
+					close(received)
+				}()
+				select {
+				case data := <-received:
+					if !strings.Contains(data, `"msg":"`+message+`"`) {
+						t.Fatalf("expected %q event, got %q", message, data)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatalf("timed out waiting for %q event", message)
+				}
+			}
+		})
+	}
+}
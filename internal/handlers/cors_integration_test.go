@@ -0,0 +1,100 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/config"
+)
+
+const testFrontendOrigin = "https://console.example.net"
+
+func newCORSTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+
+	return newTestAPI(t, func(cfg *config.Config) {
+		cfg.CORSAllowedOrigins = []string{testFrontendOrigin}
+		cfg.CORSMaxAge = 600
+		cfg.CookieSecure = true
+		cfg.CookieSameSite = "none"
+	}).router
+}
+
+func TestCORSPreflight(t *testing.T) {
+	router := newCORSTestRouter(t)
+
+	recorder := performRequestWithHeaders(router, http.MethodOptions, "/api/login", nil, map[string]string{
+		"Origin":                         testFrontendOrigin,
+		"Access-Control-Request-Method":  http.MethodPost,
+		"Access-Control-Request-Headers": "content-type,x-csrf-token",
+	})
+	if recorder.Code != http.StatusNoContent {
+		t.Fatalf("expected preflight status 204, got %d", recorder.Code)
+	}
+	want := map[string]string{
+		"Access-Control-Allow-Origin":      testFrontendOrigin,
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Max-Age":           "600",
+	}
+	for header, value := range want {
+		if got := recorder.Header().Get(header); got != value {
+			t.Errorf("%s = %q, want %q", header, got, value)
+		}
+	}
+	if recorder.Header().Get("Access-Control-Allow-Headers") == "" || recorder.Header().Get("Access-Control-Allow-Methods") == "" {
+		t.Fatalf("expected allow headers and methods, got %v", recorder.Header())
+	}
+
+	recorder = performRequestWithHeaders(router, http.MethodOptions, "/api/login", nil, map[string]string{
+		"Origin":                        "https://evil.example.com",
+		"Access-Control-Request-Method": http.MethodPost,
+	})
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected disallowed preflight status 403, got %d", recorder.Code)
+	}
+	if recorder.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("expected no CORS headers for disallowed origin, got %v", recorder.Header())
+	}
+}
+
+func TestCORSCredentialedLoginFromAllowedOrigin(t *testing.T) {
+	router := newCORSTestRouter(t)
+
+	recorder := performRequestWithHeaders(router, http.MethodPost, "/api/login", loginBody("admin", testAdminPassword), map[string]string{
+		"Origin":         testFrontendOrigin,
+		"Sec-Fetch-Site": "cross-site",
+	})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected login from allowed origin to succeed, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Header().Get("Access-Control-Allow-Origin") != testFrontendOrigin ||
+		recorder.Header().Get("Access-Control-Expose-Headers") == "" {
+		t.Fatalf("expected CORS headers on response, got %v", recorder.Header())
+	}
+
+	cookie := findCookieByName(recorder.Result().Cookies(), "session_id")
+	if cookie == nil || cookie.SameSite != http.SameSiteNoneMode || !cookie.Secure {
+		t.Fatalf("expected SameSite=None; Secure session cookie, got %+v", cookie)
+	}
+
+	recorder = performRequestWithHeaders(router, http.MethodPost, "/api/login", loginBody("admin", testAdminPassword), map[string]string{
+		"Origin":         "https://evil.example.com",
+		"Sec-Fetch-Site": "cross-site",
+	})
+	if recorder.Code != http.StatusForbidden || recorder.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("expected cross-site login from disallowed origin to be rejected, got %d %v", recorder.Code, recorder.Header())
+	}
+}
+
+func TestCORSIgnoresSameOriginRequests(t *testing.T) {
+	router := newCORSTestRouter(t)
+
+	recorder := performRequestWithHeaders(router, http.MethodGet, "/api/session", nil, map[string]string{
+		"Origin": testSameOrigin,
+	})
+	if recorder.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("expected no CORS headers for same-origin request, got %v", recorder.Header())
+	}
+}
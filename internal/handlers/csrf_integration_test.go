@@ -0,0 +1,130 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apitoken"
+	"main/internal/config"
+)
+
+// httptest.NewRequest 默认 Host 为 example.com
+const testSameOrigin = "http://example.com"
+
+func newCSRFTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+
+	return newTestAPI(t, func(cfg *config.Config) {
+		cfg.CSRFTrustedOrigins = []string{"https://console.example.net"}
+	})
+}
+
+func fetchCSRFToken(t *testing.T, router *gin.Engine, cookie *http.Cookie) string {
+	t.Helper()
+
+	recorder := performRequest(router, http.MethodGet, "/api/session", nil, cookie)
+	var response struct {
+		CSRFToken string `json:"csrf_token"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to parse session response: %v", err)
+	}
+	if response.CSRFToken == "" || recorder.Header().Get("X-CSRF-Token") != response.CSRFToken {
+		t.Fatalf("expected csrf token in session response, got %s", recorder.Body.String())
+	}
+	return response.CSRFToken
+}
+
+func TestCSRFRejectsCrossOriginLogin(t *testing.T) {
+	env := newCSRFTestAPI(t)
+	body := loginBody("admin", testAdminPassword)
+
+	rejected := []map[string]string{
+		{"Sec-Fetch-Site": "cross-site", "Origin": "https://evil.example.org"},
+		{"Sec-Fetch-Site": "same-site", "Origin": "http://sub.example.com"},
+		{"Origin": "https://evil.example.org"},
+		{"Origin": "null"},
+	}
+	for _, headers := range rejected {
+		recorder := performRequestWithHeaders(env.router, http.MethodPost, "/api/login", body, headers)
+		if recorder.Code != http.StatusForbidden {
+			t.Fatalf("expected cross-origin login %v to be rejected, got %d", headers, recorder.Code)
+		}
+	}
+
+	accepted := []map[string]string{
+		{"Sec-Fetch-Site": "same-origin", "Origin": testSameOrigin},
+		{"Origin": testSameOrigin},
+		{"Sec-Fetch-Site": "cross-site", "Origin": "https://console.example.net"},
+		nil,
+	}
+	for _, headers := range accepted {
+		recorder := performRequestWithHeaders(env.router, http.MethodPost, "/api/login", body, headers)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected same-origin login %v to succeed, got %d", headers, recorder.Code)
+		}
+	}
+}
+
+func TestCSRFRequiresSessionTokenForAuthenticatedWrites(t *testing.T) {
+	env := newCSRFTestAPI(t)
+	cookie := env.loginAs(t, "admin", testAdminPassword).cookie
+	sameOrigin := map[string]string{"Sec-Fetch-Site": "same-origin"}
+
+	if recorder := performRequestWithHeaders(env.router, http.MethodPost, "/api/account/totp/setup", nil, sameOrigin, cookie); recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected write without csrf token to be rejected, got %d", recorder.Code)
+	}
+
+	wrongToken := map[string]string{"Sec-Fetch-Site": "same-origin", "X-CSRF-Token": "forged"}
+	if recorder := performRequestWithHeaders(env.router, http.MethodPost, "/api/account/totp/setup", nil, wrongToken, cookie); recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected write with wrong csrf token to be rejected, got %d", recorder.Code)
+	}
+
+	token := fetchCSRFToken(t, env.router, cookie)
+	withToken := map[string]string{"Sec-Fetch-Site": "same-origin", "X-CSRF-Token": token}
+	if recorder := performRequestWithHeaders(env.router, http.MethodPost, "/api/account/totp/setup", nil, withToken, cookie); recorder.Code != http.StatusOK {
+		t.Fatalf("expected write with csrf token to succeed, got %d", recorder.Code)
+	}
+
+	crossSite := map[string]string{"Sec-Fetch-Site": "cross-site", "X-CSRF-Token": token}
+	if recorder := performRequestWithHeaders(env.router, http.MethodPost, "/api/logout", nil, crossSite, cookie); recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected cross-site logout to be rejected, got %d", recorder.Code)
+	}
+	if recorder := performRequestWithHeaders(env.router, http.MethodPost, "/api/logout", nil, withToken, cookie); recorder.Code != http.StatusOK {
+		t.Fatalf("expected logout with csrf token to succeed, got %d", recorder.Code)
+	}
+}
+
+func TestCSRFExemptsBearerTokenRequests(t *testing.T) {
+	env := newCSRFTestAPI(t)
+	admin, err := env.users.GetByUsername(t.Context(), "admin")
+	if err != nil {
+		t.Fatalf("load admin: %v", err)
+	}
+	_, plaintext, err := env.tokens.Create(t.Context(), admin, apitoken.CreateParams{
+		Name:   "cron",
+		Scopes: []string{"stats:read"},
+	})
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+
+	headers := map[string]string{
+		"Authorization":  "Bearer " + plaintext,
+		"Sec-Fetch-Site": "cross-site",
+		"Origin":         "https://automation.example.org",
+	}
+	// 登出接口不要求登录，跨站请求能通过说明 CSRF 检查被跳过
+	if recorder := performRequestWithHeaders(env.router, http.MethodPost, "/api/logout", nil, headers); recorder.Code != http.StatusOK {
+		t.Fatalf("expected bearer request to skip csrf checks, got %d", recorder.Code)
+	}
+
+	// Bearer 头不能用来绕过仅限会话接口的 CSRF 校验
+	cookie := env.loginAs(t, "admin", testAdminPassword).cookie
+	if recorder := performRequestWithHeaders(env.router, http.MethodPost, "/api/account/totp/setup", nil, headers, cookie); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected bearer header on session-only route to be rejected, got %d", recorder.Code)
+	}
+}
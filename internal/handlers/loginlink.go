@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	ginsessions "github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+
+	"main/internal/audit"
+	"main/internal/loginlink"
+	"main/internal/requestid"
+	"main/internal/user"
+)
+
+const (
+	loginLinkErrorRedirect = "/login?error=link"
+	// loginLinkMFARedirect 启用了两步验证的用户消费链接后进入登录页的验证码步骤
+	loginLinkMFARedirect = "/login?mfa=1"
+)
+
+// LoginLinkHandler 一次性登录链接处理器
+type LoginLinkHandler struct {
+	auth  *AuthHandler
+	users *user.Store
+	links *loginlink.Store
+}
+
+// NewLoginLinkHandler 创建一次性登录链接处理器
+func NewLoginLinkHandler(auth *AuthHandler, users *user.Store, links *loginlink.Store) *LoginLinkHandler {
+	return &LoginLinkHandler{
+		auth:  auth,
+		users: users,
+		links: links,
+	}
+}
+
+// Consume 校验并消费登录链接，建立与密码登录相同的会话后跳转到前端；
+// 启用两步验证的用户与密码登录一样只得到半认证会话，需再通过 /login/totp 完成登录
+func (h *LoginLinkHandler) Consume(c *gin.Context) {
+	sess := ginsessions.Default(c)
+	// 链接中的令牌不应通过 Referer 泄露给跳转后的页面
+	c.Header("Referrer-Policy", "no-referrer")
+
+	fail := func(reason string, attrs ...any) {
+		requestid.Logger(c).Warn("login link rejected", append([]any{"reason", reason, "remote_addr", c.ClientIP()}, attrs...)...)
+		h.auth.audit.RecordRequest(c, audit.Event{
+			Action:  audit.ActionLogin,
+			Outcome: audit.OutcomeFailure,
+			Detail:  "login link: " + reason,
+		})
+		c.Redirect(http.StatusFound, loginLinkErrorRedirect)
+	}
+
+	userID, err := h.links.Consume(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		switch {
+		case errors.Is(err, loginlink.ErrExpired):
+			fail("expired")
+		case errors.Is(err, loginlink.ErrUsed):
+			fail("already used")
+		case errors.Is(err, loginlink.ErrInvalid):
+			fail("invalid token")
+		default:
+			requestid.Logger(c).Error("failed to consume login link", "error", err)
+			c.Redirect(http.StatusFound, loginLinkErrorRedirect)
+		}
+		return
+	}
+
+	u, err := h.users.GetByID(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, user.ErrNotFound) {
+			fail("user not found", "user_id", userID)
+			return
+		}
+		requestid.Logger(c).Error("failed to load login link user", "user_id", userID, "error", err)
+		c.Redirect(http.StatusFound, loginLinkErrorRedirect)
+		return
+	}
+	if u.Disabled {
+		fail("account disabled", "username", u.Username)
+		return
+	}
+
+	if u.TOTPEnabled {
+		if err := h.auth.startMFA(sess, u); err != nil {
+			requestid.Logger(c).Error("failed to save mfa session", "error", err)
+			c.Redirect(http.StatusFound, loginLinkErrorRedirect)
+			return
+		}
+		requestid.Logger(c).Info(
+			"login requires second factor",
+			"username",
+			u.Username,
+			"method",
+			"login_link",
+			"remote_addr",
+			c.ClientIP(),
+		)
+		c.Redirect(http.StatusFound, loginLinkMFARedirect)
+		return
+	}
+
+	sessionID, err := h.auth.startSession(sess, u)
+	if err != nil {
+		requestid.Logger(c).Error("failed to start session", "error", err)
+		c.Redirect(http.StatusFound, loginLinkErrorRedirect)
+		return
+	}
+
+	requestid.Logger(c).Info(
+		"user logged in",
+		"session_id",
+		sessionID,
+		"username",
+		u.Username,
+		"role",
+		u.Role,
+		"method",
+		"login_link",
+		"remote_addr",
+		c.ClientIP(),
+	)
+	h.auth.audit.RecordRequest(c, audit.Event{
+		Actor:     u.Username,
+		ActorID:   u.ID,
+		SessionID: sessionID,
+		Action:    audit.ActionLogin,
+		Outcome:   audit.OutcomeSuccess,
+		Detail:    "login link",
+	})
+
+	c.Redirect(http.StatusFound, defaultLoginRedirect)
+}
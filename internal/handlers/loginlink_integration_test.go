@@ -0,0 +1,122 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"main/internal/audit"
+	"main/internal/loginlink"
+	"main/internal/totp"
+	"main/internal/user"
+)
+
+// newLoginLinkTestAPI 返回测试环境与共用其数据目录签名密钥的登录链接存储，用于签发链接
+func newLoginLinkTestAPI(t *testing.T) (*testAPI, *loginlink.Store) {
+	t.Helper()
+
+	env := newTestAPI(t, nil)
+	return env, loginlink.NewStore(env.db, env.cfg.DataDir)
+}
+
+func TestLoginLinkCreatesSessionOnce(t *testing.T) {
+	env, links := newLoginLinkTestAPI(t)
+	admin, err := env.users.GetByUsername(t.Context(), "admin")
+	if err != nil {
+		t.Fatalf("load admin: %v", err)
+	}
+	token, _, err := links.Mint(admin.ID, time.Minute)
+	if err != nil {
+		t.Fatalf("mint login link: %v", err)
+	}
+
+	recorder := performRequest(env.router, http.MethodGet, "/api/auth/magic?token="+token, nil)
+	if recorder.Code != http.StatusFound || recorder.Header().Get("Location") != "/dashboard" {
+		t.Fatalf("expected redirect to dashboard, got %d %q", recorder.Code, recorder.Header().Get("Location"))
+	}
+	if protected := env.sessionFrom(t, recorder).do(http.MethodGet, "/api/dashboard/stats", nil); protected.Code != http.StatusOK {
+		t.Fatalf("expected login link session to access protected route, got %d", protected.Code)
+	}
+
+	reused := performRequest(env.router, http.MethodGet, "/api/auth/magic?token="+token, nil)
+	if reused.Code != http.StatusFound || reused.Header().Get("Location") != "/login?error=link" {
+		t.Fatalf("expected reused link to redirect to login error, got %d %q", reused.Code, reused.Header().Get("Location"))
+	}
+	if findCookieByName(reused.Result().Cookies(), "session_id") != nil {
+		t.Fatal("expected reused link not to issue a session")
+	}
+
+	page, err := env.auditLog.Query(t.Context(), audit.Filter{Action: audit.ActionLogin})
+	if err != nil {
+		t.Fatalf("query audit: %v", err)
+	}
+	if len(page.Events) != 2 ||
+		page.Events[0].Outcome != audit.OutcomeFailure || page.Events[0].Detail != "login link: already used" ||
+		page.Events[1].Outcome != audit.OutcomeSuccess || page.Events[1].Actor != "admin" {
+		t.Fatalf("unexpected audit events: %+v", page.Events)
+	}
+}
+
+func TestLoginLinkRejectsDisabledUser(t *testing.T) {
+	env, links := newLoginLinkTestAPI(t)
+	viewer, err := env.users.Create(t.Context(), "viewer-dan", "viewer-password", user.RoleViewer)
+	if err != nil {
+		t.Fatalf("create viewer: %v", err)
+	}
+	token, _, err := links.Mint(viewer.ID, time.Minute)
+	if err != nil {
+		t.Fatalf("mint login link: %v", err)
+	}
+	disabled := true
+	if _, err := env.users.Update(t.Context(), viewer.ID, user.UpdateParams{Disabled: &disabled}); err != nil {
+		t.Fatalf("disable viewer: %v", err)
+	}
+
+	recorder := performRequest(env.router, http.MethodGet, "/api/auth/magic?token="+token, nil)
+	if recorder.Header().Get("Location") != "/login?error=link" {
+		t.Fatalf("expected disabled user link to be rejected, got %q", recorder.Header().Get("Location"))
+	}
+}
+
+func TestLoginLinkRequiresSecondFactor(t *testing.T) {
+	env, links := newLoginLinkTestAPI(t)
+	admin, err := env.users.GetByUsername(t.Context(), "admin")
+	if err != nil {
+		t.Fatalf("load admin: %v", err)
+	}
+	secret, err := env.users.BeginTOTPEnrollment(t.Context(), admin.ID)
+	if err != nil {
+		t.Fatalf("begin totp enrollment: %v", err)
+	}
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+	recoveryCodes, err := env.users.ConfirmTOTPEnrollment(t.Context(), admin.ID, code)
+	if err != nil {
+		t.Fatalf("confirm totp enrollment: %v", err)
+	}
+	token, _, err := links.Mint(admin.ID, time.Minute)
+	if err != nil {
+		t.Fatalf("mint login link: %v", err)
+	}
+
+	recorder := performRequest(env.router, http.MethodGet, "/api/auth/magic?token="+token, nil)
+	if recorder.Code != http.StatusFound || recorder.Header().Get("Location") != "/login?mfa=1" {
+		t.Fatalf("expected redirect to second factor step, got %d %q", recorder.Code, recorder.Header().Get("Location"))
+	}
+	pending := env.sessionFrom(t, recorder)
+	if protected := pending.do(http.MethodGet, "/api/dashboard/stats", nil); protected.Code != http.StatusUnauthorized {
+		t.Fatalf("expected pending session to be rejected before second factor, got %d", protected.Code)
+	}
+
+	second := pending.do(
+		http.MethodPost,
+		"/api/login/totp",
+		[]byte(fmt.Sprintf(`{"recovery_code":%q}`, recoveryCodes[0])),
+	)
+	if second.Code != http.StatusOK {
+		t.Fatalf("expected second factor to complete login, got %d: %s", second.Code, second.Body.String())
+	}
+	if protected := env.sessionFrom(t, second).do(http.MethodGet, "/api/dashboard/stats", nil); protected.Code != http.StatusOK {
+		t.Fatalf("expected full session after second factor, got %d", protected.Code)
+	}
+}
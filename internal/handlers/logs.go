@@ -1,21 +1,30 @@
 package handlers
 
 import (
-	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 
+	"main/internal/requestid"
 	"main/internal/stream"
 )
 
+// shutdownReconnectDelayMs 服务关闭时建议客户端等待的重连间隔
+const shutdownReconnectDelayMs = 3000
+
 // LogsHandler 日志处理器
 type LogsHandler struct {
 	broadcaster *stream.LogBroadcaster
 }
 
+// LogsHistoryResponse 历史日志响应
+type LogsHistoryResponse struct {
+	Logs  []stream.LogEntry `json:"logs"`
+	Count int               `json:"count" jsonschema:"minimum=0"`
+}
+
 // NewLogsHandler 创建日志处理器
 func NewLogsHandler(broadcaster *stream.LogBroadcaster) *LogsHandler {
 	return &LogsHandler{
@@ -28,15 +37,23 @@ func (h *LogsHandler) StreamLogs(c *gin.Context) {
 	// 设置 SSE 响应头
 	c.Writer.Header().Set("Content-Type", sse.ContentType)
 	c.Writer.Header().Set("Cache-Control", "no-cache")
-	c.Writer.Header().Set("Connection", "keep-alive")
+	// Connection 是 HTTP/1.x 的逐跳头，HTTP/3 响应携带时会被客户端整体拒绝
+	if c.Request.ProtoMajor == 1 {
+		c.Writer.Header().Set("Connection", "keep-alive")
+	}
 	c.Writer.Header().Set("X-Accel-Buffering", "no") // 禁用 Nginx 缓冲
 
+	// 日志流是长连接，取消 http.Server 的写超时，由客户端断开或心跳写入失败结束
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		requestid.Logger(c).Debug("failed to clear write deadline for log stream", "error", err)
+	}
+
 	// 订阅日志广播
 	ch := h.broadcaster.Subscribe()
 	defer h.broadcaster.Unsubscribe(ch)
 
 	sessionID, _ := c.Get("session_id")
-	slog.Info("客户端连接日志流", "session_id", sessionID, "remote_addr", c.ClientIP())
+	requestid.Logger(c).Info("客户端连接日志流", "session_id", sessionID, "remote_addr", c.ClientIP())
 
 	sendHistory := c.DefaultQuery("history", "1") != "0"
 	if sendHistory {
@@ -47,8 +64,9 @@ func (h *LogsHandler) StreamLogs(c *gin.Context) {
 				return
 			}
 		}
-		c.Writer.Flush()
 	}
+	// 立即发送响应头，不请求历史时客户端也能马上确认连接建立
+	c.Writer.Flush()
 
 	// 持续推送新日志
 	ticker := time.NewTicker(30 * time.Second)
@@ -59,6 +77,16 @@ func (h *LogsHandler) StreamLogs(c *gin.Context) {
 		case <-c.Request.Context().Done():
 			return
 
+		case <-h.broadcaster.Done():
+			// 服务关闭前通知客户端稍后重连，避免连接被直接切断
+			_ = sse.Encode(c.Writer, sse.Event{
+				Event: "shutdown",
+				Retry: shutdownReconnectDelayMs,
+				Data:  "server shutting down",
+			})
+			c.Writer.Flush()
+			return
+
 		case logEntry, ok := <-ch:
 			if !ok {
 				// 通道已关闭
@@ -83,8 +111,8 @@ func (h *LogsHandler) StreamLogs(c *gin.Context) {
 // GetHistory 获取历史日志
 func (h *LogsHandler) GetHistory(c *gin.Context) {
 	history := h.broadcaster.GetHistory()
-	c.JSON(http.StatusOK, gin.H{
-		"logs":  history,
-		"count": len(history),
+	c.JSON(http.StatusOK, LogsHistoryResponse{
+		Logs:  history,
+		Count: len(history),
 	})
 }
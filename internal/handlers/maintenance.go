@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apierror"
+	"main/internal/audit"
+	"main/internal/maintenance"
+	"main/internal/requestid"
+)
+
+// maintenanceEvent 维护状态推送的 SSE 事件名
+const maintenanceEvent = "maintenance"
+
+// MaintenanceHandler 维护模式查询、切换与推送
+type MaintenanceHandler struct {
+	mode  *maintenance.Mode
+	audit *audit.Store
+}
+
+// NewMaintenanceHandler 创建维护模式处理器
+func NewMaintenanceHandler(mode *maintenance.Mode, auditLog *audit.Store) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		mode:  mode,
+		audit: auditLog,
+	}
+}
+
+// UpdateMaintenanceRequest 切换维护模式请求，整体替换当前状态
+type UpdateMaintenanceRequest struct {
+	Enabled           bool   `json:"enabled"`
+	Message           string `json:"message" binding:"max=500" jsonschema:"maxLength=500"`
+	RetryAfterSeconds int    `json:"retry_after_seconds" binding:"min=0,max=86400" jsonschema:"minimum=0,maximum=86400"`
+}
+
+// GetStatus 获取当前维护状态，登录页同样需要展示，无需认证；操作者等信息只通过需登录的推送下发
+func (h *MaintenanceHandler) GetStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.mode.Current().Public())
+}
+
+// Update 开启或关闭维护模式
+func (h *MaintenanceHandler) Update(c *gin.Context) {
+	var req UpdateMaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBindError(c, err)
+		return
+	}
+
+	state := maintenance.State{
+		Enabled:           req.Enabled,
+		Message:           req.Message,
+		RetryAfterSeconds: req.RetryAfterSeconds,
+		UpdatedAt:         time.Now().Unix(),
+		UpdatedBy:         c.GetString("username"),
+	}
+	if err := h.mode.Set(state); err != nil {
+		if errors.Is(err, maintenance.ErrInvalidState) {
+			apierror.Respond(c, apierror.CodeInvalidRequest)
+			return
+		}
+		requestid.Logger(c).Error("failed to update maintenance mode", "error", err)
+		apierror.Respond(c, apierror.CodeInternal)
+		return
+	}
+
+	requestid.Logger(c).Warn(
+		"maintenance mode changed",
+		"enabled",
+		state.Enabled,
+		"updated_by",
+		state.UpdatedBy,
+	)
+	h.audit.RecordRequest(c, audit.Event{
+		Action:  audit.ActionMaintenance,
+		Target:  "system",
+		Outcome: audit.OutcomeSuccess,
+		Detail:  fmt.Sprintf("enabled=%t retry_after=%d", state.Enabled, state.RetryAfterSeconds),
+	})
+
+	c.JSON(http.StatusOK, state)
+}
+
+// StreamStatus 以 SSE 推送维护状态：连接时先发送当前状态，之后每次变化推送一次
+func (h *MaintenanceHandler) StreamStatus(c *gin.Context) {
+	c.Writer.Header().Set("Content-Type", sse.ContentType)
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	// Connection 是 HTTP/1.x 的逐跳头，HTTP/3 响应携带时会被客户端整体拒绝
+	if c.Request.ProtoMajor == 1 {
+		c.Writer.Header().Set("Connection", "keep-alive")
+	}
+	c.Writer.Header().Set("X-Accel-Buffering", "no")
+
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		requestid.Logger(c).Debug("failed to clear write deadline for maintenance stream", "error", err)
+	}
+
+	// 先订阅再读取当前状态，两者之间的变化不会丢失
+	ch := h.mode.Subscribe()
+	defer h.mode.Unsubscribe(ch)
+
+	if err := sse.Encode(c.Writer, sse.Event{Event: maintenanceEvent, Data: h.mode.Current()}); err != nil {
+		return
+	}
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+
+		case <-h.mode.Done():
+			_ = sse.Encode(c.Writer, sse.Event{
+				Event: "shutdown",
+				Retry: shutdownReconnectDelayMs,
+				Data:  "server shutting down",
+			})
+			c.Writer.Flush()
+			return
+
+		case state, ok := <-ch:
+			if !ok {
+				return
+			}
+			if err := sse.Encode(c.Writer, sse.Event{Event: maintenanceEvent, Data: state}); err != nil {
+				return
+			}
+			c.Writer.Flush()
+
+		case <-ticker.C:
+			if err := sse.Encode(c.Writer, sse.Event{Event: "heartbeat", Data: "ping"}); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package handlers_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"main/internal/apierror"
+	"main/internal/config"
+	"main/internal/maintenance"
+	"main/internal/user"
+)
+
+// newMaintenanceTestAPI 使用给定数据目录的测试环境，并创建 viewer 账号 alice
+func newMaintenanceTestAPI(t *testing.T, dataDir string) *testAPI {
+	t.Helper()
+
+	env := newTestAPI(t, func(cfg *config.Config) {
+		cfg.DataDir = dataDir
+	})
+	if _, err := env.users.Create(t.Context(), "alice", "viewer-password", user.RoleViewer); err != nil {
+		t.Fatalf("create viewer: %v", err)
+	}
+	return env
+}
+
+func TestMaintenanceBlocksWritesExceptAdmins(t *testing.T) {
+	dataDir := t.TempDir()
+	env := newMaintenanceTestAPI(t, dataDir)
+	adminSession := env.loginAs(t, "admin", testAdminPassword)
+	viewerSession := env.loginAs(t, "alice", "viewer-password")
+
+	recorder := adminSession.do(
+		http.MethodPut,
+		"/api/maintenance",
+		[]byte(`{"enabled":true,"message":"数据迁移中","retry_after_seconds":120}`),
+	)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected enable status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = viewerSession.do(http.MethodPost, "/api/account/totp/setup", nil)
+	if recorder.Code != http.StatusServiceUnavailable || recorder.Header().Get("Retry-After") != "120" {
+		t.Fatalf("expected 503 with Retry-After 120, got %d %q", recorder.Code, recorder.Header().Get("Retry-After"))
+	}
+	var body apierror.Response
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode error body: %v", err)
+	}
+	if body.Error.Code != apierror.CodeMaintenance || body.Error.Message != "数据迁移中" {
+		t.Fatalf("unexpected error body %+v", body.Error)
+	}
+
+	if recorder := viewerSession.do(http.MethodGet, "/api/dashboard/stats", nil); recorder.Code != http.StatusOK {
+		t.Fatalf("expected reads to keep working, got %d", recorder.Code)
+	}
+	if recorder := adminSession.do(http.MethodPost, "/api/account/totp/setup", nil); recorder.Code != http.StatusOK {
+		t.Fatalf("expected admin writes to bypass maintenance, got %d", recorder.Code)
+	}
+	if recorder := performRequest(env.router, http.MethodPost, "/api/login", loginBody("alice", "viewer-password")); recorder.Code != http.StatusOK {
+		t.Fatalf("expected login to keep working, got %d", recorder.Code)
+	}
+
+	// 状态写入 DATA_DIR，重启后仍然生效
+	restarted := newTestAPI(t, func(cfg *config.Config) {
+		cfg.DataDir = dataDir
+	})
+	recorder = performRequest(restarted.router, http.MethodGet, "/api/maintenance", nil)
+	var state map[string]any
+	if err := json.Unmarshal(recorder.Body.Bytes(), &state); err != nil {
+		t.Fatalf("decode state: %v", err)
+	}
+	if state["enabled"] != true || state["message"] != "数据迁移中" {
+		t.Fatalf("expected persisted maintenance state, got %v", state)
+	}
+	// 匿名接口不暴露操作者与切换时间
+	if _, ok := state["updated_by"]; ok {
+		t.Fatalf("expected public status to omit updated_by, got %v", state)
+	}
+	if _, ok := state["updated_at"]; ok {
+		t.Fatalf("expected public status to omit updated_at, got %v", state)
+	}
+	if persisted := restarted.mode.Current(); persisted.UpdatedBy != "admin" {
+		t.Fatalf("expected operator to be persisted, got %+v", persisted)
+	}
+}
+
+func TestMaintenanceUpdateRequiresAdminAndValidatesInput(t *testing.T) {
+	env := newMaintenanceTestAPI(t, t.TempDir())
+	adminSession := env.loginAs(t, "admin", testAdminPassword)
+	viewerSession := env.loginAs(t, "alice", "viewer-password")
+
+	recorder := viewerSession.do(http.MethodPut, "/api/maintenance", []byte(`{"enabled":true}`))
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected viewer to be forbidden, got %d", recorder.Code)
+	}
+
+	recorder = adminSession.do(
+		http.MethodPut,
+		"/api/maintenance",
+		[]byte(`{"enabled":true,"retry_after_seconds":-1}`),
+	)
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid retry_after_seconds to be rejected, got %d", recorder.Code)
+	}
+}
+
+func TestMaintenanceStreamPushesChanges(t *testing.T) {
+	env := newMaintenanceTestAPI(t, t.TempDir())
+	viewerCookie := env.loginAs(t, "alice", "viewer-password").cookie
+	srv := httptest.NewServer(env.router)
+	t.Cleanup(srv.Close)
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, srv.URL+"/api/maintenance/stream", nil)
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+	req.AddCookie(viewerCookie)
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	events := bufio.NewScanner(resp.Body)
+	next := func() maintenance.State {
+		t.Helper()
+		for events.Scan() {
+			data, ok := strings.CutPrefix(events.Text(), "data:")
+			if !ok {
+				continue
+			}
+			var state maintenance.State
+			if err := json.Unmarshal([]byte(data), &state); err != nil {
+				t.Fatalf("decode event %q: %v", data, err)
+			}
+			return state
+		}
+		t.Fatalf("stream ended: %v", events.Err())
+		return maintenance.State{}
+	}
+
+	if state := next(); state.Enabled {
+		t.Fatalf("expected initial state to be disabled, got %+v", state)
+	}
+	if err := env.mode.Set(maintenance.State{Enabled: true, UpdatedBy: "cli"}); err != nil {
+		t.Fatalf("enable maintenance: %v", err)
+	}
+	if state := next(); !state.Enabled || state.UpdatedBy != "cli" {
+		t.Fatalf("expected pushed enabled state, got %+v", state)
+	}
+}
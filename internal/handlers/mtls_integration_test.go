@@ -0,0 +1,143 @@
+package handlers_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"main/internal/config"
+	"main/internal/mtls"
+	"main/internal/mtls/mtlstest"
+	"main/internal/user"
+)
+
+func newMTLSTestServer(t *testing.T, ca *mtlstest.CA, defaultRole user.Role) (*httptest.Server, *user.Store) {
+	t.Helper()
+
+	caFile := filepath.Join(t.TempDir(), "client-ca.crt")
+	if err := os.WriteFile(caFile, ca.PEM(), 0o600); err != nil {
+		t.Fatalf("write client ca: %v", err)
+	}
+	env := newTestAPI(t, func(cfg *config.Config) {
+		cfg.TLSClientCAFile = caFile
+		cfg.MTLSUsernameField = mtls.UsernameFieldCN
+		cfg.MTLSDefaultRole = string(defaultRole)
+	})
+
+	server := httptest.NewUnstartedServer(env.router)
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{ca.IssueServer()},
+		ClientCAs:    ca.Pool(),
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server, env.users
+}
+
+func newMTLSClient(ca *mtlstest.CA, cert *tls.Certificate) *http.Client {
+	tlsConfig := &tls.Config{RootCAs: ca.Pool()}
+	if cert != nil {
+		tlsConfig.Certificates = []tls.Certificate{*cert}
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+}
+
+func TestClientCertificateAuthenticatesExistingUser(t *testing.T) {
+	ca := mtlstest.NewCA("test-ca")
+	server, users := newMTLSTestServer(t, ca, "")
+	svc, err := users.Create(t.Context(), "svc-backup", "service-password", user.RoleOperator)
+	if err != nil {
+		t.Fatalf("create service user: %v", err)
+	}
+
+	// 有本地密码的账号未经管理员关联前不接受证书登录
+	cert := ca.IssueClient(mtlstest.ClientOptions{CommonName: "svc-backup"})
+	resp, err := newMTLSClient(ca, &cert).Get(server.URL + "/api/logs/history")
+	if err != nil {
+		t.Fatalf("request with client certificate: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected certificate for unlinked password account to be rejected, got %d", resp.StatusCode)
+	}
+
+	linked := true
+	if _, err := users.Update(t.Context(), svc.ID, user.UpdateParams{ExternalAuth: &linked}); err != nil {
+		t.Fatalf("link service user: %v", err)
+	}
+	resp, err = newMTLSClient(ca, &cert).Get(server.URL + "/api/logs/history")
+	if err != nil {
+		t.Fatalf("request with client certificate: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected certificate principal to access operator route, got %d", resp.StatusCode)
+	}
+	if findCookieByName(resp.Cookies(), "session_id") != nil {
+		t.Fatal("expected certificate authentication not to issue a session cookie")
+	}
+	// 证书主体按 svc-backup 的 operator 角色授权，不能访问管理员接口
+	resp, err = newMTLSClient(ca, &cert).Get(server.URL + "/api/users")
+	if err != nil {
+		t.Fatalf("request with client certificate: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected certificate principal to keep its operator role, got %d", resp.StatusCode)
+	}
+
+	unknown := ca.IssueClient(mtlstest.ClientOptions{CommonName: "svc-unknown"})
+	resp, err = newMTLSClient(ca, &unknown).Get(server.URL + "/api/logs/history")
+	if err != nil {
+		t.Fatalf("request with unknown certificate: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected certificate without local user to be rejected, got %d", resp.StatusCode)
+	}
+
+	resp, err = newMTLSClient(ca, nil).Get(server.URL + "/api/logs/history")
+	if err != nil {
+		t.Fatalf("request without certificate: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected request without certificate or session to be rejected, got %d", resp.StatusCode)
+	}
+}
+
+func TestClientCertificateProvisioningAndUntrustedCA(t *testing.T) {
+	ca := mtlstest.NewCA("test-ca")
+	server, users := newMTLSTestServer(t, ca, user.RoleOperator)
+
+	cert := ca.IssueClient(mtlstest.ClientOptions{CommonName: "svc-metrics"})
+	resp, err := newMTLSClient(ca, &cert).Get(server.URL + "/api/users")
+	if err != nil {
+		t.Fatalf("request with client certificate: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected provisioned operator to be forbidden from admin route, got %d", resp.StatusCode)
+	}
+	provisioned, err := users.GetByUsername(t.Context(), "svc-metrics")
+	if err != nil || provisioned.Role != user.RoleOperator {
+		t.Fatalf("expected certificate user to be provisioned as operator, got %+v (%v)", provisioned, err)
+	}
+
+	// 强制发送证书，否则 Go 客户端会因 CA 不在服务端列表中而不发送
+	rogue := mtlstest.NewCA("rogue-ca").IssueClient(mtlstest.ClientOptions{CommonName: "admin"})
+	rogueClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs: ca.Pool(),
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return &rogue, nil
+		},
+	}}}
+	if resp, err := rogueClient.Get(server.URL + "/api/users"); err == nil {
+		resp.Body.Close()
+		t.Fatalf("expected certificate from untrusted CA to fail handshake, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	ginsessions "github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apierror"
+	"main/internal/audit"
+	"main/internal/oidc"
+	"main/internal/requestid"
+	"main/internal/session"
+	"main/internal/user"
+)
+
+const (
+	defaultLoginRedirect = "/dashboard"
+	oidcErrorRedirect    = "/login?error=oidc"
+)
+
+// OIDCHandler OIDC 单点登录处理器，provider 为 nil 表示未启用
+type OIDCHandler struct {
+	auth     *AuthHandler
+	users    *user.Store
+	provider *oidc.Provider
+}
+
+// NewOIDCHandler 创建 OIDC 登录处理器
+func NewOIDCHandler(auth *AuthHandler, users *user.Store, provider *oidc.Provider) *OIDCHandler {
+	return &OIDCHandler{
+		auth:     auth,
+		users:    users,
+		provider: provider,
+	}
+}
+
+// AuthMethodsResponse 可用登录方式
+type AuthMethodsResponse struct {
+	Password bool `json:"password"`
+	OIDC     bool `json:"oidc"`
+}
+
+// Methods 返回当前启用的登录方式，供登录页决定是否展示 SSO 入口
+func (h *OIDCHandler) Methods(c *gin.Context) {
+	c.JSON(http.StatusOK, AuthMethodsResponse{
+		Password: true,
+		OIDC:     h.provider != nil,
+	})
+}
+
+// Login 生成 state/nonce/PKCE 参数并跳转到 OIDC Provider
+func (h *OIDCHandler) Login(c *gin.Context) {
+	if h.provider == nil {
+		apierror.Respond(c, apierror.CodeOIDCDisabled)
+		return
+	}
+
+	authRequest, err := h.provider.Begin(c.Request.Context())
+	if err != nil {
+		requestid.Logger(c).Error("failed to start oidc login", "error", err)
+		c.Redirect(http.StatusFound, oidcErrorRedirect)
+		return
+	}
+
+	// GET 请求可由第三方页面触发，只覆盖本次授权的字段，不影响已登录的会话
+	sess := ginsessions.Default(c)
+	sess.Set("oidc_state", authRequest.State)
+	sess.Set("oidc_nonce", authRequest.Nonce)
+	sess.Set("oidc_verifier", authRequest.Verifier)
+	sess.Set("oidc_redirect", sanitizeRedirectPath(c.Query("redirect")))
+	sess.Set("oidc_started_at", time.Now().Unix())
+	if authenticated, _ := sess.Get("authenticated").(bool); !authenticated {
+		session.SetCookieOptions(sess, h.auth.cookies, session.OIDCStateMaxAge)
+	}
+	if err := sess.Save(); err != nil {
+		requestid.Logger(c).Error("failed to save oidc state", "error", err)
+		c.Redirect(http.StatusFound, oidcErrorRedirect)
+		return
+	}
+
+	c.Redirect(http.StatusFound, authRequest.URL)
+}
+
+// Callback 校验回调参数、换取 ID Token 并建立与密码登录相同的会话
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	if h.provider == nil {
+		apierror.Respond(c, apierror.CodeOIDCDisabled)
+		return
+	}
+
+	sess := ginsessions.Default(c)
+	expectedState, _ := sess.Get("oidc_state").(string)
+	nonce, _ := sess.Get("oidc_nonce").(string)
+	verifier, _ := sess.Get("oidc_verifier").(string)
+	redirectPath, _ := sess.Get("oidc_redirect").(string)
+	startedAt, _ := sess.Get("oidc_started_at").(int64)
+
+	fail := func(reason string, attrs ...any) {
+		requestid.Logger(c).Warn("oidc login failed", append([]any{"reason", reason, "remote_addr", c.ClientIP()}, attrs...)...)
+		h.auth.audit.RecordRequest(c, audit.Event{
+			Action:  audit.ActionLogin,
+			Outcome: audit.OutcomeFailure,
+			Detail:  "oidc: " + reason,
+		})
+		clearOIDCState(c, sess, h.auth.cookies)
+		c.Redirect(http.StatusFound, oidcErrorRedirect)
+	}
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		fail("provider error", "error", providerErr, "description", c.Query("error_description"))
+		return
+	}
+
+	state := c.Query("state")
+	if expectedState == "" || subtle.ConstantTimeCompare([]byte(state), []byte(expectedState)) != 1 {
+		fail("state mismatch")
+		return
+	}
+	if time.Since(time.Unix(startedAt, 0)) > session.OIDCStateTTL {
+		fail("state expired")
+		return
+	}
+
+	identity, err := h.provider.Exchange(c.Request.Context(), c.Query("code"), verifier, nonce)
+	if err != nil {
+		fail("token exchange", "error", err)
+		return
+	}
+
+	u, err := h.users.UpsertOIDCUser(c.Request.Context(), identity.Issuer, identity.Subject, identity.Username, identity.Role)
+	if err != nil {
+		fail("provision user", "username", identity.Username, "error", err)
+		return
+	}
+	if u.Disabled {
+		fail("account disabled", "username", u.Username)
+		return
+	}
+
+	sessionID, err := h.auth.startSession(sess, u)
+	if err != nil {
+		requestid.Logger(c).Error("failed to start session", "error", err)
+		c.Redirect(http.StatusFound, oidcErrorRedirect)
+		return
+	}
+
+	requestid.Logger(c).Info(
+		"user logged in",
+		"session_id",
+		sessionID,
+		"username",
+		u.Username,
+		"role",
+		u.Role,
+		"method",
+		"oidc",
+		"remote_addr",
+		c.ClientIP(),
+	)
+	h.auth.audit.RecordRequest(c, audit.Event{
+		Actor:     u.Username,
+		ActorID:   u.ID,
+		SessionID: sessionID,
+		Action:    audit.ActionLogin,
+		Outcome:   audit.OutcomeSuccess,
+		Detail:    "oidc",
+	})
+
+	if redirectPath == "" {
+		redirectPath = defaultLoginRedirect
+	}
+	c.Redirect(http.StatusFound, redirectPath)
+}
+
+// oidcStateKeys Login 写入会话、供 Callback 校验的字段
+var oidcStateKeys = []string{"oidc_state", "oidc_nonce", "oidc_verifier", "oidc_redirect", "oidc_started_at"}
+
+// clearOIDCState 回调失败时作废授权参数；已登录的会话只删除这些字段，其余情况清除整个会话 Cookie
+func clearOIDCState(c *gin.Context, sess ginsessions.Session, cookies session.CookiePolicy) {
+	if authenticated, _ := sess.Get("authenticated").(bool); !authenticated {
+		clearInvalidSessionCookie(c, sess, cookies)
+		return
+	}
+	for _, key := range oidcStateKeys {
+		sess.Delete(key)
+	}
+	if err := sess.Save(); err != nil {
+		requestid.Logger(c).Warn("failed to clear oidc state", "error", err)
+	}
+}
+
+// sanitizeRedirectPath 仅允许站内相对路径，防止开放重定向
+func sanitizeRedirectPath(raw string) string {
+	path := strings.TrimSpace(raw)
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.Contains(path, "\\") {
+		return defaultLoginRedirect
+	}
+	if strings.HasPrefix(path, "/login") || strings.HasPrefix(path, "/api/") {
+		return defaultLoginRedirect
+	}
+	parsed, err := url.Parse(path)
+	if err != nil || parsed.Host != "" || parsed.Scheme != "" {
+		return defaultLoginRedirect
+	}
+	return path
+}
@@ -0,0 +1,214 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"main/internal/config"
+	"main/internal/oidc/mockidp"
+	"main/internal/user"
+)
+
+const oidcTestRedirectURL = "http://console.example.com/api/auth/oidc/callback"
+
+// newOIDCTestAPI 启用 OIDC 登录并对接 mock IdP 的测试环境，idp 为 nil 时不启用
+func newOIDCTestAPI(t *testing.T, idp *mockidp.Server) *testAPI {
+	t.Helper()
+
+	return newTestAPI(t, func(cfg *config.Config) {
+		if idp == nil {
+			return
+		}
+		cfg.OIDCEnabled = true
+		cfg.OIDCIssuerURL = idp.URL
+		cfg.OIDCClientID = idp.ClientID
+		cfg.OIDCClientSecret = "client-secret"
+		cfg.OIDCRedirectURL = oidcTestRedirectURL
+		cfg.OIDCAdminGroups = []string{"console-admins"}
+		cfg.OIDCOperatorGroups = []string{"console-operators"}
+	})
+}
+
+// followToIdP 请求 IdP 授权端点，返回其重定向回客户端的回调地址
+func followToIdP(t *testing.T, location string) *url.URL {
+	t.Helper()
+
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(location)
+	if err != nil {
+		t.Fatalf("request idp authorize endpoint: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("expected idp redirect, got %d", resp.StatusCode)
+	}
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("parse callback url: %v", err)
+	}
+	return callback
+}
+
+func TestOIDCLoginCreatesSessionWithMappedRole(t *testing.T) {
+	idp := mockidp.New("console")
+	defer idp.Close()
+	idp.SetIdentity("subject-1", map[string]any{
+		"preferred_username": "sso-alice",
+		"groups":             []string{"everyone", "console-admins"},
+	})
+
+	env := newOIDCTestAPI(t, idp)
+
+	loginRecorder := performRequest(env.router, http.MethodGet, "/api/auth/oidc/login?redirect=/logs", nil)
+	if loginRecorder.Code != http.StatusFound {
+		t.Fatalf("expected login redirect, got %d", loginRecorder.Code)
+	}
+	location, err := url.Parse(loginRecorder.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("parse authorize url: %v", err)
+	}
+	if location.Query().Get("code_challenge_method") != "S256" || location.Query().Get("nonce") == "" {
+		t.Fatalf("expected pkce and nonce in authorize url, got %s", location)
+	}
+	stateCookie := findCookieByName(loginRecorder.Result().Cookies(), "session_id")
+
+	callback := followToIdP(t, location.String())
+	callbackRecorder := performRequest(env.router, http.MethodGet, callback.RequestURI(), nil, stateCookie)
+	if callbackRecorder.Code != http.StatusFound || callbackRecorder.Header().Get("Location") != "/logs" {
+		t.Fatalf(
+			"expected redirect to /logs, got %d %q",
+			callbackRecorder.Code,
+			callbackRecorder.Header().Get("Location"),
+		)
+	}
+	sessionCookie := findCookieByName(callbackRecorder.Result().Cookies(), "session_id")
+
+	sessionRecorder := performRequest(env.router, http.MethodGet, "/api/session", nil, sessionCookie)
+	var sessionResponse sessionStatusResponse
+	if err := json.Unmarshal(sessionRecorder.Body.Bytes(), &sessionResponse); err != nil {
+		t.Fatalf("failed to parse session response: %v", err)
+	}
+	if !sessionResponse.Authenticated || sessionResponse.User == nil ||
+		sessionResponse.User.Username != "sso-alice" || sessionResponse.User.Role != "admin" {
+		t.Fatalf("unexpected session response: %s", sessionRecorder.Body.String())
+	}
+
+	adminRecorder := performRequest(env.router, http.MethodGet, "/api/users", nil, sessionCookie)
+	if adminRecorder.Code != http.StatusOK {
+		t.Fatalf("expected sso admin to access admin route, got %d", adminRecorder.Code)
+	}
+
+	// SSO 用户没有本地密码
+	passwordRecorder := performRequest(env.router, http.MethodPost, "/api/login", loginBody("sso-alice", ""))
+	if passwordRecorder.Code == http.StatusOK {
+		t.Fatal("expected sso user password login to fail")
+	}
+}
+
+func TestOIDCCallbackRejectsStateMismatch(t *testing.T) {
+	idp := mockidp.New("console")
+	defer idp.Close()
+	idp.SetIdentity("subject-2", map[string]any{
+		"preferred_username": "sso-bob",
+		"groups":             []string{"console-operators"},
+	})
+
+	env := newOIDCTestAPI(t, idp)
+
+	loginRecorder := performRequest(env.router, http.MethodGet, "/api/auth/oidc/login", nil)
+	stateCookie := findCookieByName(loginRecorder.Result().Cookies(), "session_id")
+	callback := followToIdP(t, loginRecorder.Header().Get("Location"))
+
+	query := callback.Query()
+	query.Set("state", "forged-state")
+	callback.RawQuery = query.Encode()
+
+	callbackRecorder := performRequest(env.router, http.MethodGet, callback.RequestURI(), nil, stateCookie)
+	if callbackRecorder.Code != http.StatusFound || callbackRecorder.Header().Get("Location") != "/login?error=oidc" {
+		t.Fatalf("expected redirect to login error page, got %d %q", callbackRecorder.Code, callbackRecorder.Header().Get("Location"))
+	}
+
+	if _, err := env.users.GetByUsername(t.Context(), "sso-bob"); err != user.ErrNotFound {
+		t.Fatalf("expected no user to be provisioned, got %v", err)
+	}
+}
+
+func TestOIDCLoginKeepsExistingSession(t *testing.T) {
+	idp := mockidp.New("console")
+	defer idp.Close()
+
+	env := newOIDCTestAPI(t, idp)
+	adminCookie := env.loginAs(t, "admin", testAdminPassword).cookie
+
+	// 第三方页面可以诱导浏览器发起 GET，不能因此登出当前用户
+	loginRecorder := performRequest(env.router, http.MethodGet, "/api/auth/oidc/login", nil, adminCookie)
+	if loginRecorder.Code != http.StatusFound {
+		t.Fatalf("expected login redirect, got %d", loginRecorder.Code)
+	}
+	if cookie := findCookieByName(loginRecorder.Result().Cookies(), "session_id"); cookie != nil {
+		adminCookie = cookie
+	}
+	if recorder := performRequest(env.router, http.MethodGet, "/api/users", nil, adminCookie); recorder.Code != http.StatusOK {
+		t.Fatalf("expected session to survive oidc login start, got %d", recorder.Code)
+	}
+
+	forged := performRequest(env.router, http.MethodGet, "/api/auth/oidc/callback?state=forged&code=x", nil, adminCookie)
+	if forged.Header().Get("Location") != "/login?error=oidc" {
+		t.Fatalf("expected forged callback to be rejected, got %q", forged.Header().Get("Location"))
+	}
+	if cookie := findCookieByName(forged.Result().Cookies(), "session_id"); cookie != nil {
+		adminCookie = cookie
+	}
+	if recorder := performRequest(env.router, http.MethodGet, "/api/users", nil, adminCookie); recorder.Code != http.StatusOK {
+		t.Fatalf("expected session to survive rejected callback, got %d", recorder.Code)
+	}
+}
+
+func TestOIDCCallbackRejectsUnmappedGroups(t *testing.T) {
+	idp := mockidp.New("console")
+	defer idp.Close()
+	idp.SetIdentity("subject-3", map[string]any{
+		"preferred_username": "sso-carol",
+		"groups":             []string{"unrelated"},
+	})
+
+	env := newOIDCTestAPI(t, idp)
+
+	loginRecorder := performRequest(env.router, http.MethodGet, "/api/auth/oidc/login", nil)
+	stateCookie := findCookieByName(loginRecorder.Result().Cookies(), "session_id")
+	callback := followToIdP(t, loginRecorder.Header().Get("Location"))
+
+	callbackRecorder := performRequest(env.router, http.MethodGet, callback.RequestURI(), nil, stateCookie)
+	if callbackRecorder.Header().Get("Location") != "/login?error=oidc" {
+		t.Fatalf("expected unmapped user to be rejected, got %q", callbackRecorder.Header().Get("Location"))
+	}
+}
+
+func TestOIDCDisabledKeepsPasswordLogin(t *testing.T) {
+	env := newOIDCTestAPI(t, nil)
+
+	methodsRecorder := performRequest(env.router, http.MethodGet, "/api/auth/methods", nil)
+	var methods struct {
+		Password bool `json:"password"`
+		OIDC     bool `json:"oidc"`
+	}
+	if err := json.Unmarshal(methodsRecorder.Body.Bytes(), &methods); err != nil {
+		t.Fatalf("failed to parse methods response: %v", err)
+	}
+	if !methods.Password || methods.OIDC {
+		t.Fatalf("unexpected methods response: %s", methodsRecorder.Body.String())
+	}
+
+	if recorder := performRequest(env.router, http.MethodGet, "/api/auth/oidc/login", nil); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected disabled oidc login status 404, got %d", recorder.Code)
+	}
+
+	env.loginAs(t, "admin", testAdminPassword)
+}
@@ -0,0 +1,163 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/config"
+	"main/internal/proxyauth"
+	"main/internal/user"
+)
+
+const (
+	trustedProxyAddr   = "10.0.0.5:41000"
+	untrustedProxyAddr = "203.0.113.9:41000"
+)
+
+func newProxyTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+
+	return newTestAPI(t, func(cfg *config.Config) {
+		cfg.TrustedProxies = []string{"10.0.0.0/8"}
+		cfg.ProxyAuthEnabled = true
+		cfg.ProxyAuthUserHeader = proxyauth.DefaultUserHeader
+		cfg.ProxyAuthEmailHeader = proxyauth.DefaultEmailHeader
+		cfg.ProxyAuthDefaultRole = string(user.RoleViewer)
+	})
+}
+
+func performProxyRequest(
+	router *gin.Engine,
+	path string,
+	remoteAddr string,
+	headers map[string]string,
+	cookies ...*http.Cookie,
+) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = remoteAddr
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestProxyHeadersProvisionSessionFromTrustedPeer(t *testing.T) {
+	env := newProxyTestAPI(t)
+	headers := map[string]string{
+		"X-Forwarded-User":  "gateway-alice",
+		"X-Forwarded-Email": "alice@example.com",
+	}
+
+	recorder := performProxyRequest(env.router, "/api/dashboard/stats", trustedProxyAddr, headers)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected trusted proxy request to succeed, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	cookie := findCookieByName(recorder.Result().Cookies(), "session_id")
+	if cookie == nil {
+		t.Fatal("expected proxy authentication to issue a session cookie")
+	}
+
+	provisioned, err := env.users.GetByUsername(t.Context(), "gateway-alice")
+	if err != nil {
+		t.Fatalf("expected proxy user to be provisioned: %v", err)
+	}
+	if provisioned.Role != user.RoleViewer || !provisioned.SSO {
+		t.Fatalf("unexpected provisioned user: %+v", provisioned)
+	}
+
+	sessionRecorder := performProxyRequest(env.router, "/api/session", trustedProxyAddr, headers, cookie)
+	var status sessionStatusResponse
+	if err := json.Unmarshal(sessionRecorder.Body.Bytes(), &status); err != nil {
+		t.Fatalf("failed to parse session response: %v", err)
+	}
+	if !status.Authenticated || status.User == nil || status.User.Username != "gateway-alice" {
+		t.Fatalf("expected proxy session to be reported, got %+v", status)
+	}
+
+	if adminRecorder := performProxyRequest(env.router, "/api/users", trustedProxyAddr, headers, cookie); adminRecorder.Code != http.StatusForbidden {
+		t.Fatalf("expected default viewer role to be forbidden from admin route, got %d", adminRecorder.Code)
+	}
+
+	// 首次访问 /api/session 也应建立会话，供前端启动时检测登录状态
+	emailOnly := performProxyRequest(env.router, "/api/session", trustedProxyAddr, map[string]string{
+		"X-Forwarded-Email": "bob@example.com",
+	})
+	if err := json.Unmarshal(emailOnly.Body.Bytes(), &status); err != nil {
+		t.Fatalf("failed to parse session response: %v", err)
+	}
+	if emailOnly.Code != http.StatusOK || status.User == nil || status.User.Username != "bob@example.com" {
+		t.Fatalf("expected email header to be used as username, got %d %+v", emailOnly.Code, status)
+	}
+}
+
+func TestProxyHeadersSwitchSessionToExistingUser(t *testing.T) {
+	env := newProxyTestAPI(t)
+
+	first := performProxyRequest(env.router, "/api/dashboard/stats", trustedProxyAddr, map[string]string{
+		"X-Forwarded-User": "gateway-alice",
+	})
+	cookie := findCookieByName(first.Result().Cookies(), "session_id")
+	if cookie == nil {
+		t.Fatal("expected session cookie")
+	}
+
+	// 代理身份头不能冒用有本地密码的管理员，除非管理员显式关联
+	recorder := performProxyRequest(env.router, "/api/users", trustedProxyAddr, map[string]string{
+		"X-Forwarded-User": "admin",
+	}, cookie)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected proxy identity of unlinked password admin to be rejected, got %d", recorder.Code)
+	}
+
+	admin, err := env.users.GetByUsername(t.Context(), "admin")
+	if err != nil {
+		t.Fatalf("load admin: %v", err)
+	}
+	linked := true
+	if _, err := env.users.Update(t.Context(), admin.ID, user.UpdateParams{ExternalAuth: &linked}); err != nil {
+		t.Fatalf("link admin: %v", err)
+	}
+	recorder = performProxyRequest(env.router, "/api/users", trustedProxyAddr, map[string]string{
+		"X-Forwarded-User": "admin",
+	}, cookie)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected session to follow proxy identity of existing admin, got %d", recorder.Code)
+	}
+}
+
+func TestProxyHeadersRejectedFromUntrustedPeer(t *testing.T) {
+	env := newProxyTestAPI(t)
+
+	recorder := performProxyRequest(env.router, "/api/dashboard/stats", untrustedProxyAddr, map[string]string{
+		"X-Forwarded-User": "admin",
+	})
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected spoofed proxy headers to be rejected, got %d", recorder.Code)
+	}
+
+	sessionRecorder := performProxyRequest(env.router, "/api/session", untrustedProxyAddr, map[string]string{
+		"X-Forwarded-Email": "mallory@example.com",
+	})
+	if sessionRecorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected spoofed proxy headers on session endpoint to be rejected, got %d", sessionRecorder.Code)
+	}
+	if _, err := env.users.GetByUsername(t.Context(), "mallory@example.com"); err != user.ErrNotFound {
+		t.Fatalf("expected no user to be provisioned for untrusted peer, got %v", err)
+	}
+
+	// 不带身份头的直连请求仍可使用密码登录
+	cookie := env.loginAs(t, "admin", testAdminPassword).cookie
+	if recorder := performProxyRequest(env.router, "/api/dashboard/stats", untrustedProxyAddr, nil, cookie); recorder.Code != http.StatusOK {
+		t.Fatalf("expected password session without proxy headers to work, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,119 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"main/internal/apierror"
+	"main/internal/config"
+	"main/internal/ratelimit"
+)
+
+// rateLimitTestLimits 各限流器的限额，空字符串表示不限流
+type rateLimitTestLimits struct {
+	apiIP, api, auth, stats string
+}
+
+func newRateLimitTestAPI(t *testing.T, limits rateLimitTestLimits) *testAPI {
+	t.Helper()
+
+	parse := func(value string) ratelimit.Limit {
+		if value == "" {
+			return ratelimit.Limit{}
+		}
+		limit, err := ratelimit.ParseLimit(value)
+		if err != nil {
+			t.Fatalf("parse limit: %v", err)
+		}
+		return limit
+	}
+
+	return newTestAPI(t, func(cfg *config.Config) {
+		cfg.RateLimitAPIIP = parse(limits.apiIP)
+		cfg.RateLimitAPI = parse(limits.api)
+		cfg.RateLimitAuth = parse(limits.auth)
+		cfg.RateLimitStats = parse(limits.stats)
+	})
+}
+
+func TestRateLimitRejectsWithHeaders(t *testing.T) {
+	env := newRateLimitTestAPI(t, rateLimitTestLimits{api: "100/m", stats: "2/m"})
+	adminSession := env.loginAs(t, "admin", testAdminPassword)
+
+	for i := range 2 {
+		recorder := adminSession.do(http.MethodGet, "/api/dashboard/stats", nil)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, recorder.Code)
+		}
+		// 统计接口的额度少于 /api 总额度，响应头反映更紧的一个
+		if got := recorder.Header().Get("RateLimit-Limit"); got != "2" {
+			t.Fatalf("request %d: expected RateLimit-Limit 2, got %q", i, got)
+		}
+	}
+
+	recorder := adminSession.do(http.MethodGet, "/api/dashboard/stats", nil)
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", recorder.Code)
+	}
+	if recorder.Header().Get("Retry-After") != "30" || recorder.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("unexpected rate limit headers: %v", recorder.Header())
+	}
+	var body apierror.Response
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil || body.Error.Code != apierror.CodeRateLimited {
+		t.Fatalf("expected rate_limited error body, got %s", recorder.Body.String())
+	}
+
+	// 另一个会话使用独立的桶，不受同一出口 IP 上其他用户影响
+	other := env.loginAs(t, "admin", testAdminPassword)
+	if recorder := other.do(http.MethodGet, "/api/dashboard/stats", nil); recorder.Code != http.StatusOK {
+		t.Fatalf("expected separate session bucket, got %d", recorder.Code)
+	}
+}
+
+func TestRateLimitAnonymousRequestsByIP(t *testing.T) {
+	env := newRateLimitTestAPI(t, rateLimitTestLimits{api: "2/m"})
+
+	for range 2 {
+		performRequest(env.router, http.MethodPost, "/api/login", loginBody("admin", "wrong"))
+	}
+	recorder := performRequest(env.router, http.MethodPost, "/api/login", loginBody("admin", testAdminPassword))
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected anonymous requests limited by IP, got %d", recorder.Code)
+	}
+}
+
+func TestRateLimitAuthIgnoresFreshSessions(t *testing.T) {
+	env := newRateLimitTestAPI(t, rateLimitTestLimits{auth: "3/m"})
+
+	// 已登录的攻击者每次登录都会得到新会话，登录限额仍按 IP 累计
+	adminSession := env.loginAs(t, "admin", testAdminPassword)
+	for i := range 2 {
+		recorder := adminSession.do(http.MethodPost, "/api/login", loginBody("admin", "wrong"))
+		if recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected 401, got %d", i, recorder.Code)
+		}
+	}
+	recorder := adminSession.do(http.MethodPost, "/api/login", loginBody("admin", "wrong"))
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected login attempts from an authenticated session to share the IP bucket, got %d", recorder.Code)
+	}
+}
+
+func TestRateLimitAPIChecksIPAndSessionBuckets(t *testing.T) {
+	env := newRateLimitTestAPI(t, rateLimitTestLimits{apiIP: "4/m", api: "100/m"})
+
+	first := env.loginAs(t, "admin", testAdminPassword)
+	second := env.loginAs(t, "admin", testAdminPassword)
+	for _, client := range []*testSession{first, second} {
+		if recorder := client.do(http.MethodGet, "/api/dashboard/stats", nil); recorder.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", recorder.Code)
+		}
+	}
+
+	// 会话桶仍有额度，但同一 IP 的总额度已用完
+	recorder := first.do(http.MethodGet, "/api/dashboard/stats", nil)
+	if recorder.Code != http.StatusTooManyRequests || recorder.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("expected IP bucket to limit all sessions, got %d %v", recorder.Code, recorder.Header())
+	}
+}
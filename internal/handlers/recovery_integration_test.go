@@ -0,0 +1,138 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apierror"
+	"main/internal/handlers"
+	"main/internal/requestid"
+	"main/internal/stream"
+)
+
+// newRecoveryTestRouter 在完整路由上挂载会 panic 的测试路由，日志写入返回的广播器
+func newRecoveryTestRouter(t *testing.T) (*testAPI, *stream.LogBroadcaster) {
+	t.Helper()
+
+	broadcaster := stream.NewLogBroadcaster()
+	previous := slog.Default()
+	slog.SetDefault(slog.New(stream.NewSSELogHandler(slog.LevelDebug, broadcaster)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	env := newTestAPI(t, nil)
+	router := env.router
+	router.GET("/boom/:id", func(c *gin.Context) { panic("boom") })
+	router.GET("/partial", func(c *gin.Context) {
+		c.String(http.StatusOK, "partial")
+		panic("after write")
+	})
+	router.GET("/abort", func(c *gin.Context) {
+		c.String(http.StatusOK, "partial")
+		panic(http.ErrAbortHandler)
+	})
+	router.GET("/gone", func(c *gin.Context) {
+		panic(&net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.EPIPE)})
+	})
+	return env, broadcaster
+}
+
+func TestRecoveryReturnsJSONErrorAndLogsStack(t *testing.T) {
+	env, broadcaster := newRecoveryTestRouter(t)
+
+	recorder := performRequestWithHeaders(env.router, http.MethodGet, "/boom/1", nil, map[string]string{
+		requestid.HeaderName: "panic-req",
+	})
+	if recorder.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", recorder.Code)
+	}
+	var body apierror.Response
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("expected JSON error body, got %q", recorder.Body.String())
+	}
+	if body.Error.Code != apierror.CodeInternal || body.Error.RequestID != "panic-req" {
+		t.Fatalf("unexpected error body %+v", body.Error)
+	}
+
+	var found bool
+	for _, entry := range broadcaster.GetHistory() {
+		if entry.Message != "panic recovered" {
+			continue
+		}
+		found = true
+		stack, _ := entry.Attrs["stack"].(string)
+		if entry.Level != "ERROR" || entry.RequestID != "panic-req" || entry.Attrs["panic"] != "boom" ||
+			entry.Attrs["route"] != "GET /boom/:id" || !strings.Contains(stack, "recovery_integration_test.go") {
+			t.Fatalf("unexpected panic log entry %+v", entry)
+		}
+	}
+	if !found {
+		t.Fatal("expected panic to be logged to the log stream")
+	}
+}
+
+func TestRecoveryCountsPanicsPerRoute(t *testing.T) {
+	env, _ := newRecoveryTestRouter(t)
+
+	for range 2 {
+		performRequest(env.router, http.MethodGet, "/boom/1", nil)
+	}
+	partial := performRequest(env.router, http.MethodGet, "/partial", nil)
+	if partial.Body.String() != "partial" {
+		t.Fatalf("expected no error body after the response started, got %q", partial.Body.String())
+	}
+	performRequest(env.router, http.MethodGet, "/gone", nil)
+
+	recorder := env.loginAs(t, "admin", testAdminPassword).do(http.MethodGet, "/api/dashboard/stats", nil)
+	var stats handlers.SystemStatsResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("decode stats: %v", err)
+	}
+	want := map[string]uint64{"GET /boom/:id": 2, "GET /partial": 1}
+	if len(stats.Panics) != len(want) {
+		t.Fatalf("expected panics %v, got %v", want, stats.Panics)
+	}
+	for route, count := range want {
+		if stats.Panics[route] != count {
+			t.Fatalf("expected panics %v, got %v", want, stats.Panics)
+		}
+	}
+}
+
+func TestRecoveryIgnoresDisconnectedClients(t *testing.T) {
+	env, broadcaster := newRecoveryTestRouter(t)
+
+	recorder := performRequest(env.router, http.MethodGet, "/gone", nil)
+	if recorder.Body.Len() != 0 {
+		t.Fatalf("expected no response body for a disconnected client, got %q", recorder.Body.String())
+	}
+	for _, entry := range broadcaster.GetHistory() {
+		if entry.Level == "ERROR" {
+			t.Fatalf("broken pipe must not be logged as an error: %+v", entry)
+		}
+	}
+}
+
+func TestRecoveryRethrowsAbortHandler(t *testing.T) {
+	env, broadcaster := newRecoveryTestRouter(t)
+
+	// net/http 依赖 ErrAbortHandler 断开连接，Recovery 不能把中止的响应当作正常结束
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Fatalf("expected http.ErrAbortHandler to be re-panicked, got %v", recovered)
+		}
+		for _, entry := range broadcaster.GetHistory() {
+			if entry.Level == "ERROR" {
+				t.Fatalf("aborted response must not be logged as an error: %+v", entry)
+			}
+		}
+	}()
+	performRequest(env.router, http.MethodGet, "/abort", nil)
+}
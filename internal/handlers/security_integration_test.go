@@ -0,0 +1,57 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/config"
+	"main/internal/middleware"
+)
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	env := newTestAPI(t, func(cfg *config.Config) {
+		// Secure Cookie 表示由反向代理终止 TLS，此时才发送 HSTS
+		cfg.CookieSecure = true
+		cfg.HSTSMaxAge = 600
+		cfg.HSTSIncludeSubdomains = true
+		cfg.FrameAncestors = "'self'"
+		cfg.ReferrerPolicy = "strict-origin-when-cross-origin"
+		cfg.PermissionsPolicy = "camera=()"
+		cfg.ContentSecurityPolicy = "default-src 'self';"
+	})
+	router := env.router
+	router.GET("/nonce", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"nonce": middleware.CSPNonce(c)})
+	})
+
+	recorder := performRequest(router, http.MethodGet, "/nonce", nil, nil)
+	want := map[string]string{
+		"Strict-Transport-Security": "max-age=600; includeSubDomains",
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "SAMEORIGIN",
+		"Referrer-Policy":           "strict-origin-when-cross-origin",
+		"Permissions-Policy":        "camera=()",
+		"Content-Security-Policy":   "default-src 'self'; frame-ancestors 'self'",
+	}
+	for header, value := range want {
+		if got := recorder.Header().Get(header); got != value {
+			t.Errorf("%s = %q, want %q", header, got, value)
+		}
+	}
+	if recorder.Body.String() != `{"nonce":""}` {
+		t.Fatalf("expected no nonce without placeholder, got %s", recorder.Body.String())
+	}
+
+	// API 响应同样带有安全响应头
+	if got := performRequest(router, http.MethodGet, "/api/session", nil).Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Fatalf("expected security headers on API responses, got %q", got)
+	}
+
+	// 处理器自行设置的更严格策略优先于默认值
+	recorder = performRequest(router, http.MethodGet, "/api/auth/magic?token=invalid", nil, nil)
+	if got := recorder.Header().Get("Referrer-Policy"); got != "no-referrer" {
+		t.Fatalf("expected handler Referrer-Policy to win, got %q", got)
+	}
+}
@@ -1,30 +1,37 @@
 package handlers
 
 import (
-	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/shirou/gopsutil/v4/mem"
+
+	"main/internal/apierror"
+	"main/internal/middleware"
+	"main/internal/requestid"
 )
 
 // SystemHandler 系统状态处理器
 type SystemHandler struct {
 	startTime int64
+	panics    *middleware.PanicCounter
 }
 
 // SystemStatsResponse 系统状态响应
 type SystemStatsResponse struct {
 	MemoryUsed    uint64  `json:"memory_used"`
 	MemoryTotal   uint64  `json:"memory_total"`
-	MemoryPercent float64 `json:"memory_percent"`
+	MemoryPercent float64 `json:"memory_percent" jsonschema:"minimum=0,maximum=100"`
 	StartTime     int64   `json:"start_time"`
+	// Panics 进程启动以来各路由被恢复的 panic 次数
+	Panics map[string]uint64 `json:"panics"`
 }
 
-// NewSystemHandler 创建系统状态处理器
-func NewSystemHandler(startTime int64) *SystemHandler {
+// NewSystemHandler 创建系统状态处理器，panics 为 nil 时不统计 panic
+func NewSystemHandler(startTime int64, panics *middleware.PanicCounter) *SystemHandler {
 	return &SystemHandler{
 		startTime: startTime,
+		panics:    panics,
 	}
 }
 
@@ -32,10 +39,8 @@ func NewSystemHandler(startTime int64) *SystemHandler {
 func (h *SystemHandler) GetStats(c *gin.Context) {
 	vmStat, err := mem.VirtualMemory()
 	if err != nil {
-		slog.Error("failed to get memory stats", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "获取内存信息失败",
-		})
+		requestid.Logger(c).Error("failed to get memory stats", "error", err)
+		apierror.Respond(c, apierror.CodeSystemStatsUnavailable)
 		return
 	}
 
@@ -44,5 +49,6 @@ func (h *SystemHandler) GetStats(c *gin.Context) {
 		MemoryTotal:   vmStat.Total,
 		MemoryPercent: vmStat.UsedPercent,
 		StartTime:     h.startTime,
+		Panics:        h.panics.Snapshot(),
 	})
 }
@@ -0,0 +1,139 @@
+package handlers_test
+
+import (
+	"database/sql"
+	"embed"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apitoken"
+	"main/internal/audit"
+	"main/internal/config"
+	"main/internal/database"
+	"main/internal/maintenance"
+	"main/internal/server"
+	"main/internal/session"
+	"main/internal/stream"
+	"main/internal/user"
+)
+
+// testAdminPassword newTestAPI 创建的初始管理员 admin 的密码，与正式启动一样取自 AUTH_KEY
+const testAdminPassword = "top-secret-auth-key"
+
+// testAPI 处理器集成测试的公共环境：独立的数据目录与数据库、已创建 admin 的用户库，
+// 以及由 server.NewRouter 构建的完整路由，中间件顺序与正式服务一致
+type testAPI struct {
+	router   *gin.Engine
+	cfg      *config.Config
+	db       *sql.DB
+	users    *user.Store
+	tokens   *apitoken.Store
+	auditLog *audit.Store
+	mode     *maintenance.Mode
+	logs     *stream.LogBroadcaster
+}
+
+// newTestAPI 按默认配置创建测试环境，configure 可在构建路由前调整配置
+func newTestAPI(t *testing.T, configure func(cfg *config.Config)) *testAPI {
+	t.Helper()
+
+	cfg := &config.Config{
+		DataDir:             t.TempDir(),
+		AuthKey:             testAdminPassword,
+		CookieSameSite:      "lax",
+		TOTPIssuer:          "Test",
+		RateLimitMaxClients: 100,
+		MaxRequestBodyBytes: 1 << 20,
+	}
+	if configure != nil {
+		configure(cfg)
+	}
+
+	dbContainer, err := database.Open(t.Context(), database.Options{
+		Path: filepath.Join(cfg.DataDir, "data.db"),
+	})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = dbContainer.Close()
+	})
+
+	users := user.NewStore(dbContainer.DB())
+	if _, err := users.EnsureBootstrapAdmin(t.Context(), user.DefaultAdminUsername, cfg.AuthKey); err != nil {
+		t.Fatalf("bootstrap admin: %v", err)
+	}
+	mode, err := maintenance.Open(cfg.DataDir)
+	if err != nil {
+		t.Fatalf("open maintenance mode: %v", err)
+	}
+	logs := stream.NewLogBroadcaster()
+
+	router, err := server.NewRouter(cfg, dbContainer, logs, mode, 1, embed.FS{})
+	if err != nil {
+		t.Fatalf("create router: %v", err)
+	}
+
+	return &testAPI{
+		router:   router,
+		cfg:      cfg,
+		db:       dbContainer.DB(),
+		users:    users,
+		tokens:   apitoken.NewStore(dbContainer.DB()),
+		auditLog: audit.NewStore(dbContainer.DB()),
+		mode:     mode,
+		logs:     logs,
+	}
+}
+
+// testSession 已登录的客户端，请求时附带会话 Cookie 与登录时下发的 CSRF 令牌
+type testSession struct {
+	router    *gin.Engine
+	cookie    *http.Cookie
+	csrfToken string
+}
+
+// loginAs 以用户名和密码登录，失败时终止测试
+func (a *testAPI) loginAs(t *testing.T, username, password string) *testSession {
+	t.Helper()
+
+	recorder := performRequest(a.router, http.MethodPost, "/api/login", loginBody(username, password))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected login status 200 for %s, got %d: %s", username, recorder.Code, recorder.Body.String())
+	}
+	return a.sessionFrom(t, recorder)
+}
+
+// sessionFrom 从建立会话的响应中取出会话 Cookie 与 CSRF 令牌
+func (a *testAPI) sessionFrom(t *testing.T, recorder *httptest.ResponseRecorder) *testSession {
+	t.Helper()
+
+	cookie := findCookieByName(recorder.Result().Cookies(), "session_id")
+	if cookie == nil {
+		t.Fatalf("expected session_id cookie, got status %d", recorder.Code)
+	}
+	return &testSession{
+		router:    a.router,
+		cookie:    cookie,
+		csrfToken: recorder.Header().Get(session.CSRFHeaderName),
+	}
+}
+
+func (s *testSession) do(method, path string, body []byte) *httptest.ResponseRecorder {
+	return s.doWithHeaders(method, path, body, nil)
+}
+
+func (s *testSession) doWithHeaders(method, path string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+	withToken := map[string]string{}
+	if s.csrfToken != "" {
+		withToken[session.CSRFHeaderName] = s.csrfToken
+	}
+	for key, value := range headers {
+		withToken[key] = value
+	}
+	return performRequestWithHeaders(s.router, method, path, body, withToken, s.cookie)
+}
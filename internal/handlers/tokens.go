@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apierror"
+	"main/internal/apitoken"
+	"main/internal/audit"
+	"main/internal/requestid"
+	"main/internal/user"
+)
+
+// TokenHandler 当前用户的个人访问令牌管理
+type TokenHandler struct {
+	users  *user.Store
+	tokens *apitoken.Store
+	audit  *audit.Store
+}
+
+// NewTokenHandler 创建个人访问令牌处理器
+func NewTokenHandler(users *user.Store, tokens *apitoken.Store, auditLog *audit.Store) *TokenHandler {
+	return &TokenHandler{
+		users:  users,
+		tokens: tokens,
+		audit:  auditLog,
+	}
+}
+
+// CreateTokenRequest 创建令牌请求，expires_in_days 为 0 表示永不过期
+type CreateTokenRequest struct {
+	Name          string   `json:"name" binding:"required" jsonschema:"minLength=1,maxLength=64"`
+	Scopes        []string `json:"scopes" binding:"required" jsonschema:"minItems=1"`
+	ExpiresInDays int      `json:"expires_in_days" jsonschema:"minimum=0,maximum=365"`
+}
+
+// CreateTokenResponse 创建令牌响应，明文 token 仅返回这一次
+type CreateTokenResponse struct {
+	apitoken.Token
+	PlainToken string `json:"token"`
+}
+
+// TokenListResponse 令牌列表响应
+type TokenListResponse struct {
+	Tokens []apitoken.Token `json:"tokens"`
+	Count  int              `json:"count" jsonschema:"minimum=0"`
+}
+
+// ListTokens 获取当前用户的令牌列表
+func (h *TokenHandler) ListTokens(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	id, _ := userID.(int64)
+
+	tokens, err := h.tokens.ListByUser(c.Request.Context(), id)
+	if err != nil {
+		respondTokenError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, TokenListResponse{
+		Tokens: tokens,
+		Count:  len(tokens),
+	})
+}
+
+// CreateToken 为当前用户创建令牌，scope 不能超出用户角色
+func (h *TokenHandler) CreateToken(c *gin.Context) {
+	var req CreateTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBindError(c, err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	id, _ := userID.(int64)
+	owner, err := h.users.GetByID(c.Request.Context(), id)
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	token, plaintext, err := h.tokens.Create(c.Request.Context(), owner, apitoken.CreateParams{
+		Name:          req.Name,
+		Scopes:        req.Scopes,
+		ExpiresInDays: req.ExpiresInDays,
+	})
+	if err != nil {
+		respondTokenError(c, err)
+		return
+	}
+
+	requestid.Logger(c).Info(
+		"api token created",
+		"username",
+		owner.Username,
+		"token_id",
+		token.ID,
+		"prefix",
+		token.Prefix,
+		"scopes",
+		token.Scopes,
+	)
+	h.audit.RecordRequest(c, audit.Event{
+		Action:  audit.ActionAPITokenCreate,
+		Target:  tokenAuditTarget(token.ID),
+		Outcome: audit.OutcomeSuccess,
+		Detail:  fmt.Sprintf("name=%s prefix=%s scopes=%v", token.Name, token.Prefix, token.Scopes),
+	})
+
+	c.JSON(http.StatusCreated, CreateTokenResponse{
+		Token:      *token,
+		PlainToken: plaintext,
+	})
+}
+
+// RevokeToken 吊销当前用户的令牌
+func (h *TokenHandler) RevokeToken(c *gin.Context) {
+	tokenID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || tokenID <= 0 {
+		apierror.Respond(c, apierror.CodeInvalidTokenID)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	username, _ := c.Get("username")
+	id, _ := userID.(int64)
+
+	if err := h.tokens.Revoke(c.Request.Context(), id, tokenID); err != nil {
+		respondTokenError(c, err)
+		return
+	}
+
+	requestid.Logger(c).Info("api token revoked", "username", username, "token_id", tokenID)
+	h.audit.RecordRequest(c, audit.Event{
+		Action:  audit.ActionAPITokenRevoke,
+		Target:  tokenAuditTarget(tokenID),
+		Outcome: audit.OutcomeSuccess,
+	})
+
+	c.Status(http.StatusNoContent)
+}
+
+func tokenAuditTarget(id int64) string {
+	return "api_token:" + strconv.FormatInt(id, 10)
+}
+
+func respondTokenError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, apitoken.ErrNotFound):
+		apierror.Respond(c, apierror.CodeTokenNotFound)
+	case errors.Is(err, apitoken.ErrInvalidName):
+		apierror.Respond(c, apierror.CodeInvalidTokenName, apierror.Field("name", apierror.CodeFieldInvalid))
+	case errors.Is(err, apitoken.ErrInvalidScope):
+		apierror.Respond(c, apierror.CodeInvalidScope, apierror.Field("scopes", apierror.CodeFieldInvalid))
+	case errors.Is(err, apitoken.ErrScopeForbidden):
+		apierror.Respond(c, apierror.CodeScopeForbidden)
+	case errors.Is(err, apitoken.ErrInvalidExpiry):
+		apierror.Respond(c, apierror.CodeInvalidExpiry, apierror.Field("expires_in_days", apierror.CodeFieldInvalid))
+	default:
+		requestid.Logger(c).Error("api token operation failed", "error", err)
+		apierror.Respond(c, apierror.CodeInternal)
+	}
+}
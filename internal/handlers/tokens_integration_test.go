@@ -0,0 +1,126 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/user"
+)
+
+type createTokenResponse struct {
+	ID     int64    `json:"id"`
+	Name   string   `json:"name"`
+	Prefix string   `json:"prefix"`
+	Scopes []string `json:"scopes"`
+	Token  string   `json:"token"`
+}
+
+func performBearerRequest(router *gin.Engine, method, path, token string) *httptest.ResponseRecorder {
+	return performRequestWithHeaders(router, method, path, nil, map[string]string{
+		"Authorization": "Bearer " + token,
+	})
+}
+
+func createToken(t *testing.T, owner *testSession, payload map[string]any) createTokenResponse {
+	t.Helper()
+
+	body, _ := json.Marshal(payload)
+	recorder := owner.do(http.MethodPost, "/api/account/tokens", body)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("expected token create status 201, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var created createTokenResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to parse create token response: %v", err)
+	}
+	return created
+}
+
+func TestTokenAuthenticatesScopedRoutes(t *testing.T) {
+	env := newTestAPI(t, nil)
+	adminSession := env.loginAs(t, "admin", testAdminPassword)
+
+	created := createToken(t, adminSession, map[string]any{
+		"name":   "cron",
+		"scopes": []string{"stats:read"},
+	})
+	if created.Token == "" || created.Prefix == "" || !bytes.HasPrefix([]byte(created.Token), []byte(created.Prefix)) {
+		t.Fatalf("unexpected create token response: %+v", created)
+	}
+
+	if recorder := performBearerRequest(env.router, http.MethodGet, "/api/dashboard/stats", created.Token); recorder.Code != http.StatusOK {
+		t.Fatalf("expected scoped token to access stats, got %d", recorder.Code)
+	}
+	if recorder := performBearerRequest(env.router, http.MethodGet, "/api/logs/history", created.Token); recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected token without logs:read to be forbidden, got %d", recorder.Code)
+	}
+	// 令牌不能访问仅限会话的接口
+	if recorder := performBearerRequest(env.router, http.MethodGet, "/api/account/tokens", created.Token); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected token on session-only route to be rejected, got %d", recorder.Code)
+	}
+	if recorder := performBearerRequest(env.router, http.MethodGet, "/api/dashboard/stats", "vgs_not-a-real-token"); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected unknown token status 401, got %d", recorder.Code)
+	}
+
+	listRecorder := adminSession.do(http.MethodGet, "/api/account/tokens", nil)
+	if bytes.Contains(listRecorder.Body.Bytes(), []byte(created.Token)) {
+		t.Fatal("expected token list to never include plaintext token")
+	}
+	var listed struct {
+		Tokens []struct {
+			ID         int64  `json:"id"`
+			LastUsedAt *int64 `json:"last_used_at"`
+		} `json:"tokens"`
+		Count int `json:"count"`
+	}
+	if err := json.Unmarshal(listRecorder.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("failed to parse token list: %v", err)
+	}
+	if listed.Count != 1 || listed.Tokens[0].LastUsedAt == nil {
+		t.Fatalf("expected one used token, got %s", listRecorder.Body.String())
+	}
+
+	revokePath := "/api/account/tokens/" + strconv.FormatInt(created.ID, 10)
+	if recorder := adminSession.do(http.MethodDelete, revokePath, nil); recorder.Code != http.StatusNoContent {
+		t.Fatalf("expected revoke status 204, got %d", recorder.Code)
+	}
+	if recorder := performBearerRequest(env.router, http.MethodGet, "/api/dashboard/stats", created.Token); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected revoked token status 401, got %d", recorder.Code)
+	}
+}
+
+func TestTokenScopesAreLimitedByOwnerRole(t *testing.T) {
+	env := newTestAPI(t, nil)
+	if _, err := env.users.Create(t.Context(), "victor", "viewer-password", user.RoleViewer); err != nil {
+		t.Fatalf("create viewer: %v", err)
+	}
+	viewerSession := env.loginAs(t, "victor", "viewer-password")
+
+	body, _ := json.Marshal(map[string]any{"name": "logs", "scopes": []string{"logs:read"}})
+	if recorder := viewerSession.do(http.MethodPost, "/api/account/tokens", body); recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected viewer logs:read token to be forbidden, got %d", recorder.Code)
+	}
+
+	created := createToken(t, viewerSession, map[string]any{
+		"name":            "stats",
+		"scopes":          []string{"stats:read"},
+		"expires_in_days": 30,
+	})
+
+	// 所有者被禁用后令牌立即失效
+	disabled := true
+	victor, _ := env.users.GetByUsername(t.Context(), "victor")
+	if _, err := env.users.Update(t.Context(), victor.ID, user.UpdateParams{Disabled: &disabled}); err != nil {
+		t.Fatalf("disable viewer: %v", err)
+	}
+	if recorder := performBearerRequest(env.router, http.MethodGet, "/api/dashboard/stats", created.Token); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected disabled owner token status 401, got %d", recorder.Code)
+	}
+}
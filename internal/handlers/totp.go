@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apierror"
+	"main/internal/audit"
+	"main/internal/requestid"
+	"main/internal/totp"
+	"main/internal/user"
+)
+
+// TOTPHandler 当前用户的两步验证管理
+type TOTPHandler struct {
+	users  *user.Store
+	audit  *audit.Store
+	issuer string
+}
+
+// NewTOTPHandler 创建两步验证处理器
+func NewTOTPHandler(users *user.Store, auditLog *audit.Store, issuer string) *TOTPHandler {
+	return &TOTPHandler{
+		users:  users,
+		audit:  auditLog,
+		issuer: issuer,
+	}
+}
+
+// TOTPSetupResponse 两步验证注册响应，otpauth_uri 可直接生成二维码
+type TOTPSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// TOTPCodeRequest 携带验证码的请求
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TOTPConfirmResponse 两步验证启用响应，恢复码仅返回这一次
+type TOTPConfirmResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// Setup 生成新的 TOTP 密钥，需调用 Confirm 校验首个验证码后才会启用
+func (h *TOTPHandler) Setup(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	username, _ := c.Get("username")
+	id, _ := userID.(int64)
+	name, _ := username.(string)
+
+	secret, err := h.users.BeginTOTPEnrollment(c.Request.Context(), id)
+	if err != nil {
+		respondTOTPError(c, err)
+		return
+	}
+
+	requestid.Logger(c).Info("totp enrollment started", "username", name)
+
+	c.JSON(http.StatusOK, TOTPSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(h.issuer, name, secret),
+	})
+}
+
+// Confirm 校验首个验证码并启用两步验证
+func (h *TOTPHandler) Confirm(c *gin.Context) {
+	var req TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBindError(c, err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	username, _ := c.Get("username")
+	id, _ := userID.(int64)
+
+	codes, err := h.users.ConfirmTOTPEnrollment(c.Request.Context(), id, req.Code)
+	if err != nil {
+		respondTOTPError(c, err)
+		return
+	}
+
+	requestid.Logger(c).Info("totp enabled", "username", username)
+	h.audit.RecordRequest(c, audit.Event{
+		Action:  audit.ActionTOTPEnable,
+		Target:  userAuditTarget(id),
+		Outcome: audit.OutcomeSuccess,
+	})
+
+	c.JSON(http.StatusOK, TOTPConfirmResponse{
+		RecoveryCodes: codes,
+	})
+}
+
+// Disable 校验当前验证码后关闭自己的两步验证
+func (h *TOTPHandler) Disable(c *gin.Context) {
+	var req TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBindError(c, err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	username, _ := c.Get("username")
+	id, _ := userID.(int64)
+
+	if err := h.users.VerifyTOTP(c.Request.Context(), id, req.Code); err != nil {
+		respondTOTPError(c, err)
+		return
+	}
+	if err := h.users.ResetTOTP(c.Request.Context(), id); err != nil {
+		respondTOTPError(c, err)
+		return
+	}
+
+	requestid.Logger(c).Info("totp disabled", "username", username)
+	h.audit.RecordRequest(c, audit.Event{
+		Action:  audit.ActionTOTPDisable,
+		Target:  userAuditTarget(id),
+		Outcome: audit.OutcomeSuccess,
+	})
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondTOTPError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, user.ErrTOTPAlreadyEnabled):
+		apierror.Respond(c, apierror.CodeTOTPAlreadyEnabled)
+	case errors.Is(err, user.ErrTOTPNotEnrolled):
+		apierror.Respond(c, apierror.CodeTOTPNotEnrolled)
+	case errors.Is(err, user.ErrInvalidTOTPCode):
+		apierror.Respond(c, apierror.CodeInvalidTOTPCode)
+	default:
+		respondUserError(c, err)
+	}
+}
@@ -0,0 +1,107 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"main/internal/totp"
+)
+
+type loginStepResponse struct {
+	Success     bool `json:"success"`
+	MFARequired bool `json:"mfa_required"`
+}
+
+func TestTOTPLoginRequiresSecondStep(t *testing.T) {
+	env := newTestAPI(t, nil)
+	adminSession := env.loginAs(t, "admin", testAdminPassword)
+
+	setupRecorder := adminSession.do(http.MethodPost, "/api/account/totp/setup", nil)
+	if setupRecorder.Code != http.StatusOK {
+		t.Fatalf("expected setup status 200, got %d: %s", setupRecorder.Code, setupRecorder.Body.String())
+	}
+	var setup struct {
+		Secret     string `json:"secret"`
+		OTPAuthURI string `json:"otpauth_uri"`
+	}
+	if err := json.Unmarshal(setupRecorder.Body.Bytes(), &setup); err != nil {
+		t.Fatalf("failed to parse setup response: %v", err)
+	}
+	if setup.Secret == "" || setup.OTPAuthURI == "" {
+		t.Fatalf("unexpected setup response: %s", setupRecorder.Body.String())
+	}
+
+	now := time.Now()
+	code, _ := totp.Code(setup.Secret, totp.Step(now))
+	confirmRecorder := adminSession.do(
+		http.MethodPost,
+		"/api/account/totp/confirm",
+		[]byte(fmt.Sprintf(`{"code":%q}`, code)),
+	)
+	if confirmRecorder.Code != http.StatusOK {
+		t.Fatalf("expected confirm status 200, got %d: %s", confirmRecorder.Code, confirmRecorder.Body.String())
+	}
+	var confirm struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	if err := json.Unmarshal(confirmRecorder.Body.Bytes(), &confirm); err != nil {
+		t.Fatalf("failed to parse confirm response: %v", err)
+	}
+
+	passwordRecorder := performRequest(env.router, http.MethodPost, "/api/login", loginBody("admin", testAdminPassword))
+	if passwordRecorder.Code != http.StatusOK {
+		t.Fatalf("expected password step status 200, got %d", passwordRecorder.Code)
+	}
+	var step loginStepResponse
+	if err := json.Unmarshal(passwordRecorder.Body.Bytes(), &step); err != nil {
+		t.Fatalf("failed to parse login response: %v", err)
+	}
+	if step.Success || !step.MFARequired {
+		t.Fatalf("expected mfa_required response, got %s", passwordRecorder.Body.String())
+	}
+
+	pending := env.sessionFrom(t, passwordRecorder)
+
+	protectedRecorder := pending.do(http.MethodGet, "/api/dashboard/stats", nil)
+	if protectedRecorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected half-authenticated session to be rejected, got %d", protectedRecorder.Code)
+	}
+
+	wrongRecorder := pending.do(http.MethodPost, "/api/login/totp", []byte(`{"code":"000000"}`))
+	if wrongRecorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected wrong code status 401, got %d", wrongRecorder.Code)
+	}
+
+	recoveryRecorder := pending.do(
+		http.MethodPost,
+		"/api/login/totp",
+		[]byte(fmt.Sprintf(`{"recovery_code":%q}`, confirm.RecoveryCodes[0])),
+	)
+	if recoveryRecorder.Code != http.StatusOK {
+		t.Fatalf("expected recovery code login status 200, got %d: %s", recoveryRecorder.Code, recoveryRecorder.Body.String())
+	}
+	full := env.sessionFrom(t, recoveryRecorder)
+
+	protectedRecorder = full.do(http.MethodGet, "/api/dashboard/stats", nil)
+	if protectedRecorder.Code != http.StatusOK {
+		t.Fatalf("expected full session to be accepted, got %d", protectedRecorder.Code)
+	}
+
+	admin, err := env.users.GetByUsername(t.Context(), "admin")
+	if err != nil {
+		t.Fatalf("load admin: %v", err)
+	}
+	resetRecorder := full.do(
+		http.MethodDelete,
+		fmt.Sprintf("/api/users/%d/totp", admin.ID),
+		nil,
+	)
+	if resetRecorder.Code != http.StatusNoContent {
+		t.Fatalf("expected reset status 204, got %d", resetRecorder.Code)
+	}
+
+	env.loginAs(t, "admin", testAdminPassword)
+}
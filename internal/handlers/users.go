@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apierror"
+	"main/internal/audit"
+	"main/internal/requestid"
+	"main/internal/user"
+)
+
+// UserHandler 用户管理处理器
+type UserHandler struct {
+	users *user.Store
+	audit *audit.Store
+}
+
+// NewUserHandler 创建用户管理处理器
+func NewUserHandler(users *user.Store, auditLog *audit.Store) *UserHandler {
+	return &UserHandler{
+		users: users,
+		audit: auditLog,
+	}
+}
+
+// CreateUserRequest 创建用户请求
+type CreateUserRequest struct {
+	Username string    `json:"username" binding:"required" jsonschema:"pattern=^[A-Za-z0-9][A-Za-z0-9._@-]{0,63}$"`
+	Password string    `json:"password" binding:"required" jsonschema:"minLength=8,maxLength=72"`
+	Role     user.Role `json:"role" binding:"required"`
+}
+
+// UpdateUserRequest 更新用户请求，省略的字段保持不变
+type UpdateUserRequest struct {
+	Role     *user.Role `json:"role"`
+	Disabled *bool      `json:"disabled"`
+	Password *string    `json:"password" jsonschema:"minLength=8,maxLength=72"`
+	// ExternalAuth 允许可信代理或客户端证书以该用户名登录
+	ExternalAuth *bool `json:"external_auth"`
+}
+
+// UserListResponse 用户列表响应
+type UserListResponse struct {
+	Users []user.User `json:"users"`
+	Count int         `json:"count" jsonschema:"minimum=0"`
+}
+
+// ListUsers 获取用户列表
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	users, err := h.users.List(c.Request.Context())
+	if err != nil {
+		requestid.Logger(c).Error("failed to list users", "error", err)
+		apierror.Respond(c, apierror.CodeInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, UserListResponse{
+		Users: users,
+		Count: len(users),
+	})
+}
+
+// CreateUser 创建用户
+func (h *UserHandler) CreateUser(c *gin.Context) {
+	var req CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBindError(c, err)
+		return
+	}
+
+	role, err := user.ParseRole(string(req.Role))
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	created, err := h.users.Create(c.Request.Context(), req.Username, req.Password, role)
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	actor, _ := c.Get("username")
+	requestid.Logger(c).Info("user created", "actor", actor, "username", created.Username, "role", created.Role)
+	h.audit.RecordRequest(c, audit.Event{
+		Action:  audit.ActionUserCreate,
+		Target:  userAuditTarget(created.ID),
+		Outcome: audit.OutcomeSuccess,
+		Detail:  fmt.Sprintf("username=%s role=%s", created.Username, created.Role),
+	})
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// UpdateUser 更新用户角色、禁用状态、密码或外部身份源关联
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBindError(c, err)
+		return
+	}
+
+	params := user.UpdateParams{
+		Disabled:     req.Disabled,
+		Password:     req.Password,
+		ExternalAuth: req.ExternalAuth,
+	}
+	if req.Role != nil {
+		role, err := user.ParseRole(string(*req.Role))
+		if err != nil {
+			respondUserError(c, err)
+			return
+		}
+		params.Role = &role
+	}
+
+	if isCurrentUser(c, id) && req.Disabled != nil && *req.Disabled {
+		apierror.Respond(c, apierror.CodeCannotDisableSelf)
+		return
+	}
+
+	updated, err := h.users.Update(c.Request.Context(), id, params)
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	actor, _ := c.Get("username")
+	requestid.Logger(c).Info(
+		"user updated",
+		"actor",
+		actor,
+		"username",
+		updated.Username,
+		"role",
+		updated.Role,
+		"disabled",
+		updated.Disabled,
+		"password_changed",
+		req.Password != nil,
+		"external_auth",
+		updated.ExternalAuth,
+	)
+	h.audit.RecordRequest(c, audit.Event{
+		Action:  audit.ActionUserUpdate,
+		Target:  userAuditTarget(updated.ID),
+		Outcome: audit.OutcomeSuccess,
+		Detail: fmt.Sprintf(
+			"username=%s role=%s disabled=%t password_changed=%t external_auth=%t",
+			updated.Username,
+			updated.Role,
+			updated.Disabled,
+			req.Password != nil,
+			updated.ExternalAuth,
+		),
+	})
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteUser 删除用户
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	if isCurrentUser(c, id) {
+		apierror.Respond(c, apierror.CodeCannotDeleteSelf)
+		return
+	}
+
+	if err := h.users.Delete(c.Request.Context(), id); err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	actor, _ := c.Get("username")
+	requestid.Logger(c).Info("user deleted", "actor", actor, "user_id", id)
+	h.audit.RecordRequest(c, audit.Event{
+		Action:  audit.ActionUserDelete,
+		Target:  userAuditTarget(id),
+		Outcome: audit.OutcomeSuccess,
+	})
+
+	c.Status(http.StatusNoContent)
+}
+
+// ResetTOTP 管理员重置用户的两步验证（如用户丢失认证器和恢复码）
+func (h *UserHandler) ResetTOTP(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.users.ResetTOTP(c.Request.Context(), id); err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	actor, _ := c.Get("username")
+	requestid.Logger(c).Info("user totp reset", "actor", actor, "user_id", id)
+	h.audit.RecordRequest(c, audit.Event{
+		Action:  audit.ActionUserTOTPReset,
+		Target:  userAuditTarget(id),
+		Outcome: audit.OutcomeSuccess,
+	})
+
+	c.Status(http.StatusNoContent)
+}
+
+func parseUserID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		apierror.Respond(c, apierror.CodeInvalidUserID)
+		return 0, false
+	}
+	return id, true
+}
+
+func userAuditTarget(id int64) string {
+	return "user:" + strconv.FormatInt(id, 10)
+}
+
+func isCurrentUser(c *gin.Context, id int64) bool {
+	currentID, _ := c.Get("user_id")
+	return currentID == id
+}
+
+func respondUserError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, user.ErrNotFound):
+		apierror.Respond(c, apierror.CodeUserNotFound)
+	case errors.Is(err, user.ErrUsernameTaken):
+		apierror.Respond(c, apierror.CodeUsernameTaken)
+	case errors.Is(err, user.ErrInvalidUsername):
+		apierror.Respond(c, apierror.CodeInvalidUsername, apierror.Field("username", apierror.CodeFieldInvalid))
+	case errors.Is(err, user.ErrInvalidRole):
+		apierror.Respond(c, apierror.CodeInvalidRole, apierror.Field("role", apierror.CodeFieldInvalid))
+	case errors.Is(err, user.ErrInvalidPassword):
+		apierror.Respond(c, apierror.CodeInvalidPassword, apierror.Field("password", apierror.CodeFieldInvalid))
+	case errors.Is(err, user.ErrLastAdmin):
+		apierror.Respond(c, apierror.CodeLastAdmin)
+	default:
+		requestid.Logger(c).Error("user operation failed", "error", err)
+		apierror.Respond(c, apierror.CodeInternal)
+	}
+}
@@ -0,0 +1,129 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"main/internal/user"
+)
+
+func TestRoleMiddlewareEnforcesMinimumRole(t *testing.T) {
+	env := newTestAPI(t, nil)
+	if _, err := env.users.Create(t.Context(), "alice", "viewer-password", user.RoleViewer); err != nil {
+		t.Fatalf("create viewer: %v", err)
+	}
+	if _, err := env.users.Create(t.Context(), "bob", "operator-password", user.RoleOperator); err != nil {
+		t.Fatalf("create operator: %v", err)
+	}
+
+	tests := []struct {
+		username string
+		password string
+		path     string
+		want     int
+	}{
+		{username: "alice", password: "viewer-password", path: "/api/dashboard/stats", want: http.StatusOK},
+		{username: "alice", password: "viewer-password", path: "/api/logs/history", want: http.StatusForbidden},
+		{username: "alice", password: "viewer-password", path: "/api/users", want: http.StatusForbidden},
+		{username: "bob", password: "operator-password", path: "/api/logs/history", want: http.StatusOK},
+		{username: "bob", password: "operator-password", path: "/api/users", want: http.StatusForbidden},
+		{username: "admin", password: testAdminPassword, path: "/api/users", want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %s", tt.username, tt.path), func(t *testing.T) {
+			recorder := env.loginAs(t, tt.username, tt.password).do(http.MethodGet, tt.path, nil)
+			if recorder.Code != tt.want {
+				t.Fatalf("expected status %d, got %d", tt.want, recorder.Code)
+			}
+		})
+	}
+}
+
+func TestAdminCreatesAndDisablesUser(t *testing.T) {
+	env := newTestAPI(t, nil)
+	admin := env.loginAs(t, "admin", testAdminPassword)
+
+	createRecorder := admin.do(
+		http.MethodPost,
+		"/api/users",
+		[]byte(`{"username":"carol","password":"carol-password","role":"operator"}`),
+	)
+	if createRecorder.Code != http.StatusCreated {
+		t.Fatalf("expected create status 201, got %d: %s", createRecorder.Code, createRecorder.Body.String())
+	}
+
+	var created struct {
+		ID           int64  `json:"id"`
+		Role         string `json:"role"`
+		PasswordHash string `json:"password_hash"`
+	}
+	if err := json.Unmarshal(createRecorder.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to parse create response: %v", err)
+	}
+	if created.Role != "operator" || created.PasswordHash != "" {
+		t.Fatalf("unexpected create response: %s", createRecorder.Body.String())
+	}
+
+	duplicateRecorder := admin.do(
+		http.MethodPost,
+		"/api/users",
+		[]byte(`{"username":"CAROL","password":"carol-password","role":"viewer"}`),
+	)
+	if duplicateRecorder.Code != http.StatusConflict {
+		t.Fatalf("expected duplicate status 409, got %d", duplicateRecorder.Code)
+	}
+
+	carol := env.loginAs(t, "carol", "carol-password")
+
+	disableRecorder := admin.do(
+		http.MethodPatch,
+		fmt.Sprintf("/api/users/%d", created.ID),
+		[]byte(`{"disabled":true}`),
+	)
+	if disableRecorder.Code != http.StatusOK {
+		t.Fatalf("expected disable status 200, got %d: %s", disableRecorder.Code, disableRecorder.Body.String())
+	}
+
+	protectedRecorder := carol.do(http.MethodGet, "/api/dashboard/stats", nil)
+	if protectedRecorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected disabled user session to be rejected, got %d", protectedRecorder.Code)
+	}
+
+	loginRecorder := performRequest(env.router, http.MethodPost, "/api/login", loginBody("carol", "carol-password"))
+	if loginRecorder.Code != http.StatusForbidden {
+		t.Fatalf("expected disabled user login status 403, got %d", loginRecorder.Code)
+	}
+}
+
+func TestLastAdminCannotBeRemoved(t *testing.T) {
+	env := newTestAPI(t, nil)
+	second, err := env.users.Create(t.Context(), "root2", "second-admin-password", user.RoleAdmin)
+	if err != nil {
+		t.Fatalf("create second admin: %v", err)
+	}
+	secondSession := env.loginAs(t, "root2", "second-admin-password")
+
+	admin, err := env.users.GetByUsername(t.Context(), "admin")
+	if err != nil {
+		t.Fatalf("load admin: %v", err)
+	}
+
+	deleteRecorder := secondSession.do(
+		http.MethodDelete,
+		fmt.Sprintf("/api/users/%d", admin.ID),
+		nil,
+	)
+	if deleteRecorder.Code != http.StatusNoContent {
+		t.Fatalf("expected delete status 204, got %d: %s", deleteRecorder.Code, deleteRecorder.Body.String())
+	}
+
+	if _, err := env.users.Update(t.Context(), second.ID, user.UpdateParams{Role: new(user.RoleViewer)}); err != user.ErrLastAdmin {
+		t.Fatalf("expected ErrLastAdmin when demoting last admin, got %v", err)
+	}
+	if err := env.users.Delete(t.Context(), second.ID); err != user.ErrLastAdmin {
+		t.Fatalf("expected ErrLastAdmin when deleting last admin, got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+// Package keyfile 管理 DATA_DIR 下的随机签名密钥文件，供不应随 AUTH_KEY 变化的签名使用。
+package keyfile
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// KeySize 生成的密钥字节数
+const KeySize = 32
+
+// LoadOrCreate 读取密钥文件，不存在时生成；并发创建时以先写入者为准
+func LoadOrCreate(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err == nil {
+		return decode(path, content)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("keyfile: failed to read %s: %w", path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("keyfile: failed to create data dir: %w", err)
+	}
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("keyfile: failed to generate key: %w", err)
+	}
+
+	// 先写临时文件再硬链接到目标路径，其他进程不会读到写了一半的密钥
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("keyfile: failed to create %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.WriteString(hex.EncodeToString(key))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("keyfile: failed to write %s: %w", path, err)
+	}
+
+	if err := os.Link(tmp.Name(), path); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return LoadOrCreate(path)
+		}
+		return nil, fmt.Errorf("keyfile: failed to install %s: %w", path, err)
+	}
+	return key, nil
+}
+
+func decode(path string, content []byte) ([]byte, error) {
+	key, err := hex.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil || len(key) < KeySize {
+		return nil, fmt.Errorf("keyfile: malformed key file %s", path)
+	}
+	return key, nil
+}
@@ -0,0 +1,40 @@
+package keyfile
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOrCreatePersistsKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", ".test_key")
+
+	key, err := LoadOrCreate(path)
+	if err != nil || len(key) != KeySize {
+		t.Fatalf("expected new key, got %x (%v)", key, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat key file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("expected key file mode 0600, got %v", info.Mode().Perm())
+	}
+
+	again, err := LoadOrCreate(path)
+	if err != nil || !bytes.Equal(again, key) {
+		t.Fatalf("expected the same key on reload, got %x (%v)", again, err)
+	}
+}
+
+func TestLoadOrCreateRejectsMalformedKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".test_key")
+	if err := os.WriteFile(path, []byte("not-hex"), 0600); err != nil {
+		t.Fatalf("write key file: %v", err)
+	}
+
+	if _, err := LoadOrCreate(path); err == nil {
+		t.Fatal("expected malformed key file to be rejected")
+	}
+}
@@ -0,0 +1,146 @@
+// Package loginlink 签发一次性登录链接，供无法查看 AUTH_KEY 的无头部署完成首次登录。
+package loginlink
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"main/internal/keyfile"
+)
+
+// KeyFileName 签名密钥文件名，位于 DATA_DIR 下，服务进程与 login-link 命令共用
+const KeyFileName = ".login_link_key"
+
+// Path 消费登录链接的接口路径
+const Path = "/api/v1/auth/magic"
+
+var (
+	ErrInvalid = errors.New("loginlink: invalid token")
+	ErrExpired = errors.New("loginlink: token expired")
+	ErrUsed    = errors.New("loginlink: token already used")
+)
+
+// Store 签发与消费登录链接。
+// 令牌自身携带用户 ID 与过期时间并由 HMAC 签名，已使用的令牌记录在 login_link_uses 表中。
+type Store struct {
+	db      *sql.DB
+	keyPath string
+	now     func() time.Time
+
+	mu  sync.Mutex
+	key []byte
+}
+
+// NewStore 创建登录链接存储，签名密钥在首次使用时从 DATA_DIR 加载或生成
+func NewStore(db *sql.DB, dataDir string) *Store {
+	return &Store{
+		db:      db,
+		keyPath: filepath.Join(dataDir, KeyFileName),
+		now:     time.Now,
+	}
+}
+
+// Mint 为用户签发有效期为 ttl 的一次性令牌
+func (s *Store) Mint(userID int64, ttl time.Duration) (string, time.Time, error) {
+	key, err := s.signingKey()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", time.Time{}, fmt.Errorf("loginlink: failed to generate nonce: %w", err)
+	}
+
+	expiresAt := s.now().Add(ttl)
+	payload := strconv.FormatInt(userID, 10) + "." + strconv.FormatInt(expiresAt.Unix(), 10) + "." + hex.EncodeToString(nonce)
+	return payload + "." + sign(key, payload), expiresAt, nil
+}
+
+// Consume 校验签名与有效期并将令牌标记为已使用，返回令牌对应的用户 ID
+func (s *Store) Consume(ctx context.Context, token string) (int64, error) {
+	key, err := s.signingKey()
+	if err != nil {
+		return 0, err
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 {
+		return 0, ErrInvalid
+	}
+	payload := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(sign(key, payload))) {
+		return 0, ErrInvalid
+	}
+	userID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, ErrInvalid
+	}
+	expiresAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, ErrInvalid
+	}
+
+	now := s.now().Unix()
+	if now >= expiresAt {
+		return 0, ErrExpired
+	}
+
+	// 过期令牌无法通过上面的校验，对应的使用记录可以删除
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM login_link_uses WHERE expires_at <= ?`, now); err != nil {
+		return 0, fmt.Errorf("loginlink: failed to prune used tokens: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO login_link_uses(nonce, user_id, expires_at, used_at) VALUES (?, ?, ?, ?)`,
+		parts[2],
+		userID,
+		expiresAt,
+		now,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return 0, ErrUsed
+		}
+		return 0, fmt.Errorf("loginlink: failed to mark token used: %w", err)
+	}
+
+	return userID, nil
+}
+
+// URL 拼接完整登录链接，baseURL 形如 https://example.com
+func URL(baseURL, token string) string {
+	return strings.TrimRight(baseURL, "/") + Path + "?token=" + url.QueryEscape(token)
+}
+
+func (s *Store) signingKey() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.key != nil {
+		return s.key, nil
+	}
+	key, err := keyfile.LoadOrCreate(s.keyPath)
+	if err != nil {
+		return nil, err
+	}
+	s.key = key
+	return key, nil
+}
+
+func sign(key []byte, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,97 @@
+package loginlink
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"main/internal/database"
+)
+
+func newTestStore(t *testing.T, now time.Time) *Store {
+	t.Helper()
+
+	dir := t.TempDir()
+	dbContainer, err := database.Open(t.Context(), database.Options{
+		Path: filepath.Join(dir, "data.db"),
+	})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = dbContainer.Close()
+	})
+
+	store := NewStore(dbContainer.DB(), dir)
+	store.now = func() time.Time { return now }
+	return store
+}
+
+func TestConsumeIsSingleUse(t *testing.T) {
+	store := newTestStore(t, time.Unix(1_700_000_000, 0))
+
+	token, expiresAt, err := store.Mint(42, 15*time.Minute)
+	if err != nil {
+		t.Fatalf("mint: %v", err)
+	}
+	if expiresAt.Unix() != 1_700_000_000+15*60 {
+		t.Fatalf("unexpected expiry: %v", expiresAt)
+	}
+
+	userID, err := store.Consume(t.Context(), token)
+	if err != nil || userID != 42 {
+		t.Fatalf("expected first use to succeed, got %d (%v)", userID, err)
+	}
+	if _, err := store.Consume(t.Context(), token); !errors.Is(err, ErrUsed) {
+		t.Fatalf("expected second use to fail with ErrUsed, got %v", err)
+	}
+}
+
+func TestConsumeRejectsExpiredAndForgedTokens(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	store := newTestStore(t, now)
+
+	token, _, err := store.Mint(1, time.Minute)
+	if err != nil {
+		t.Fatalf("mint: %v", err)
+	}
+
+	forged := strings.Replace(token, "1.", "2.", 1)
+	if _, err := store.Consume(t.Context(), forged); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected tampered user id to be rejected, got %v", err)
+	}
+	if _, err := store.Consume(t.Context(), "garbage"); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected malformed token to be rejected, got %v", err)
+	}
+
+	store.now = func() time.Time { return now.Add(time.Minute) }
+	if _, err := store.Consume(t.Context(), token); !errors.Is(err, ErrExpired) {
+		t.Fatalf("expected expired token to be rejected, got %v", err)
+	}
+}
+
+func TestSigningKeyIsSharedThroughDataDir(t *testing.T) {
+	store := newTestStore(t, time.Unix(1_700_000_000, 0))
+	token, _, err := store.Mint(7, time.Minute)
+	if err != nil {
+		t.Fatalf("mint: %v", err)
+	}
+
+	info, err := os.Stat(store.keyPath)
+	if err != nil {
+		t.Fatalf("stat key file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("expected key file mode 0600, got %v", info.Mode().Perm())
+	}
+
+	// 模拟 login-link 命令与服务进程分别创建的 Store
+	other := NewStore(store.db, filepath.Dir(store.keyPath))
+	other.now = store.now
+	if userID, err := other.Consume(t.Context(), token); err != nil || userID != 7 {
+		t.Fatalf("expected token minted by another store to verify, got %d (%v)", userID, err)
+	}
+}
@@ -0,0 +1,261 @@
+// Package maintenance 维护模式：开启后拒绝写操作，只读接口与日志流照常可用，用于数据迁移或恢复期间冻结写入。
+// 状态保存在 DATA_DIR 下的文件中，服务进程与 maintenance 命令共用，重启后保持。
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// FileName 状态文件名，位于 DATA_DIR 下
+const FileName = "maintenance.json"
+
+const (
+	// MaxMessageLength 说明文字的最大字符数
+	MaxMessageLength = 500
+	// MaxRetryAfterSeconds Retry-After 的上限
+	MaxRetryAfterSeconds = 86400
+	// DefaultRetryAfterSeconds 未指定时建议客户端的重试间隔
+	DefaultRetryAfterSeconds = 300
+	// PollInterval 服务进程重新读取状态文件的间隔
+	PollInterval = 5 * time.Second
+)
+
+var ErrInvalidState = errors.New("maintenance: invalid state")
+
+// State 维护模式状态
+type State struct {
+	Enabled bool `json:"enabled"`
+	// Message 展示给用户的说明，为空时使用默认文案
+	Message string `json:"message,omitempty" jsonschema:"maxLength=500"`
+	// RetryAfterSeconds 写操作被拒绝时 Retry-After 响应头的值，0 表示使用默认值
+	RetryAfterSeconds int `json:"retry_after_seconds" jsonschema:"minimum=0,maximum=86400"`
+	// UpdatedAt 最近一次切换的时间（Unix 秒），从未切换过为 0
+	UpdatedAt int64 `json:"updated_at"`
+	// UpdatedBy 最近一次切换的操作者，命令行切换时为 cli
+	UpdatedBy string `json:"updated_by,omitempty"`
+}
+
+// PublicState 无需认证即可读取的维护状态，不包含操作者与切换时间
+type PublicState struct {
+	Enabled bool `json:"enabled"`
+	// Message 展示给用户的说明，为空时使用默认文案
+	Message string `json:"message,omitempty" jsonschema:"maxLength=500"`
+	// RetryAfterSeconds 写操作被拒绝时 Retry-After 响应头的值，0 表示使用默认值
+	RetryAfterSeconds int `json:"retry_after_seconds" jsonschema:"minimum=0,maximum=86400"`
+}
+
+// Public 返回可公开的字段
+func (s State) Public() PublicState {
+	return PublicState{
+		Enabled:           s.Enabled,
+		Message:           s.Message,
+		RetryAfterSeconds: s.RetryAfterSeconds,
+	}
+}
+
+// RetryAfter 返回 Retry-After 秒数，未设置时使用默认值
+func (s State) RetryAfter() int {
+	if s.RetryAfterSeconds > 0 {
+		return s.RetryAfterSeconds
+	}
+	return DefaultRetryAfterSeconds
+}
+
+func (s State) validate() error {
+	if utf8.RuneCountInString(s.Message) > MaxMessageLength {
+		return fmt.Errorf("%w: message exceeds %d characters", ErrInvalidState, MaxMessageLength)
+	}
+	if s.RetryAfterSeconds < 0 || s.RetryAfterSeconds > MaxRetryAfterSeconds {
+		return fmt.Errorf("%w: retry after must be between 0 and %d seconds", ErrInvalidState, MaxRetryAfterSeconds)
+	}
+	return nil
+}
+
+// Load 读取 dataDir 下的状态文件，文件不存在表示未开启
+func Load(dataDir string) (State, error) {
+	content, err := os.ReadFile(filepath.Join(dataDir, FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return State{}, nil
+	}
+	if err != nil {
+		return State{}, fmt.Errorf("maintenance: failed to read state file: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(content, &state); err != nil {
+		return State{}, fmt.Errorf("maintenance: failed to parse state file: %w", err)
+	}
+	return state, nil
+}
+
+// Save 校验并写入状态文件，先写临时文件再重命名，读取方不会看到写了一半的内容
+func Save(dataDir string, state State) error {
+	if err := state.validate(); err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("maintenance: failed to encode state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dataDir, FileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("maintenance: failed to create state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(content, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("maintenance: failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("maintenance: failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dataDir, FileName)); err != nil {
+		return fmt.Errorf("maintenance: failed to replace state file: %w", err)
+	}
+	return nil
+}
+
+// Mode 服务进程内的维护模式状态，变化时通知订阅者。
+// 命令行工具直接修改状态文件，由 Run 定期重新读取后生效
+type Mode struct {
+	dataDir string
+
+	mu          sync.RWMutex
+	state       State
+	subscribers map[chan State]struct{}
+
+	done         chan struct{}
+	shutdownOnce sync.Once
+}
+
+// Open 读取 dataDir 下的当前状态
+func Open(dataDir string) (*Mode, error) {
+	state, err := Load(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	return &Mode{
+		dataDir:     dataDir,
+		state:       state,
+		subscribers: make(map[chan State]struct{}),
+		done:        make(chan struct{}),
+	}, nil
+}
+
+// Current 返回当前状态，m 为 nil 时视为未开启
+func (m *Mode) Current() State {
+	if m == nil {
+		return State{}
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
+
+// Set 持久化新状态并通知订阅者
+func (m *Mode) Set(state State) error {
+	if err := Save(m.dataDir, state); err != nil {
+		return err
+	}
+	m.update(state)
+	return nil
+}
+
+// Reload 重新读取状态文件，返回状态是否发生变化
+func (m *Mode) Reload() (bool, error) {
+	state, err := Load(m.dataDir)
+	if err != nil {
+		return false, err
+	}
+	return m.update(state), nil
+}
+
+func (m *Mode) update(state State) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.state == state {
+		return false
+	}
+	m.state = state
+	for ch := range m.subscribers {
+		// 只保留最新状态：丢弃订阅者尚未取走的旧值
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- state
+	}
+	return true
+}
+
+// Subscribe 订阅状态变化，通道只缓存最新一次状态
+func (m *Mode) Subscribe() chan State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ch := make(chan State, 1)
+	m.subscribers[ch] = struct{}{}
+	return ch
+}
+
+// Unsubscribe 取消订阅
+func (m *Mode) Unsubscribe(ch chan State) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.subscribers[ch]; ok {
+		delete(m.subscribers, ch)
+		close(ch)
+	}
+}
+
+// Shutdown 通知所有订阅连接服务即将关闭，可重复调用
+func (m *Mode) Shutdown() {
+	m.shutdownOnce.Do(func() {
+		close(m.done)
+	})
+}
+
+// Done 在 Shutdown 后关闭
+func (m *Mode) Done() <-chan struct{} {
+	return m.done
+}
+
+// Run 每隔 interval 重新读取状态文件，使命令行切换在运行中的服务上生效
+func Run(ctx context.Context, m *Mode, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := m.Reload()
+			if err != nil {
+				slog.Warn("failed to reload maintenance state", "error", err)
+				continue
+			}
+			if changed {
+				state := m.Current()
+				slog.Warn(
+					"maintenance mode changed",
+					"enabled",
+					state.Enabled,
+					"updated_by",
+					state.UpdatedBy,
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package maintenance
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSaveAndLoadRoundTrip(t *testing.T) {
+	dataDir := t.TempDir()
+
+	state, err := Load(dataDir)
+	if err != nil || state.Enabled {
+		t.Fatalf("expected disabled state without a state file, got %+v (%v)", state, err)
+	}
+
+	want := State{Enabled: true, Message: "数据迁移中", RetryAfterSeconds: 60, UpdatedAt: 1_700_000_000, UpdatedBy: "admin"}
+	if err := Save(dataDir, want); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if got, err := Load(dataDir); err != nil || got != want {
+		t.Fatalf("expected %+v, got %+v (%v)", want, got, err)
+	}
+}
+
+func TestSaveRejectsInvalidState(t *testing.T) {
+	for _, state := range []State{
+		{Enabled: true, Message: strings.Repeat("维", MaxMessageLength+1)},
+		{Enabled: true, RetryAfterSeconds: -1},
+		{Enabled: true, RetryAfterSeconds: MaxRetryAfterSeconds + 1},
+	} {
+		if err := Save(t.TempDir(), state); !errors.Is(err, ErrInvalidState) {
+			t.Fatalf("expected ErrInvalidState for %+v, got %v", state, err)
+		}
+	}
+}
+
+func TestRetryAfterDefaults(t *testing.T) {
+	if got := (State{}).RetryAfter(); got != DefaultRetryAfterSeconds {
+		t.Fatalf("expected default retry after, got %d", got)
+	}
+	if got := (State{RetryAfterSeconds: 30}).RetryAfter(); got != 30 {
+		t.Fatalf("expected configured retry after, got %d", got)
+	}
+}
+
+func TestReloadNotifiesSubscribersOfExternalChanges(t *testing.T) {
+	dataDir := t.TempDir()
+	mode, err := Open(dataDir)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	ch := mode.Subscribe()
+	defer mode.Unsubscribe(ch)
+
+	if changed, err := mode.Reload(); err != nil || changed {
+		t.Fatalf("expected no change, got %v (%v)", changed, err)
+	}
+
+	// 模拟 maintenance 命令直接修改状态文件
+	if err := Save(dataDir, State{Enabled: true, UpdatedBy: "cli"}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if changed, err := mode.Reload(); err != nil || !changed {
+		t.Fatalf("expected change, got %v (%v)", changed, err)
+	}
+	if err := Save(dataDir, State{Enabled: true, Message: "later", UpdatedBy: "cli"}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, err := mode.Reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+
+	// 订阅者只会收到最新状态
+	if state := <-ch; state.Message != "later" {
+		t.Fatalf("expected latest state, got %+v", state)
+	}
+	select {
+	case state := <-ch:
+		t.Fatalf("expected no stale state, got %+v", state)
+	default:
+	}
+	if !mode.Current().Enabled {
+		t.Fatal("expected current state to be enabled")
+	}
+}
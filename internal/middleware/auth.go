@@ -1,35 +1,93 @@
 package middleware
 
 import (
-	"log/slog"
-	"net/http"
+	"errors"
+	"strings"
 	"time"
 
 	ginsessions "github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 
+	"main/internal/apierror"
+	"main/internal/apitoken"
+	"main/internal/audit"
+	"main/internal/mtls"
+	"main/internal/proxyauth"
+	"main/internal/requestid"
 	"main/internal/session"
+	"main/internal/user"
 )
 
-// AuthMiddleware 认证中间件
-func AuthMiddleware(cookieSecure bool) gin.HandlerFunc {
+var errTokenNotAccepted = errors.New("api token not accepted on this route")
+
+// AuthOptions AuthMiddleware 可选的认证方式与依赖，零值只接受会话 Cookie
+type AuthOptions struct {
+	// Tokens 不为 nil 时同时接受 Authorization: Bearer 个人访问令牌，路由需再挂载 RequireScope
+	Tokens *apitoken.Store
+	// Proxy 不为 nil 时信任可信代理注入的身份头并为其建立会话
+	Proxy *proxyauth.Authenticator
+	// Certs 不为 nil 时将握手阶段已校验的客户端证书作为认证主体，不使用会话
+	Certs *mtls.Authenticator
+	// Audit 认证失败的请求写入审计日志，为 nil 时不记录
+	Audit *audit.Store
+	// Cookies 续期与清除会话 Cookie 时使用的策略
+	Cookies session.CookiePolicy
+}
+
+// AuthMiddleware 认证中间件，按 opts 依次尝试令牌、客户端证书、代理身份头与会话 Cookie
+func AuthMiddleware(users *user.Store, opts AuthOptions) gin.HandlerFunc {
+	tokens, proxy, certs, auditLog, cookies := opts.Tokens, opts.Proxy, opts.Certs, opts.Audit, opts.Cookies
+
 	return func(c *gin.Context) {
+		if raw, ok := bearerToken(c); ok {
+			// 仅限会话的接口不接受令牌，也不回退到 Cookie，CSRF 中间件据此豁免 Bearer 请求
+			if tokens == nil {
+				rejectToken(c, auditLog, errTokenNotAccepted)
+				return
+			}
+			authenticateToken(c, users, tokens, auditLog, raw)
+			return
+		}
+
+		if certs != nil {
+			if identity, ok := certs.Identity(c.Request); ok {
+				authenticateCert(c, users, certs, auditLog, identity)
+				return
+			}
+		}
+
 		sess := ginsessions.Default(c)
+		if proxy != nil && !applyProxyAuth(c, sess, users, proxy, auditLog, cookies) {
+			return
+		}
+
 		authenticated, ok := sess.Get("authenticated").(bool)
-		if !ok || !authenticated {
-			clearInvalidSessionCookie(sess, cookieSecure)
-			slog.Warn("unauthorized request", "remote_addr", c.ClientIP())
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "未授权，请先登录",
-			})
-			c.Abort()
+		userID, hasUser := sess.Get("user_id").(int64)
+		if !ok || !authenticated || !hasUser {
+			rejectUnauthorized(c, sess, auditLog, cookies)
+			return
+		}
+
+		// 每次请求重新加载用户，使禁用、删除和角色变更立即生效
+		u, err := users.GetByID(c.Request.Context(), userID)
+		if err != nil {
+			if errors.Is(err, user.ErrNotFound) {
+				rejectUnauthorized(c, sess, auditLog, cookies)
+				return
+			}
+			requestid.Logger(c).Error("failed to load session user", "user_id", userID, "error", err)
+			apierror.Respond(c, apierror.CodeInternal)
+			return
+		}
+		if u.Disabled {
+			rejectUnauthorized(c, sess, auditLog, cookies)
 			return
 		}
 
 		sess.Set("last_seen_at", time.Now().Unix())
-		session.SetCookieOptions(sess, cookieSecure, session.SessionMaxAgeSeconds)
+		session.SetCookieOptions(sess, cookies, session.SessionMaxAgeSeconds)
 		if err := sess.Save(); err != nil {
-			slog.Warn("failed to refresh session", "error", err)
+			requestid.Logger(c).Warn("failed to refresh session", "error", err)
 		}
 
 		sessionID, _ := sess.Get("session_id").(string)
@@ -38,13 +96,92 @@ func AuthMiddleware(cookieSecure bool) gin.HandlerFunc {
 		}
 
 		c.Set("session_id", sessionID)
+		c.Set("user_id", u.ID)
+		c.Set("username", u.Username)
+		c.Set("role", u.Role)
 		c.Next()
 	}
 }
 
-func clearInvalidSessionCookie(sess ginsessions.Session, secure bool) {
-	session.ExpireCookie(sess, secure)
+// authenticateToken 校验个人访问令牌，令牌请求不读写会话 Cookie
+func authenticateToken(c *gin.Context, users *user.Store, tokens *apitoken.Store, auditLog *audit.Store, raw string) {
+	token, err := tokens.Authenticate(c.Request.Context(), raw)
+	if err != nil {
+		if errors.Is(err, apitoken.ErrInvalidToken) || errors.Is(err, apitoken.ErrExpired) {
+			rejectToken(c, auditLog, err)
+			return
+		}
+		requestid.Logger(c).Error("failed to authenticate api token", "error", err)
+		apierror.Respond(c, apierror.CodeInternal)
+		return
+	}
+
+	u, err := users.GetByID(c.Request.Context(), token.UserID)
+	if err != nil {
+		if errors.Is(err, user.ErrNotFound) {
+			rejectToken(c, auditLog, err)
+			return
+		}
+		requestid.Logger(c).Error("failed to load token owner", "user_id", token.UserID, "error", err)
+		apierror.Respond(c, apierror.CodeInternal)
+		return
+	}
+	if u.Disabled {
+		rejectToken(c, auditLog, user.ErrDisabled)
+		return
+	}
+
+	c.Set("session_id", "token:"+token.Prefix)
+	c.Set("user_id", u.ID)
+	c.Set("username", u.Username)
+	c.Set("role", u.Role)
+	c.Set("api_token", token)
+	c.Next()
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func rejectToken(c *gin.Context, auditLog *audit.Store, reason error) {
+	requestid.Logger(c).Warn("unauthorized token request", "reason", reason, "remote_addr", c.ClientIP())
+	auditLog.RecordRequest(c, audit.Event{
+		Action:  audit.ActionUnauthorized,
+		Target:  c.Request.Method + " " + c.Request.URL.Path,
+		Outcome: audit.OutcomeFailure,
+		Detail:  "api token: " + reason.Error(),
+	})
+	c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+	apierror.Respond(c, apierror.CodeInvalidAccessToken)
+}
+
+func rejectUnauthorized(c *gin.Context, sess ginsessions.Session, auditLog *audit.Store, cookies session.CookiePolicy) {
+	// 会话被禁用或删除时仍记录原用户名，需在清理前读取
+	username, _ := sess.Get("username").(string)
+
+	// 等待两步验证的半认证会话保持不变，避免前端探测请求打断登录流程
+	if _, pending := sess.Get("mfa_user_id").(int64); !pending {
+		clearInvalidSessionCookie(c, sess, cookies)
+	}
+	requestid.Logger(c).Warn("unauthorized request", "remote_addr", c.ClientIP())
+	auditLog.RecordRequest(c, audit.Event{
+		Actor:   username,
+		Action:  audit.ActionUnauthorized,
+		Target:  c.Request.Method + " " + c.Request.URL.Path,
+		Outcome: audit.OutcomeFailure,
+	})
+	apierror.Respond(c, apierror.CodeUnauthorized)
+}
+
+func clearInvalidSessionCookie(c *gin.Context, sess ginsessions.Session, cookies session.CookiePolicy) {
+	session.ExpireCookie(sess, cookies)
 	if err := sess.Save(); err != nil {
-		slog.Warn("failed to clear invalid session", "error", err)
+		requestid.Logger(c).Warn("failed to clear invalid session", "error", err)
 	}
 }
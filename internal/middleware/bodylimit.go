@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apierror"
+	"main/internal/requestid"
+)
+
+// BodyLimit 限制请求体大小：声明的 Content-Length 超限时直接返回 413，
+// 未声明长度（分块传输）的请求体在读取超过 maxBytes 时报错，由 apierror.RespondBindError 返回 413
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > maxBytes {
+			requestid.Logger(c).Warn(
+				"request body too large",
+				"method",
+				c.Request.Method,
+				"path",
+				c.Request.URL.Path,
+				"content_length",
+				c.Request.ContentLength,
+				"remote_addr",
+				c.ClientIP(),
+			)
+			// 不读取请求体，关闭连接避免服务端继续接收；HTTP/2、HTTP/3 由协议层重置流
+			if c.Request.ProtoMajor == 1 {
+				c.Header("Connection", "close")
+			}
+			apierror.Respond(c, apierror.CodeBodyTooLarge)
+			return
+		}
+
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		}
+		c.Next()
+	}
+}
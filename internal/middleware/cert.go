@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apierror"
+	"main/internal/audit"
+	"main/internal/mtls"
+	"main/internal/requestid"
+	"main/internal/user"
+)
+
+// authenticateCert 将已校验的客户端证书作为认证主体，与令牌请求一样不读写会话 Cookie
+func authenticateCert(c *gin.Context, users *user.Store, certs *mtls.Authenticator, auditLog *audit.Store, identity mtls.Identity) {
+	if identity.Username == "" {
+		rejectCert(c, auditLog, identity, "certificate has no username field")
+		return
+	}
+
+	var (
+		u   *user.User
+		err error
+	)
+	if role := certs.DefaultRole(); role != "" {
+		u, err = users.EnsureExternalUser(c.Request.Context(), identity.Username, role)
+	} else {
+		u, err = users.GetExternalUser(c.Request.Context(), identity.Username)
+	}
+	if err != nil {
+		if errors.Is(err, user.ErrNotFound) || errors.Is(err, user.ErrInvalidUsername) ||
+			errors.Is(err, user.ErrExternalNotLinked) {
+			rejectCert(c, auditLog, identity, err.Error())
+			return
+		}
+		requestid.Logger(c).Error("failed to load certificate user", "username", identity.Username, "error", err)
+		apierror.Respond(c, apierror.CodeInternal)
+		return
+	}
+	if u.Disabled {
+		rejectCert(c, auditLog, identity, user.ErrDisabled.Error())
+		return
+	}
+
+	c.Set("session_id", "cert:"+identity.Serial)
+	c.Set("user_id", u.ID)
+	c.Set("username", u.Username)
+	c.Set("role", u.Role)
+	c.Next()
+}
+
+func rejectCert(c *gin.Context, auditLog *audit.Store, identity mtls.Identity, reason string) {
+	requestid.Logger(c).Warn(
+		"unauthorized certificate request",
+		"reason",
+		reason,
+		"subject",
+		identity.Subject,
+		"serial",
+		identity.Serial,
+		"remote_addr",
+		c.ClientIP(),
+	)
+	auditLog.RecordRequest(c, audit.Event{
+		Actor:     identity.Username,
+		SessionID: "cert:" + identity.Serial,
+		Action:    audit.ActionUnauthorized,
+		Target:    c.Request.Method + " " + c.Request.URL.Path,
+		Outcome:   audit.OutcomeFailure,
+		Detail:    "client certificate " + identity.Subject + ": " + reason,
+	})
+	apierror.Respond(c, apierror.CodeCertificateUnmapped)
+}
@@ -0,0 +1,278 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+
+	"main/internal/config"
+)
+
+// encoder 各压缩算法 Writer 的公共方法，Reset 后可从池中复用
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// newEncoder 动态内容按速度优先选择压缩级别；zstd 窗口限制在 1MB，满足浏览器 8MB 的上限并降低内存占用
+func newEncoder(encoding string) encoder {
+	switch encoding {
+	case config.EncodingBrotli:
+		return brotli.NewWriterLevel(nil, 4)
+	case config.EncodingZstd:
+		w, _ := zstd.NewWriter(nil,
+			zstd.WithEncoderLevel(zstd.SpeedDefault),
+			zstd.WithEncoderConcurrency(1),
+			zstd.WithWindowSize(1<<20),
+		)
+		return w
+	default:
+		w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return w
+	}
+}
+
+// CompressConfig 动态压缩配置
+type CompressConfig struct {
+	// Encodings 启用的编码，客户端 q 值相同时按此顺序优先
+	Encodings []string
+	// MinSize 响应体达到该字节数才压缩；SSE 流无论大小都压缩
+	MinSize int
+}
+
+// Compress 按 Accept-Encoding 协商压缩 JSON、文本与 SSE 响应，并始终添加 Vary: Accept-Encoding。
+// 响应体先缓冲到 MinSize 再决定是否压缩，处理器已设置 Content-Encoding 或已提前写出响应头时原样发送；
+// 处理器每次 Flush 都会先刷新压缩器，SSE 事件仍能即时送达
+func Compress(cfg CompressConfig) gin.HandlerFunc {
+	pools := make(map[string]*sync.Pool, len(cfg.Encodings))
+	for _, encoding := range cfg.Encodings {
+		pools[encoding] = &sync.Pool{New: func() any { return newEncoder(encoding) }}
+	}
+
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		if c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"), cfg.Encodings)
+		if encoding == "" {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{
+			ResponseWriter: c.Writer,
+			encoding:       encoding,
+			pool:           pools[encoding],
+			minSize:        cfg.MinSize,
+		}
+		c.Writer = w
+		completed := false
+		defer func() {
+			c.Writer = w.ResponseWriter
+			// 处理器 panic 时丢弃尚未发送的缓冲，由 Recovery 写出错误响应
+			w.finish(completed)
+		}()
+		c.Next()
+		completed = true
+	}
+}
+
+// negotiateEncoding 选择客户端 q 值最高的编码，q 值相同时按 supported 的顺序；都不接受时返回空字符串
+func negotiateEncoding(header string, supported []string) string {
+	if header == "" {
+		return ""
+	}
+
+	accepted := make(map[string]float64)
+	wildcard := 0.0
+	for part := range strings.SplitSeq(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		for param := range strings.SplitSeq(params, ";") {
+			key, value, _ := strings.Cut(param, "=")
+			if strings.TrimSpace(key) != "q" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				parsed = 0
+			}
+			q = parsed
+		}
+		if name == "*" {
+			wildcard = q
+		} else {
+			accepted[name] = q
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range supported {
+		q, ok := accepted[encoding]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// compressibleType 只压缩文本类内容，图片、压缩包等已压缩格式再压缩没有收益
+func compressibleType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/json",
+		mediaType == "application/javascript",
+		mediaType == "application/xml",
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	return false
+}
+
+// compressWriter 缓冲响应体直到能决定是否压缩，之后直接写入压缩器或原始 Writer
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	pool     *sync.Pool
+	minSize  int
+
+	buf     []byte
+	decided bool
+	enc     encoder
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		switch {
+		case w.ResponseWriter.Written():
+			w.decide(false)
+		case w.isEventStream():
+			w.decide(true)
+		case len(w.buf)+len(data) < w.minSize:
+			w.buf = append(w.buf, data...)
+			return len(data), nil
+		default:
+			w.decide(true)
+		}
+		if err := w.writeBuffered(); err != nil {
+			return 0, err
+		}
+	}
+	if w.enc != nil {
+		return w.enc.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush 未达到阈值的普通响应此时按原样发送；SSE 流立即开始压缩，每次都把压缩器中的数据刷出
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(w.isEventStream())
+		if err := w.writeBuffered(); err != nil {
+			return
+		}
+	}
+	if w.enc != nil {
+		if err := w.enc.Flush(); err != nil {
+			return
+		}
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Unwrap 供 http.ResponseController 访问底层连接，如 SSE 取消写超时
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *compressWriter) isEventStream() bool {
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	return mediaType == "text/event-stream"
+}
+
+// decide 确定是否压缩；压缩时改写响应头，此后不能再改变
+func (w *compressWriter) decide(compress bool) {
+	w.decided = true
+	if !compress || !w.canCompress() {
+		return
+	}
+
+	h := w.Header()
+	h.Set("Content-Encoding", w.encoding)
+	h.Del("Content-Length")
+	// 压缩后的字节与原始响应不同，强 ETag 需降级为弱 ETag
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		h.Set("ETag", "W/"+etag)
+	}
+
+	w.enc = w.pool.Get().(encoder)
+	w.enc.Reset(w.ResponseWriter)
+}
+
+func (w *compressWriter) canCompress() bool {
+	if w.ResponseWriter.Written() || w.Header().Get("Content-Encoding") != "" {
+		return false
+	}
+	switch status := w.Status(); {
+	case status < http.StatusOK, status == http.StatusNoContent, status == http.StatusPartialContent,
+		status == http.StatusNotModified:
+		return false
+	}
+	return compressibleType(w.Header().Get("Content-Type"))
+}
+
+func (w *compressWriter) writeBuffered() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	buf := w.buf
+	w.buf = nil
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// finish 处理器返回后发送不足阈值的缓冲并结束压缩流，压缩器放回池中
+func (w *compressWriter) finish(completed bool) {
+	if !w.decided {
+		if !completed {
+			return
+		}
+		w.decide(false)
+		_ = w.writeBuffered()
+	}
+	if w.enc != nil {
+		_ = w.enc.Close()
+		w.enc.Reset(io.Discard)
+		w.pool.Put(w.enc)
+		w.enc = nil
+	}
+}
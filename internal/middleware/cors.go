@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apierror"
+	"main/internal/requestid"
+	"main/internal/session"
+)
+
+var (
+	corsAllowMethods  = strings.Join([]string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}, ", ")
+	corsAllowHeaders  = strings.Join([]string{"Content-Type", "Authorization", session.CSRFHeaderName, requestid.HeaderName}, ", ")
+	corsExposeHeaders = strings.Join([]string{requestid.HeaderName, "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"}, ", ")
+)
+
+// CORS 允许 allowedOrigins 中的前端跨域携带 Cookie 调用 API。
+// 同源请求与不带 Origin 的请求直接放行；其他来源不返回任何 CORS 响应头，
+// 预检请求直接以 403 拒绝，并记录日志便于排查部署配置。
+// maxAge 为预检结果缓存时间（秒），0 表示不缓存。
+// 预检请求只有匹配到路由才会经过分组中间件，挂载时需同时注册 OPTIONS 通配路由。
+func CORS(allowedOrigins []string, maxAge int) gin.HandlerFunc {
+	allowed := make(map[string]struct{}, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[strings.TrimRight(strings.ToLower(origin), "/")] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Add("Vary", "Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if _, ok := allowed[strings.ToLower(origin)]; !ok {
+			if sameOrigin(c, origin) {
+				c.Next()
+				return
+			}
+			requestid.Logger(c).Warn(
+				"cors origin rejected",
+				"origin",
+				origin,
+				"method",
+				c.Request.Method,
+				"path",
+				c.Request.URL.Path,
+				"preflight",
+				preflight,
+				"remote_addr",
+				c.ClientIP(),
+			)
+			if preflight {
+				apierror.Respond(c, apierror.CodeCORSOriginRejected)
+				return
+			}
+			// 简单请求仍交给后续中间件处理：浏览器读不到响应，写操作由 CSRF 校验拦截
+			c.Next()
+			return
+		}
+
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Set("Access-Control-Allow-Credentials", "true")
+		if !preflight {
+			h.Set("Access-Control-Expose-Headers", corsExposeHeaders)
+			c.Next()
+			return
+		}
+
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+		h.Set("Access-Control-Allow-Methods", corsAllowMethods)
+		h.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+		if maxAge > 0 {
+			h.Set("Access-Control-Max-Age", strconv.Itoa(maxAge))
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+func sameOrigin(c *gin.Context, origin string) bool {
+	parsed, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(parsed.Host, c.Request.Host)
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/requestid"
+)
+
+// deprecationLogInterval 同一路由的弃用告警最短间隔，避免轮询接口刷屏
+const deprecationLogInterval = time.Hour
+
+// Deprecation 已弃用接口的说明
+type Deprecation struct {
+	// Since 弃用时间，写入 Deprecation 响应头（RFC 9745）
+	Since time.Time
+	// Sunset 计划下线时间，零值表示尚未确定；非零时写入 Sunset 响应头（RFC 8594）
+	Sunset time.Time
+	// Successor 返回替代接口的路径，非空时通过 Link: rel="successor-version" 告知客户端
+	Successor func(path string) string
+}
+
+// Deprecated 为已弃用的接口添加 Deprecation、Sunset 与 Link 响应头，并记录告警日志，
+// 同一路由每 deprecationLogInterval 最多记录一次
+func Deprecated(d Deprecation) gin.HandlerFunc {
+	var mu sync.Mutex
+	lastLogged := make(map[string]time.Time)
+
+	return func(c *gin.Context) {
+		c.Header("Deprecation", fmt.Sprintf("@%d", d.Since.Unix()))
+		if !d.Sunset.IsZero() {
+			c.Header("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if d.Successor != nil {
+			if successor := d.Successor(c.Request.URL.Path); successor != "" {
+				c.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+			}
+		}
+
+		route := c.Request.Method + " " + c.FullPath()
+		now := time.Now()
+		mu.Lock()
+		shouldLog := now.Sub(lastLogged[route]) >= deprecationLogInterval
+		if shouldLog {
+			lastLogged[route] = now
+		}
+		mu.Unlock()
+		if shouldLog {
+			attrs := []any{"route", route, "remote_addr", c.ClientIP(), "user_agent", c.Request.UserAgent()}
+			if !d.Sunset.IsZero() {
+				attrs = append(attrs, "sunset", d.Sunset.UTC().Format(time.RFC3339))
+			}
+			requestid.Logger(c).Warn("deprecated api endpoint called", attrs...)
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apierror"
+	"main/internal/maintenance"
+	"main/internal/requestid"
+	"main/internal/user"
+)
+
+// Maintenance 维护模式中间件，需挂载在 AuthMiddleware 之后：
+// 维护期间非管理员的写请求返回 503 与 Retry-After，读请求与日志流不受影响
+func Maintenance(mode *maintenance.Mode) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		state := mode.Current()
+		if !state.Enabled {
+			c.Next()
+			return
+		}
+		role, _ := c.Get("role")
+		if current, _ := role.(user.Role); current == user.RoleAdmin {
+			c.Next()
+			return
+		}
+
+		requestid.Logger(c).Info(
+			"write rejected during maintenance",
+			"method",
+			c.Request.Method,
+			"path",
+			c.FullPath(),
+			"username",
+			c.GetString("username"),
+		)
+		c.Header("Retry-After", strconv.Itoa(state.RetryAfter()))
+		apierror.Write(c, &apierror.Error{Code: apierror.CodeMaintenance, Message: state.Message})
+	}
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"errors"
+
+	ginsessions "github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apierror"
+	"main/internal/audit"
+	"main/internal/proxyauth"
+	"main/internal/requestid"
+	"main/internal/session"
+	"main/internal/user"
+)
+
+// ProxySession 在不经过 AuthMiddleware 的接口（如 /api/session）上应用代理头认证，proxy 为 nil 时直接放行
+func ProxySession(users *user.Store, proxy *proxyauth.Authenticator, auditLog *audit.Store, cookies session.CookiePolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if proxy != nil && !applyProxyAuth(c, ginsessions.Default(c), users, proxy, auditLog, cookies) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// applyProxyAuth 处理代理身份头，返回 false 表示请求已被拒绝。
+// 非可信代理携带身份头一律拒绝；可信代理的身份与当前会话不一致时建立新会话，后续按普通会话校验。
+func applyProxyAuth(
+	c *gin.Context,
+	sess ginsessions.Session,
+	users *user.Store,
+	proxy *proxyauth.Authenticator,
+	auditLog *audit.Store,
+	cookies session.CookiePolicy,
+) bool {
+	if !proxy.Trusted(c.Request) {
+		if proxy.HasHeaders(c.Request) {
+			rejectProxy(c, auditLog, "", "identity headers from untrusted peer")
+			return false
+		}
+		return true
+	}
+
+	identity, ok := proxy.Identity(c.Request)
+	if !ok {
+		return true
+	}
+
+	sessionUser, _ := sess.Get("username").(string)
+	authenticated, _ := sess.Get("authenticated").(bool)
+	if authenticated && sessionUser == identity.Username {
+		return true
+	}
+
+	u, err := users.EnsureExternalUser(c.Request.Context(), identity.Username, proxy.DefaultRole())
+	if err != nil {
+		if errors.Is(err, user.ErrInvalidUsername) {
+			rejectProxy(c, auditLog, identity.Username, "invalid username")
+			return false
+		}
+		if errors.Is(err, user.ErrExternalNotLinked) {
+			rejectProxy(c, auditLog, identity.Username, "account not linked to proxy authentication")
+			return false
+		}
+		requestid.Logger(c).Error("failed to provision proxy user", "username", identity.Username, "error", err)
+		apierror.Respond(c, apierror.CodeInternal)
+		return false
+	}
+	if u.Disabled {
+		rejectProxy(c, auditLog, u.Username, "account disabled")
+		return false
+	}
+	// 用户名大小写不同的同一用户不必重建会话
+	if authenticated && sessionUser == u.Username {
+		return true
+	}
+
+	sessionID, err := session.Start(sess, u.ID, u.Username, cookies)
+	if err != nil {
+		requestid.Logger(c).Error("failed to start proxy session", "error", err)
+		apierror.Respond(c, apierror.CodeInternal)
+		return false
+	}
+
+	requestid.Logger(c).Info(
+		"user logged in",
+		"session_id",
+		sessionID,
+		"username",
+		u.Username,
+		"role",
+		u.Role,
+		"method",
+		"proxy",
+		"remote_addr",
+		c.ClientIP(),
+	)
+	auditLog.RecordRequest(c, audit.Event{
+		Actor:     u.Username,
+		ActorID:   u.ID,
+		SessionID: sessionID,
+		Action:    audit.ActionLogin,
+		Outcome:   audit.OutcomeSuccess,
+		Detail:    "proxy email=" + identity.Email,
+	})
+	return true
+}
+
+func rejectProxy(c *gin.Context, auditLog *audit.Store, username, reason string) {
+	requestid.Logger(c).Warn(
+		"proxy authentication rejected",
+		"reason",
+		reason,
+		"username",
+		username,
+		"remote_addr",
+		c.Request.RemoteAddr,
+	)
+	auditLog.RecordRequest(c, audit.Event{
+		Actor:   username,
+		Action:  audit.ActionLogin,
+		Target:  c.Request.Method + " " + c.Request.URL.Path,
+		Outcome: audit.OutcomeFailure,
+		Detail:  "proxy: " + reason,
+	})
+	apierror.Respond(c, apierror.CodeUnauthorized)
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	ginsessions "github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apierror"
+	"main/internal/ratelimit"
+	"main/internal/requestid"
+)
+
+// RateLimit 同时检查两个令牌桶，任一耗尽即返回 429：
+//   - perIP 以客户端 IP（IPv6 为 /64 前缀）为键，覆盖同一出口 IP 的全部请求，反复登录换取新会话也无法绕过；
+//   - perClient 对已登录会话以会话 ID 为键，同一出口 IP 后的多个用户互不影响，
+//     其余请求（匿名、Bearer 令牌）以客户端 IP 为键。
+//
+// 登录类接口只应使用 perIP，会话可由攻击者随意重新获取。
+// 多个限流器叠加时，RateLimit-* 响应头反映剩余额度最少的一个。
+// 限流器为 nil 表示不检查对应的桶。
+func RateLimit(perIP, perClient *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if perIP != nil && !allow(c, perIP, "ip:"+ratelimit.IPKey(c.ClientIP())) {
+			return
+		}
+		if perClient != nil && !allow(c, perClient, rateLimitKey(c)) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// allow 消耗 key 对应桶中的一个令牌，额度耗尽时写出 429 并中止请求
+func allow(c *gin.Context, limiter *ratelimit.Limiter, key string) bool {
+	result := limiter.Allow(key)
+	setRateLimitHeaders(c, result)
+	if result.Allowed {
+		return true
+	}
+
+	retryAfter := ceilSeconds(result.RetryAfter)
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	requestid.Logger(c).Warn(
+		"rate limit exceeded",
+		"method",
+		c.Request.Method,
+		"path",
+		c.Request.URL.Path,
+		"username",
+		c.GetString("username"),
+		"remote_addr",
+		c.ClientIP(),
+		"bucket",
+		key,
+		"retry_after",
+		retryAfter,
+	)
+	apierror.Respond(c, apierror.CodeRateLimited)
+	return false
+}
+
+func rateLimitKey(c *gin.Context) string {
+	if sessionID := c.GetString("session_id"); sessionID != "" {
+		return "session:" + sessionID
+	}
+	// 认证中间件之前执行时从会话读取，会话 Cookie 已签名，客户端无法伪造
+	sess := ginsessions.Default(c)
+	if authenticated, _ := sess.Get("authenticated").(bool); authenticated {
+		if sessionID, _ := sess.Get("session_id").(string); sessionID != "" {
+			return "session:" + sessionID
+		}
+	}
+	return "ip:" + ratelimit.IPKey(c.ClientIP())
+}
+
+func setRateLimitHeaders(c *gin.Context, result ratelimit.Result) {
+	h := c.Writer.Header()
+	if existing := h.Get("RateLimit-Remaining"); existing != "" {
+		if remaining, err := strconv.Atoi(existing); err == nil && remaining <= result.Remaining {
+			return
+		}
+	}
+	h.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
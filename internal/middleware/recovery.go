@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apierror"
+	"main/internal/requestid"
+)
+
+// unmatchedRoute 未匹配任何路由（如 SPA 回退）时的计数键
+const unmatchedRoute = "unmatched"
+
+// PanicCounter 按路由统计处理器 panic 次数，键为 "方法 路由模板"，如 "GET /api/v1/users/:id"
+type PanicCounter struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+// NewPanicCounter 创建 panic 计数器
+func NewPanicCounter() *PanicCounter {
+	return &PanicCounter{counts: make(map[string]uint64)}
+}
+
+func (p *PanicCounter) add(route string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.counts[route]++
+}
+
+// Snapshot 返回当前计数的副本，p 为 nil 时返回空表
+func (p *PanicCounter) Snapshot() map[string]uint64 {
+	if p == nil {
+		return map[string]uint64{}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return maps.Clone(p.counts)
+}
+
+// Recovery 捕获处理器 panic：通过 slog 以 error 级别记录 panic 值与调用栈（因而进入日志流），
+// 按路由计数，并在尚未写出响应时返回统一的 internal_error。
+// 客户端断开导致的写失败（broken pipe、connection reset）不计数，只记 debug 日志；
+// http.ErrAbortHandler 记录后重新抛出，由 net/http 断开连接，客户端不会把中止的响应当作完整响应
+func Recovery(counter *PanicCounter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			route := c.FullPath()
+			if route == "" {
+				route = unmatchedRoute
+			}
+			route = c.Request.Method + " " + route
+
+			if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				requestid.Logger(c).Debug("response aborted by handler", "route", route)
+				panic(recovered)
+			}
+			if isClientGone(recovered) {
+				requestid.Logger(c).Debug(
+					"client disconnected during response",
+					"route",
+					route,
+					"error",
+					recovered,
+				)
+				c.Abort()
+				return
+			}
+
+			if counter != nil {
+				counter.add(route)
+			}
+			requestid.Logger(c).Error(
+				"panic recovered",
+				"route",
+				route,
+				"panic",
+				fmt.Sprint(recovered),
+				"stack",
+				string(debug.Stack()),
+			)
+
+			if c.Writer.Written() {
+				// 响应已开始写出，无法再改为错误响应，只能中止
+				c.Abort()
+				return
+			}
+			apierror.Respond(c, apierror.CodeInternal)
+		}()
+
+		c.Next()
+	}
+}
+
+// isClientGone 判断 panic 是否源于客户端已断开，这类错误无需告警
+func isClientGone(recovered any) bool {
+	err, ok := recovered.(error)
+	if !ok {
+		return false
+	}
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)
+}
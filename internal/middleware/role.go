@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apierror"
+	"main/internal/requestid"
+	"main/internal/user"
+)
+
+// RequireRole 角色授权中间件，需挂载在 AuthMiddleware 之后
+func RequireRole(required user.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := c.Get("role")
+		current, _ := role.(user.Role)
+		if !current.Allows(required) {
+			username, _ := c.Get("username")
+			requestid.Logger(c).Warn(
+				"forbidden request",
+				"username",
+				username,
+				"role",
+				current,
+				"required_role",
+				required,
+				"path",
+				c.FullPath(),
+				"remote_addr",
+				c.ClientIP(),
+			)
+			apierror.Respond(c, apierror.CodeForbidden)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apierror"
+	"main/internal/apitoken"
+	"main/internal/requestid"
+)
+
+// RequireScope 令牌 scope 授权中间件，需挂载在 AuthMiddleware 之后。
+// 会话请求不受 scope 限制，仅由 RequireRole 控制。
+func RequireScope(required apitoken.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, ok := c.Get("api_token")
+		if !ok {
+			c.Next()
+			return
+		}
+
+		token, _ := value.(*apitoken.Token)
+		if token == nil || !token.HasScope(required) {
+			username, _ := c.Get("username")
+			requestid.Logger(c).Warn(
+				"forbidden token request",
+				"username",
+				username,
+				"required_scope",
+				required,
+				"path",
+				c.FullPath(),
+				"remote_addr",
+				c.ClientIP(),
+			)
+			c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+string(required)+`"`)
+			apierror.Respond(c, apierror.CodeInsufficientScope)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,134 @@
+// Package mtls 将经过 CA 校验的 TLS 客户端证书映射为本地用户，供机器间调用管理接口。
+package mtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"main/internal/user"
+)
+
+// 客户端证书校验模式
+const (
+	ClientAuthRequest = "request" // 证书可选，提供时必须由 CA 签发
+	ClientAuthRequire = "require" // 所有连接都必须提供有效证书
+)
+
+// 作为用户名的证书字段
+const (
+	UsernameFieldCN    = "cn"
+	UsernameFieldDNS   = "dns"
+	UsernameFieldEmail = "email"
+)
+
+var ErrNoCertificates = errors.New("mtls: no certificates found in client ca file")
+
+// Config 客户端证书身份映射配置
+type Config struct {
+	// UsernameField 取证书中的哪个字段作为用户名：cn、dns（第一个 DNS SAN）或 email（第一个邮箱 SAN）
+	UsernameField string
+	// DefaultRole 证书对应用户不存在时自动创建使用的角色，为空表示只允许已存在的用户
+	DefaultRole user.Role
+}
+
+// Identity 客户端证书中解析出的身份
+type Identity struct {
+	Username string
+	Subject  string
+	Serial   string
+}
+
+// Authenticator 从已校验的证书链中提取身份
+type Authenticator struct {
+	cfg Config
+}
+
+// New 创建客户端证书身份解析器
+func New(cfg Config) (*Authenticator, error) {
+	if cfg.UsernameField == "" {
+		cfg.UsernameField = UsernameFieldCN
+	}
+	if err := ValidateUsernameField(cfg.UsernameField); err != nil {
+		return nil, err
+	}
+	if cfg.DefaultRole != "" {
+		if _, err := user.ParseRole(string(cfg.DefaultRole)); err != nil {
+			return nil, err
+		}
+	}
+	return &Authenticator{cfg: cfg}, nil
+}
+
+// ValidateUsernameField 校验用户名字段配置
+func ValidateUsernameField(field string) error {
+	switch field {
+	case UsernameFieldCN, UsernameFieldDNS, UsernameFieldEmail:
+		return nil
+	default:
+		return fmt.Errorf("mtls: invalid username field %q", field)
+	}
+}
+
+// ParseClientAuth 将配置的校验模式转换为 tls.ClientAuthType
+func ParseClientAuth(mode string) (tls.ClientAuthType, error) {
+	switch mode {
+	case ClientAuthRequest:
+		return tls.VerifyClientCertIfGiven, nil
+	case ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("mtls: invalid client auth mode %q", mode)
+	}
+}
+
+// LoadCertPool 从 PEM 文件加载客户端 CA
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("mtls: failed to read client ca file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, ErrNoCertificates
+	}
+	return pool, nil
+}
+
+// DefaultRole 证书用户不存在时自动创建使用的角色
+func (a *Authenticator) DefaultRole() user.Role {
+	return a.cfg.DefaultRole
+}
+
+// Identity 读取握手阶段已由 CA 校验的客户端证书。
+// 只使用 VerifiedChains，未经校验的 PeerCertificates 一律忽略。
+func (a *Authenticator) Identity(r *http.Request) (Identity, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return Identity{}, false
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+
+	var username string
+	switch a.cfg.UsernameField {
+	case UsernameFieldDNS:
+		if len(cert.DNSNames) > 0 {
+			username = cert.DNSNames[0]
+		}
+	case UsernameFieldEmail:
+		if len(cert.EmailAddresses) > 0 {
+			username = cert.EmailAddresses[0]
+		}
+	default:
+		username = cert.Subject.CommonName
+	}
+
+	return Identity{
+		Username: strings.TrimSpace(username),
+		Subject:  cert.Subject.String(),
+		Serial:   cert.SerialNumber.Text(16),
+	}, true
+}
@@ -0,0 +1,66 @@
+package mtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http/httptest"
+	"testing"
+
+	"main/internal/mtls/mtlstest"
+)
+
+func TestIdentityUsesVerifiedChainOnly(t *testing.T) {
+	ca := mtlstest.NewCA("test-ca")
+	cert := ca.IssueClient(mtlstest.ClientOptions{
+		CommonName: "svc-backup",
+		DNSNames:   []string{"backup.internal"},
+		Emails:     []string{"backup@example.com"},
+	})
+
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{field: UsernameFieldCN, want: "svc-backup"},
+		{field: UsernameFieldDNS, want: "backup.internal"},
+		{field: UsernameFieldEmail, want: "backup@example.com"},
+	}
+	for _, tt := range tests {
+		authenticator, err := New(Config{UsernameField: tt.field})
+		if err != nil {
+			t.Fatalf("new authenticator: %v", err)
+		}
+
+		req := httptest.NewRequest("GET", "https://localhost/", nil)
+		req.TLS = &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{cert.Leaf},
+			VerifiedChains:   [][]*x509.Certificate{{cert.Leaf, ca.Cert}},
+		}
+		identity, ok := authenticator.Identity(req)
+		if !ok || identity.Username != tt.want || identity.Serial == "" {
+			t.Fatalf("field %s: unexpected identity %+v (%v)", tt.field, identity, ok)
+		}
+	}
+
+	authenticator, _ := New(Config{})
+	unverified := httptest.NewRequest("GET", "https://localhost/", nil)
+	unverified.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert.Leaf}}
+	if _, ok := authenticator.Identity(unverified); ok {
+		t.Fatal("expected unverified peer certificate to be ignored")
+	}
+}
+
+func TestParseClientAuth(t *testing.T) {
+	if mode, err := ParseClientAuth(ClientAuthRequest); err != nil || mode != tls.VerifyClientCertIfGiven {
+		t.Fatalf("unexpected request mode: %v (%v)", mode, err)
+	}
+	if mode, err := ParseClientAuth(ClientAuthRequire); err != nil || mode != tls.RequireAndVerifyClientCert {
+		t.Fatalf("unexpected require mode: %v (%v)", mode, err)
+	}
+	if _, err := ParseClientAuth("optional"); err == nil {
+		t.Fatal("expected unknown mode to be rejected")
+	}
+	if _, err := New(Config{UsernameField: "uri"}); err == nil {
+		t.Fatal("expected unknown username field to be rejected")
+	}
+}
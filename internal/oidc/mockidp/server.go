@@ -0,0 +1,196 @@
+// Package mockidp 提供进程内的 OIDC Provider，仅用于测试授权码 + PKCE 登录流程。
+package mockidp
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"github.com/coreos/go-oidc/v3/oidc/oidctest"
+)
+
+const keyID = "mockidp-key"
+
+type grant struct {
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	subject       string
+	claims        map[string]any
+}
+
+// Server 模拟 OIDC Provider，支持发现、JWKS、授权和令牌端点
+type Server struct {
+	URL      string
+	ClientID string
+
+	mu sync.Mutex
+	// subject 与 claims 为下一次授权签发的用户身份
+	subject string
+	claims  map[string]any
+
+	key        *rsa.PrivateKey
+	base       *oidctest.Server
+	httpServer *httptest.Server
+	grants     map[string]grant
+}
+
+// New 启动模拟 Provider，调用方负责 Close
+func New(clientID string) *Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic("mockidp: generate key: " + err.Error())
+	}
+
+	s := &Server{
+		ClientID: clientID,
+		subject:  "mock-subject",
+		claims:   map[string]any{},
+		key:      key,
+		base: &oidctest.Server{
+			PublicKeys: []oidctest.PublicKey{
+				{PublicKey: key.Public(), KeyID: keyID, Algorithm: gooidc.RS256},
+			},
+		},
+		grants: make(map[string]grant),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth", s.serveAuthorize)
+	mux.HandleFunc("/token", s.serveToken)
+	mux.Handle("/", s.base)
+
+	s.httpServer = httptest.NewServer(mux)
+	s.URL = s.httpServer.URL
+	s.base.SetIssuer(s.URL)
+	return s
+}
+
+// Close 关闭模拟 Provider
+func (s *Server) Close() {
+	s.httpServer.Close()
+}
+
+// SetIdentity 设置下一次授权签发的 subject 与额外 claims
+func (s *Server) SetIdentity(subject string, claims map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subject = subject
+	s.claims = claims
+}
+
+// serveAuthorize 直接"同意"授权并重定向回客户端
+func (s *Server) serveAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != s.ClientID || query.Get("response_type") != "code" {
+		http.Error(w, "invalid client or response_type", http.StatusBadRequest)
+		return
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "pkce required", http.StatusBadRequest)
+		return
+	}
+
+	code := randomHex()
+	s.mu.Lock()
+	s.grants[code] = grant{
+		clientID:      query.Get("client_id"),
+		redirectURI:   query.Get("redirect_uri"),
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		subject:       s.subject,
+		claims:        s.claims,
+	}
+	s.mu.Unlock()
+
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	values := redirect.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	redirect.RawQuery = values.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeTokenError(w, "invalid_request")
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	s.mu.Lock()
+	g, ok := s.grants[code]
+	delete(s.grants, code)
+	s.mu.Unlock()
+	if !ok {
+		writeTokenError(w, "invalid_grant")
+		return
+	}
+
+	clientID, _, hasBasic := r.BasicAuth()
+	if !hasBasic {
+		clientID = r.PostForm.Get("client_id")
+	}
+	if clientID != g.clientID || r.PostForm.Get("redirect_uri") != g.redirectURI {
+		writeTokenError(w, "invalid_client")
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != g.codeChallenge {
+		writeTokenError(w, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	claims := map[string]any{
+		"iss":   s.URL,
+		"aud":   g.clientID,
+		"sub":   g.subject,
+		"nonce": g.nonce,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}
+	for key, value := range g.claims {
+		claims[key] = value
+	}
+	rawClaims, err := json.Marshal(claims)
+	if err != nil {
+		writeTokenError(w, "server_error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"access_token": randomHex(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     oidctest.SignIDToken(s.key, keyID, gooidc.RS256, string(rawClaims)),
+	})
+}
+
+func writeTokenError(w http.ResponseWriter, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
+
+func randomHex() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
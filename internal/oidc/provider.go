@@ -0,0 +1,231 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+
+	"main/internal/user"
+)
+
+var (
+	ErrNoRole          = errors.New("oidc: no role mapped for user")
+	ErrNonceMismatch   = errors.New("oidc: id token nonce mismatch")
+	ErrMissingIDToken  = errors.New("oidc: token response has no id_token")
+	ErrMissingUsername = errors.New("oidc: username claim missing")
+)
+
+// Config OIDC 客户端与角色映射配置
+type Config struct {
+	IssuerURL      string
+	ClientID       string
+	ClientSecret   string
+	RedirectURL    string
+	Scopes         []string
+	UsernameClaim  string
+	GroupsClaim    string
+	AdminGroups    []string
+	OperatorGroups []string
+	ViewerGroups   []string
+	// DefaultRole 未匹配任何用户组时使用的角色，为空表示拒绝登录
+	DefaultRole user.Role
+}
+
+// AuthRequest 一次授权请求的参数，State/Nonce/Verifier 需保存在会话中供回调校验
+type AuthRequest struct {
+	URL      string
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+// Identity 从 ID Token 解析出的用户身份
+type Identity struct {
+	Issuer   string
+	Subject  string
+	Username string
+	Groups   []string
+	Role     user.Role
+}
+
+// Provider OIDC 授权码 + PKCE 流程客户端。
+// Provider 元数据在首次使用时自动发现，失败后会在下次请求时重试。
+type Provider struct {
+	cfg Config
+
+	mu       sync.Mutex
+	oauth2   *oauth2.Config
+	verifier *gooidc.IDTokenVerifier
+}
+
+// NewProvider 创建 OIDC Provider，不会立即发起网络请求
+func NewProvider(cfg Config) *Provider {
+	if !slices.Contains(cfg.Scopes, gooidc.ScopeOpenID) {
+		cfg.Scopes = append([]string{gooidc.ScopeOpenID}, cfg.Scopes...)
+	}
+	if cfg.UsernameClaim == "" {
+		cfg.UsernameClaim = "preferred_username"
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	return &Provider{cfg: cfg}
+}
+
+func (p *Provider) discover(ctx context.Context) (*oauth2.Config, *gooidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.oauth2 != nil {
+		return p.oauth2, p.verifier, nil
+	}
+
+	provider, err := gooidc.NewProvider(ctx, p.cfg.IssuerURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("oidc: discover provider: %w", err)
+	}
+
+	p.oauth2 = &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       p.cfg.Scopes,
+	}
+	p.verifier = provider.Verifier(&gooidc.Config{ClientID: p.cfg.ClientID})
+	return p.oauth2, p.verifier, nil
+}
+
+// Begin 生成授权跳转地址
+func (p *Provider) Begin(ctx context.Context) (*AuthRequest, error) {
+	oauthConfig, _, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	return &AuthRequest{
+		URL:      oauthConfig.AuthCodeURL(state, gooidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)),
+		State:    state,
+		Nonce:    nonce,
+		Verifier: verifier,
+	}, nil
+}
+
+// Exchange 用授权码换取并校验 ID Token，返回映射后的身份
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	oauthConfig, idVerifier, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauthConfig.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("oidc: exchange code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, ErrMissingIDToken
+	}
+
+	idToken, err := idVerifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: verify id token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("oidc: decode claims: %w", err)
+	}
+
+	username, _ := claims[p.cfg.UsernameClaim].(string)
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return nil, ErrMissingUsername
+	}
+
+	groups := stringList(claims[p.cfg.GroupsClaim])
+	role, ok := p.MapRole(groups)
+	if !ok {
+		return nil, ErrNoRole
+	}
+
+	return &Identity{
+		Issuer:   idToken.Issuer,
+		Subject:  idToken.Subject,
+		Username: username,
+		Groups:   groups,
+		Role:     role,
+	}, nil
+}
+
+// MapRole 按 admin > operator > viewer 的顺序匹配用户组，未匹配时使用 DefaultRole
+func (p *Provider) MapRole(groups []string) (user.Role, bool) {
+	mappings := []struct {
+		role   user.Role
+		groups []string
+	}{
+		{user.RoleAdmin, p.cfg.AdminGroups},
+		{user.RoleOperator, p.cfg.OperatorGroups},
+		{user.RoleViewer, p.cfg.ViewerGroups},
+	}
+	for _, mapping := range mappings {
+		for _, group := range groups {
+			if slices.Contains(mapping.groups, group) {
+				return mapping.role, true
+			}
+		}
+	}
+
+	if p.cfg.DefaultRole != "" {
+		return p.cfg.DefaultRole, true
+	}
+	return "", false
+}
+
+// stringList 兼容字符串数组与单个字符串两种 claim 形式
+func stringList(raw any) []string {
+	switch value := raw.(type) {
+	case string:
+		return []string{value}
+	case []any:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				items = append(items, s)
+			}
+		}
+		return items
+	default:
+		return nil
+	}
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("oidc: generate random token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
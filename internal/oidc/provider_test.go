@@ -0,0 +1,52 @@
+package oidc
+
+import (
+	"testing"
+
+	"main/internal/user"
+)
+
+func TestMapRolePrefersHighestRole(t *testing.T) {
+	p := NewProvider(Config{
+		AdminGroups:    []string{"admins"},
+		OperatorGroups: []string{"ops"},
+		ViewerGroups:   []string{"staff"},
+	})
+
+	tests := []struct {
+		name   string
+		groups []string
+		want   user.Role
+		ok     bool
+	}{
+		{name: "admin wins", groups: []string{"staff", "ops", "admins"}, want: user.RoleAdmin, ok: true},
+		{name: "operator", groups: []string{"ops"}, want: user.RoleOperator, ok: true},
+		{name: "viewer", groups: []string{"staff"}, want: user.RoleViewer, ok: true},
+		{name: "no match", groups: []string{"guests"}, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := p.MapRole(tt.groups)
+			if got != tt.want || ok != tt.ok {
+				t.Fatalf("got (%q, %v), want (%q, %v)", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestMapRoleFallsBackToDefaultRole(t *testing.T) {
+	p := NewProvider(Config{DefaultRole: user.RoleViewer})
+
+	got, ok := p.MapRole(nil)
+	if !ok || got != user.RoleViewer {
+		t.Fatalf("expected default viewer role, got (%q, %v)", got, ok)
+	}
+}
+
+func TestNewProviderAddsOpenIDScope(t *testing.T) {
+	p := NewProvider(Config{Scopes: []string{"email"}})
+	if len(p.cfg.Scopes) != 2 || p.cfg.Scopes[0] != "openid" {
+		t.Fatalf("expected openid scope to be prepended, got %v", p.cfg.Scopes)
+	}
+}
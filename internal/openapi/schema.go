@@ -0,0 +1,273 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema JSON Schema 2020-12 子集，OpenAPI 3.1 直接使用该格式
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"` // string，可为 null 时为 []string
+	Format               string             `json:"format,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+}
+
+// Direction 区分请求体与响应体，两者判断必填字段的方式不同
+type Direction int
+
+const (
+	// ForRequest 请求体：带 binding:"required" 的字段必填
+	ForRequest Direction = iota
+	// ForResponse 响应体：没有 omitempty 的字段总会输出，视为必填
+	ForResponse
+)
+
+// Generator 通过反射由 Go 类型生成 Schema，具名结构体放入 components 并以 $ref 引用。
+//
+// 字段约束写在 jsonschema 标签中，逗号分隔，例如 `jsonschema:"minimum=0,maximum=100"`；
+// 支持 minimum、maximum、minLength、maxLength、minItems、maxItems、format 与 pattern，
+// pattern 的值可能包含逗号，必须放在最后
+type Generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+	aliases map[reflect.Type]string
+	enums   map[reflect.Type][]any
+}
+
+// NewGenerator 创建 Schema 生成器
+func NewGenerator() *Generator {
+	return &Generator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+		aliases: make(map[reflect.Type]string),
+		enums:   make(map[reflect.Type][]any),
+	}
+}
+
+// Enum 登记类型 T 的全部取值，生成的 Schema 带 enum 约束
+func Enum[T any](g *Generator, values ...T) {
+	enum := make([]any, 0, len(values))
+	for _, v := range values {
+		enum = append(enum, v)
+	}
+	g.enums[reflect.TypeFor[T]()] = enum
+}
+
+// Name 指定类型 T 在 components 中的名称，默认使用 Go 类型名
+func Name[T any](g *Generator, name string) {
+	g.aliases[reflect.TypeFor[T]()] = name
+}
+
+// Schema 返回 v 的类型对应的 Schema
+func (g *Generator) Schema(v any, dir Direction) *Schema {
+	return g.schemaFor(reflect.TypeOf(v), dir)
+}
+
+// Components 返回已生成的具名 Schema
+func (g *Generator) Components() map[string]*Schema {
+	return g.schemas
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+func (g *Generator) schemaFor(t reflect.Type, dir Direction) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	s := g.typeSchema(t, dir)
+	if enum, ok := g.enums[t]; ok {
+		s.Enum = enum
+	}
+	return s
+}
+
+func (g *Generator) typeSchema(t reflect.Type, dir Direction) *Schema {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schemaFor(t.Elem(), dir)
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: new(float64(0))}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem(), dir)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem(), dir)}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		if t == timeType {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		if t.Name() == "" {
+			return g.structSchema(t, dir)
+		}
+		return &Schema{Ref: "#/components/schemas/" + g.define(t, dir)}
+	}
+	panic(fmt.Sprintf("openapi: unsupported type %s", t))
+}
+
+// define 生成具名结构体的 Schema 并放入 components，名称冲突属于编程错误
+func (g *Generator) define(t reflect.Type, dir Direction) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name, ok := g.aliases[t]
+	if !ok {
+		name = t.Name()
+	}
+	if _, taken := g.schemas[name]; taken {
+		panic(fmt.Sprintf("openapi: schema name %q of %s already used, register another one with Name", name, t))
+	}
+	g.names[t] = name
+	// 先占位，允许结构体递归引用自身
+	g.schemas[name] = &Schema{}
+	*g.schemas[name] = *g.structSchema(t, dir)
+	return name
+}
+
+func (g *Generator) structSchema(t reflect.Type, dir Direction) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.addFields(s, t, dir)
+	return s
+}
+
+// addFields 按 encoding/json 的规则展开字段，未命名的嵌入结构体字段提升到外层
+func (g *Generator) addFields(s *Schema, t reflect.Type, dir Direction) {
+	for field := range t.Fields() {
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(s, embedded, dir)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		omitEmpty := strings.Contains(","+opts+",", ",omitempty,") || strings.Contains(","+opts+",", ",omitzero,")
+		prop := g.schemaFor(field.Type, dir)
+		// 没有 omitempty 的指针字段在为 nil 时输出 null
+		if field.Type.Kind() == reflect.Pointer && !omitEmpty {
+			prop = nullable(prop)
+		}
+		if err := applyConstraints(prop, field.Tag.Get("jsonschema")); err != nil {
+			panic(fmt.Sprintf("openapi: %s.%s: %v", t, field.Name, err))
+		}
+		s.Properties[name] = prop
+
+		required := !omitEmpty
+		if dir == ForRequest {
+			required = hasRule(field.Tag.Get("binding"), "required")
+		}
+		if required {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func nullable(s *Schema) *Schema {
+	if typ, ok := s.Type.(string); ok && s.Ref == "" {
+		s.Type = []string{typ, "null"}
+		return s
+	}
+	return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+}
+
+func hasRule(tag, rule string) bool {
+	for r := range strings.SplitSeq(tag, ",") {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func applyConstraints(s *Schema, tag string) error {
+	for tag != "" {
+		var item string
+		if strings.HasPrefix(tag, "pattern=") {
+			item, tag = tag, ""
+		} else {
+			item, tag, _ = strings.Cut(tag, ",")
+		}
+		key, value, _ := strings.Cut(item, "=")
+
+		var err error
+		switch key {
+		case "minimum":
+			s.Minimum, err = parseFloat(value)
+		case "maximum":
+			s.Maximum, err = parseFloat(value)
+		case "minLength":
+			s.MinLength, err = parseInt(value)
+		case "maxLength":
+			s.MaxLength, err = parseInt(value)
+		case "minItems":
+			s.MinItems, err = parseInt(value)
+		case "maxItems":
+			s.MaxItems, err = parseInt(value)
+		case "format":
+			s.Format = value
+		case "pattern":
+			s.Pattern = value
+		default:
+			return fmt.Errorf("unknown jsonschema constraint %q", key)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid jsonschema constraint %q: %w", item, err)
+		}
+	}
+	return nil
+}
+
+func parseFloat(value string) (*float64, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func parseInt(value string) (*int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
@@ -0,0 +1,113 @@
+package openapi
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+type testColor string
+
+type testBase struct {
+	ID int64 `json:"id"`
+}
+
+type testItem struct {
+	testBase
+	Name     string            `json:"name" jsonschema:"minLength=1,maxLength=64"`
+	Percent  float64           `json:"percent" jsonschema:"minimum=0,maximum=100"`
+	Code     string            `json:"code,omitempty" jsonschema:"pattern=^[a-z]{1,8}$"`
+	Color    testColor         `json:"color"`
+	Expires  *int64            `json:"expires"`
+	Parent   *testItem         `json:"parent,omitempty"`
+	Tags     []string          `json:"tags"`
+	Attrs    map[string]any    `json:"attrs,omitempty"`
+	Size     uint64            `json:"size"`
+	Internal string            `json:"-"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
+type testRequest struct {
+	Name  string     `json:"name" binding:"required"`
+	Color *testColor `json:"color"`
+}
+
+func TestGeneratorResponseSchema(t *testing.T) {
+	g := NewGenerator()
+	Enum(g, testColor("red"), testColor("blue"))
+
+	ref := g.Schema(testItem{}, ForResponse)
+	if ref.Ref != "#/components/schemas/testItem" {
+		t.Fatalf("expected ref to component, got %+v", ref)
+	}
+	s := g.Components()["testItem"]
+
+	wantRequired := []string{"id", "name", "percent", "color", "expires", "tags", "size"}
+	if !slices.Equal(s.Required, wantRequired) {
+		t.Fatalf("required = %v, want %v", s.Required, wantRequired)
+	}
+	if _, ok := s.Properties["Internal"]; ok {
+		t.Fatal("json:\"-\" field must be skipped")
+	}
+	if p := s.Properties["percent"]; *p.Minimum != 0 || *p.Maximum != 100 {
+		t.Fatalf("percent constraints not applied: %+v", p)
+	}
+	if p := s.Properties["name"]; *p.MinLength != 1 || *p.MaxLength != 64 {
+		t.Fatalf("name constraints not applied: %+v", p)
+	}
+	if p := s.Properties["code"]; p.Pattern != "^[a-z]{1,8}$" {
+		t.Fatalf("pattern with comma not preserved: %q", p.Pattern)
+	}
+	if p := s.Properties["color"]; !slices.Equal(p.Enum, []any{testColor("red"), testColor("blue")}) {
+		t.Fatalf("enum not applied: %+v", p)
+	}
+	if p := s.Properties["size"]; *p.Minimum != 0 {
+		t.Fatalf("unsigned integers must have minimum 0: %+v", p)
+	}
+	if p := s.Properties["parent"]; p.Ref != ref.Ref {
+		t.Fatalf("recursive reference expected, got %+v", p)
+	}
+
+	encoded, _ := json.Marshal(s.Properties["expires"])
+	if string(encoded) != `{"type":["integer","null"],"format":"int64"}` {
+		t.Fatalf("pointer without omitempty must be nullable, got %s", encoded)
+	}
+}
+
+func TestGeneratorRequestSchema(t *testing.T) {
+	g := NewGenerator()
+	Enum(g, testColor("red"))
+
+	g.Schema(testRequest{}, ForRequest)
+	s := g.Components()["testRequest"]
+	if !slices.Equal(s.Required, []string{"name"}) {
+		t.Fatalf("request required fields come from binding tags, got %v", s.Required)
+	}
+	if p := s.Properties["color"]; len(p.Enum) != 1 {
+		t.Fatalf("enum must apply through pointers: %+v", p)
+	}
+}
+
+func TestGeneratorNameConflict(t *testing.T) {
+	outer := testRequest{}
+	type testRequest struct {
+		Other string `json:"other"`
+	}
+
+	g := NewGenerator()
+	Name[testRequest](g, "OtherRequest")
+	g.Schema(testRequest{}, ForRequest)
+	g.Schema(outer, ForRequest)
+	if _, ok := g.Components()["OtherRequest"]; !ok {
+		t.Fatalf("expected renamed component, got %v", g.Components())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for duplicated schema name")
+		}
+	}()
+	g = NewGenerator()
+	g.Schema(testRequest{}, ForRequest)
+	g.Schema(outer, ForRequest)
+}
@@ -0,0 +1,133 @@
+// Package proxyauth 信任前置 SSO 网关（如 oauth2-proxy）注入的身份请求头。
+package proxyauth
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"main/internal/user"
+)
+
+const (
+	DefaultUserHeader  = "X-Forwarded-User"
+	DefaultEmailHeader = "X-Forwarded-Email"
+)
+
+var ErrNoTrustedProxies = errors.New("proxyauth: no trusted proxies configured")
+
+// Config 代理头认证配置
+type Config struct {
+	// TrustedProxies 允许注入身份头的代理地址，支持 CIDR 与单个 IP
+	TrustedProxies []string
+	UserHeader     string
+	EmailHeader    string
+	// DefaultRole 自动创建用户时分配的角色，已存在用户保持原角色
+	DefaultRole user.Role
+}
+
+// Identity 代理头中的用户身份，Username 为空时使用 Email
+type Identity struct {
+	Username string
+	Email    string
+}
+
+// Authenticator 校验请求是否来自可信代理并解析身份头
+type Authenticator struct {
+	cfg      Config
+	prefixes []netip.Prefix
+}
+
+// New 解析可信代理列表，列表为空时返回 ErrNoTrustedProxies
+func New(cfg Config) (*Authenticator, error) {
+	prefixes, err := ParsePrefixes(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	if len(prefixes) == 0 {
+		return nil, ErrNoTrustedProxies
+	}
+	if _, err := user.ParseRole(string(cfg.DefaultRole)); err != nil {
+		return nil, err
+	}
+	if cfg.UserHeader == "" {
+		cfg.UserHeader = DefaultUserHeader
+	}
+	if cfg.EmailHeader == "" {
+		cfg.EmailHeader = DefaultEmailHeader
+	}
+	return &Authenticator{
+		cfg:      cfg,
+		prefixes: prefixes,
+	}, nil
+}
+
+// ParsePrefixes 解析 CIDR 或单个 IP 列表，单个 IP 视为 /32 或 /128
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if strings.Contains(value, "/") {
+			prefix, err := netip.ParsePrefix(value)
+			if err != nil {
+				return nil, fmt.Errorf("proxyauth: invalid trusted proxy %q: %w", value, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("proxyauth: invalid trusted proxy %q: %w", value, err)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return prefixes, nil
+}
+
+// DefaultRole 自动创建用户时分配的角色
+func (a *Authenticator) DefaultRole() user.Role {
+	return a.cfg.DefaultRole
+}
+
+// Trusted 判断直连对端地址是否属于可信代理。
+// 只看 RemoteAddr，不看 X-Forwarded-For，否则客户端可伪造来源。
+func (a *Authenticator) Trusted(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range a.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasHeaders 判断请求是否携带任一身份头
+func (a *Authenticator) HasHeaders(r *http.Request) bool {
+	return r.Header.Get(a.cfg.UserHeader) != "" || r.Header.Get(a.cfg.EmailHeader) != ""
+}
+
+// Identity 读取身份头，两者均为空时返回 false
+func (a *Authenticator) Identity(r *http.Request) (Identity, bool) {
+	identity := Identity{
+		Username: strings.TrimSpace(r.Header.Get(a.cfg.UserHeader)),
+		Email:    strings.TrimSpace(r.Header.Get(a.cfg.EmailHeader)),
+	}
+	if identity.Username == "" {
+		identity.Username = identity.Email
+	}
+	return identity, identity.Username != ""
+}
@@ -0,0 +1,50 @@
+package proxyauth
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"main/internal/user"
+)
+
+func TestTrustedMatchesDirectPeerOnly(t *testing.T) {
+	authenticator, err := New(Config{
+		TrustedProxies: []string{"10.0.0.0/8", "192.168.1.10", "fd00::/8"},
+		DefaultRole:    user.RoleViewer,
+	})
+	if err != nil {
+		t.Fatalf("new authenticator: %v", err)
+	}
+
+	cases := map[string]bool{
+		"10.1.2.3:80":            true,
+		"192.168.1.10:443":       true,
+		"192.168.1.11:443":       false,
+		"[fd00::1]:8080":         true,
+		"[::ffff:10.0.0.1]:8080": true,
+		"203.0.113.1:80":         false,
+		"not-an-address":         false,
+		"@":                      false,
+	}
+	for remoteAddr, want := range cases {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", "10.0.0.1")
+		if got := authenticator.Trusted(req); got != want {
+			t.Errorf("Trusted(%q) = %v, want %v", remoteAddr, got, want)
+		}
+	}
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	if _, err := New(Config{DefaultRole: user.RoleViewer}); !errors.Is(err, ErrNoTrustedProxies) {
+		t.Fatalf("expected ErrNoTrustedProxies, got %v", err)
+	}
+	if _, err := New(Config{TrustedProxies: []string{"10.0.0.0/33"}, DefaultRole: user.RoleViewer}); err == nil {
+		t.Fatal("expected invalid CIDR to be rejected")
+	}
+	if _, err := New(Config{TrustedProxies: []string{"10.0.0.1"}, DefaultRole: "root"}); !errors.Is(err, user.ErrInvalidRole) {
+		t.Fatalf("expected ErrInvalidRole, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+// Package ratelimit 提供按客户端划分的令牌桶限流，桶数量有上限并按最近使用顺序淘汰。
+package ratelimit
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit 令牌桶参数：每 Period 补充 Requests 个令牌，最多积累 Burst 个
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// Enabled 零值 Limit 表示不限流
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// ParseLimit 解析 "请求数/周期[,突发]" 格式，周期为 s、m 或 h，例如 "120/m,20"。
+// 突发省略时等于请求数，"off" 或 "0" 表示不限流
+func ParseLimit(value string) (Limit, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" || strings.EqualFold(value, "off") {
+		return Limit{}, nil
+	}
+
+	rate, burstStr, hasBurst := strings.Cut(value, ",")
+	countStr, unit, ok := strings.Cut(rate, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("ratelimit: invalid limit %q, expected requests/period[,burst]", value)
+	}
+
+	var limit Limit
+	var err error
+	if limit.Requests, err = strconv.Atoi(strings.TrimSpace(countStr)); err != nil || limit.Requests <= 0 {
+		return Limit{}, fmt.Errorf("ratelimit: invalid request count in %q", value)
+	}
+	switch strings.TrimSpace(unit) {
+	case "s":
+		limit.Period = time.Second
+	case "m":
+		limit.Period = time.Minute
+	case "h":
+		limit.Period = time.Hour
+	default:
+		return Limit{}, fmt.Errorf("ratelimit: invalid period in %q, expected s, m or h", value)
+	}
+	if hasBurst {
+		if limit.Burst, err = strconv.Atoi(strings.TrimSpace(burstStr)); err != nil || limit.Burst <= 0 {
+			return Limit{}, fmt.Errorf("ratelimit: invalid burst in %q", value)
+		}
+	}
+	return limit, nil
+}
+
+// Result 单次请求的限流结果
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset 令牌桶补满所需时间
+	Reset time.Duration
+	// RetryAfter 被拒绝时距离下一个令牌可用的时间
+	RetryAfter time.Duration
+}
+
+// IPKey 返回 IP 对应的限流键：IPv4 按单个地址，IPv6 按 /64 前缀。
+// 单个 IPv6 客户端通常能支配整个 /64，按地址计数时轮换源地址即可绕过限额并挤占其他客户端的桶
+func IPKey(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+	addr = addr.Unmap()
+	if addr.Is4() {
+		return addr.String()
+	}
+	prefix, _ := addr.WithZone("").Prefix(64)
+	return prefix.String()
+}
+
+type bucket struct {
+	key      string
+	tokens   float64
+	lastSeen time.Time
+}
+
+// Limiter 以客户端标识为键的令牌桶集合，并发安全。
+// 桶按最近使用顺序保存在链表中，查找、淘汰与清理空闲桶都不需要遍历全部桶
+type Limiter struct {
+	limit    Limit
+	perToken time.Duration
+	maxKeys  int
+	idleTTL  time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*list.Element
+	// lru 表头为最近使用的桶，表尾为最久未使用的桶
+	lru *list.List
+}
+
+// New 创建限流器，maxKeys 限制同时跟踪的客户端数量。
+// 超过补满时间未活动的桶与新建的桶等价，会被清理；
+// 达到上限时淘汰最久未活动的桶。limit 未启用时返回 nil，nil 限流器放行所有请求
+func New(limit Limit, maxKeys int) *Limiter {
+	if !limit.Enabled() {
+		return nil
+	}
+	perToken := limit.Period / time.Duration(limit.Requests)
+	idleTTL := perToken * time.Duration(limit.burst())
+	if idleTTL < time.Minute {
+		idleTTL = time.Minute
+	}
+	return &Limiter{
+		limit:    limit,
+		perToken: perToken,
+		maxKeys:  maxKeys,
+		idleTTL:  idleTTL,
+		now:      time.Now,
+		buckets:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Allow 为 key 消耗一个令牌
+func (l *Limiter) Allow(key string) Result {
+	if l == nil {
+		return Result{Allowed: true}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	burst := float64(l.limit.burst())
+	var b *bucket
+	if elem, ok := l.buckets[key]; ok {
+		b = elem.Value.(*bucket)
+		elapsed := now.Sub(b.lastSeen)
+		b.tokens = math.Min(burst, b.tokens+float64(elapsed)/float64(l.perToken))
+		b.lastSeen = now
+		l.lru.MoveToFront(elem)
+	} else {
+		if l.maxKeys > 0 && l.lru.Len() >= l.maxKeys {
+			l.remove(l.lru.Back())
+		}
+		b = &bucket{key: key, tokens: burst, lastSeen: now}
+		l.buckets[key] = l.lru.PushFront(b)
+	}
+
+	result := Result{Limit: l.limit.burst()}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) * float64(l.perToken))
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration((burst - b.tokens) * float64(l.perToken))
+	return result
+}
+
+// Len 返回当前跟踪的客户端数量
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lru.Len()
+}
+
+// sweep 从表尾开始删除空闲超过 idleTTL 的桶，遇到第一个仍活跃的桶即停止
+func (l *Limiter) sweep(now time.Time) {
+	for elem := l.lru.Back(); elem != nil; elem = l.lru.Back() {
+		if now.Sub(elem.Value.(*bucket).lastSeen) < l.idleTTL {
+			return
+		}
+		l.remove(elem)
+	}
+}
+
+func (l *Limiter) remove(elem *list.Element) {
+	b := l.lru.Remove(elem).(*bucket)
+	delete(l.buckets, b.key)
+}
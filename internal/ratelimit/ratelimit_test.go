@@ -0,0 +1,121 @@
+package ratelimit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(t *testing.T, value string, maxKeys int) (*Limiter, *time.Time) {
+	t.Helper()
+	limit, err := ParseLimit(value)
+	if err != nil {
+		t.Fatalf("parse limit: %v", err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	limiter := New(limit, maxKeys)
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+func TestParseLimit(t *testing.T) {
+	cases := map[string]Limit{
+		"120/m,20": {Requests: 120, Period: time.Minute, Burst: 20},
+		" 5 / s ":  {Requests: 5, Period: time.Second},
+		"1000/h":   {Requests: 1000, Period: time.Hour},
+		"off":      {},
+		"0":        {},
+	}
+	for value, want := range cases {
+		got, err := ParseLimit(value)
+		if err != nil || got != want {
+			t.Errorf("ParseLimit(%q) = %+v, %v; want %+v", value, got, err, want)
+		}
+	}
+
+	for _, value := range []string{"10", "10/d", "-1/m", "10/m,0", "x/m", "10/m,x"} {
+		if _, err := ParseLimit(value); err == nil {
+			t.Errorf("ParseLimit(%q) expected error", value)
+		}
+	}
+
+	if New(Limit{}, 10) != nil {
+		t.Fatal("expected nil limiter for disabled limit")
+	}
+	var disabled *Limiter
+	if !disabled.Allow("any").Allowed {
+		t.Fatal("expected nil limiter to allow requests")
+	}
+}
+
+func TestLimiterTokenBucket(t *testing.T) {
+	limiter, now := newTestLimiter(t, "60/m,3", 0)
+
+	for i := range 3 {
+		result := limiter.Allow("client")
+		if !result.Allowed || result.Remaining != 2-i || result.Limit != 3 {
+			t.Fatalf("request %d: unexpected result %+v", i, result)
+		}
+	}
+
+	result := limiter.Allow("client")
+	if result.Allowed || result.RetryAfter != time.Second || result.Reset != 3*time.Second {
+		t.Fatalf("expected rejection with 1s retry, got %+v", result)
+	}
+	if !limiter.Allow("other").Allowed {
+		t.Fatal("expected independent bucket for another client")
+	}
+
+	*now = now.Add(1500 * time.Millisecond)
+	if result := limiter.Allow("client"); !result.Allowed || result.Remaining != 0 {
+		t.Fatalf("expected one refilled token, got %+v", result)
+	}
+
+	*now = now.Add(time.Hour)
+	if result := limiter.Allow("client"); !result.Allowed || result.Remaining != 2 {
+		t.Fatalf("expected bucket capped at burst, got %+v", result)
+	}
+}
+
+func TestLimiterBoundsAndEvictsIdleBuckets(t *testing.T) {
+	limiter, now := newTestLimiter(t, "60/m,3", 3)
+
+	for i := range 3 {
+		limiter.Allow(fmt.Sprintf("client-%d", i))
+		*now = now.Add(time.Second)
+	}
+	for range 3 {
+		limiter.Allow("client-0")
+	}
+
+	// 达到上限时淘汰最久未活动的 client-1，client-0 的消耗记录保留
+	limiter.Allow("client-3")
+	if limiter.Len() != 3 {
+		t.Fatalf("expected bucket count bounded at 3, got %d", limiter.Len())
+	}
+	if result := limiter.Allow("client-0"); result.Allowed {
+		t.Fatalf("expected client-0 bucket to survive eviction, got %+v", result)
+	}
+
+	*now = now.Add(2 * time.Minute)
+	limiter.Allow("client-4")
+	if limiter.Len() != 1 {
+		t.Fatalf("expected idle buckets to be swept, got %d", limiter.Len())
+	}
+}
+
+func TestIPKey(t *testing.T) {
+	tests := map[string]string{
+		"203.0.113.7":               "203.0.113.7",
+		"::ffff:203.0.113.7":        "203.0.113.7",
+		"2001:db8:1:2::1":           "2001:db8:1:2::/64",
+		"2001:db8:1:2:aaaa:bbbb::9": "2001:db8:1:2::/64",
+		"fe80::1%eth0":              "fe80::/64",
+		"":                          "",
+	}
+	for ip, want := range tests {
+		if got := IPKey(ip); got != want {
+			t.Fatalf("IPKey(%q) = %q, want %q", ip, got, want)
+		}
+	}
+}
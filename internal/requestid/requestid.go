@@ -0,0 +1,65 @@
+// Package requestid 为每个请求分配 X-Request-ID，并提供携带 request_id 的请求级 logger。
+package requestid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	sloggin "github.com/samber/slog-gin"
+)
+
+// HeaderName 请求与响应中携带请求 ID 的头
+const HeaderName = "X-Request-ID"
+
+// LogKey 日志与错误响应中的字段名
+const LogKey = "request_id"
+
+const (
+	contextKey = "request_id"
+	loggerKey  = "request_logger"
+)
+
+// 只接受长度有限的安全字符，避免客户端借请求头向日志注入内容
+var validID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// Middleware 沿用客户端或上游代理传入的合法 X-Request-ID，否则生成新的 ID。
+// ID 写入响应头与访问日志，并以此创建请求级 logger；错误响应通过 Get 读取。
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(HeaderName)
+		if !validID.MatchString(id) {
+			id = generate()
+		}
+
+		c.Set(contextKey, id)
+		c.Set(loggerKey, slog.Default().With(LogKey, id))
+		c.Header(HeaderName, id)
+		sloggin.AddCustomAttributes(c, slog.String(LogKey, id))
+
+		c.Next()
+	}
+}
+
+// Get 返回当前请求 ID，未经过 Middleware 时返回空字符串
+func Get(c *gin.Context) string {
+	return c.GetString(contextKey)
+}
+
+// Logger 返回携带 request_id 的请求级 logger，未经过 Middleware 时返回默认 logger
+func Logger(c *gin.Context) *slog.Logger {
+	if logger, ok := c.Get(loggerKey); ok {
+		if l, ok := logger.(*slog.Logger); ok {
+			return l
+		}
+	}
+	return slog.Default()
+}
+
+func generate() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
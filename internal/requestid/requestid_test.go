@@ -0,0 +1,59 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestRouter(handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/", handler)
+	return r
+}
+
+func serve(r *gin.Engine, requestID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if requestID != "" {
+		req.Header.Set(HeaderName, requestID)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddlewarePropagatesOrGeneratesID(t *testing.T) {
+	var seen string
+	r := newTestRouter(func(c *gin.Context) {
+		seen = Get(c)
+		c.Status(http.StatusNoContent)
+	})
+
+	w := serve(r, "upstream-id:42")
+	if seen != "upstream-id:42" || w.Header().Get(HeaderName) != "upstream-id:42" {
+		t.Fatalf("expected upstream id to be reused, got context=%q header=%q", seen, w.Header().Get(HeaderName))
+	}
+
+	for _, invalid := range []string{"", "bad id", "line\nbreak", strings.Repeat("a", 129)} {
+		w = serve(r, invalid)
+		got := w.Header().Get(HeaderName)
+		if got == invalid || len(got) != 32 || seen != got {
+			t.Fatalf("expected generated id for %q, got header=%q context=%q", invalid, got, seen)
+		}
+	}
+}
+
+func TestLoggerFallsBackToDefault(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	if Logger(c) == nil {
+		t.Fatal("expected default logger without middleware")
+	}
+	if Get(c) != "" {
+		t.Fatalf("expected empty id without middleware, got %q", Get(c))
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/middleware"
+)
+
+// apiBasePath 所有接口的公共前缀
+const apiBasePath = "/api"
+
+// currentAPIVersion 前端使用的接口版本，OpenAPI 文档描述该版本
+const currentAPIVersion = "v1"
+
+// legacyAPIAlias 无版本号的 /api 前缀是 v1 的别名，供升级前缓存在浏览器中的旧前端与已有脚本继续使用；
+// 弃用半年后下线
+var legacyAPIAlias = middleware.Deprecation{
+	Since:  time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC),
+	Sunset: time.Date(2027, time.April, 18, 0, 0, 0, 0, time.UTC),
+	Successor: func(path string) string {
+		return apiBasePath + "/" + currentAPIVersion + strings.TrimPrefix(path, apiBasePath)
+	},
+}
+
+// apiMount 路由的一个挂载点
+type apiMount struct {
+	version string
+	group   *gin.RouterGroup
+}
+
+// apiRouter 将路由同时注册到多个挂载点：/api/v1 等各版本前缀，以及作为 v1 别名的 /api。
+// 各版本共用的路由直接注册；某个版本需要不同的处理器时，通过 Version 只注册到该版本
+type apiRouter struct {
+	mounts []apiMount
+}
+
+// newAPIRouter 在 base 下创建 v1 与 /api 别名两个挂载点，别名上的请求带弃用响应头
+func newAPIRouter(base *gin.RouterGroup) apiRouter {
+	return apiRouter{mounts: []apiMount{
+		{version: currentAPIVersion, group: base.Group("/" + currentAPIVersion)},
+		{version: currentAPIVersion, group: base.Group("", middleware.Deprecated(legacyAPIAlias))},
+	}}
+}
+
+// Version 返回只包含指定版本挂载点的 apiRouter，用于注册该版本特有的处理器
+func (a apiRouter) Version(version string) apiRouter {
+	var mounts []apiMount
+	for _, m := range a.mounts {
+		if m.version == version {
+			mounts = append(mounts, m)
+		}
+	}
+	return apiRouter{mounts: mounts}
+}
+
+// Group 在每个挂载点下创建子分组
+func (a apiRouter) Group(relativePath string, handlers ...gin.HandlerFunc) apiRouter {
+	mounts := make([]apiMount, len(a.mounts))
+	for i, m := range a.mounts {
+		mounts[i] = apiMount{version: m.version, group: m.group.Group(relativePath, handlers...)}
+	}
+	return apiRouter{mounts: mounts}
+}
+
+// Use 为每个挂载点添加中间件；中间件实例在各挂载点间共享，限流等状态不会因别名而翻倍
+func (a apiRouter) Use(handlers ...gin.HandlerFunc) {
+	for _, m := range a.mounts {
+		m.group.Use(handlers...)
+	}
+}
+
+// Handle 在每个挂载点注册路由
+func (a apiRouter) Handle(method, relativePath string, handlers ...gin.HandlerFunc) {
+	for _, m := range a.mounts {
+		m.group.Handle(method, relativePath, handlers...)
+	}
+}
+
+func (a apiRouter) GET(relativePath string, handlers ...gin.HandlerFunc) {
+	a.Handle(http.MethodGet, relativePath, handlers...)
+}
+
+func (a apiRouter) POST(relativePath string, handlers ...gin.HandlerFunc) {
+	a.Handle(http.MethodPost, relativePath, handlers...)
+}
+
+func (a apiRouter) PUT(relativePath string, handlers ...gin.HandlerFunc) {
+	a.Handle(http.MethodPut, relativePath, handlers...)
+}
+
+func (a apiRouter) PATCH(relativePath string, handlers ...gin.HandlerFunc) {
+	a.Handle(http.MethodPatch, relativePath, handlers...)
+}
+
+func (a apiRouter) DELETE(relativePath string, handlers ...gin.HandlerFunc) {
+	a.Handle(http.MethodDelete, relativePath, handlers...)
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/middleware"
+)
+
+func TestLegacyAPIAliasIsDeprecated(t *testing.T) {
+	r := newTestRouter(t)
+
+	for _, path := range []string{"/api/v1/auth/methods", "/api/auth/methods"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", path, w.Code)
+		}
+
+		deprecated := w.Header().Get("Deprecation") != ""
+		if deprecated != (path == "/api/auth/methods") {
+			t.Fatalf("%s: unexpected Deprecation header %q", path, w.Header().Get("Deprecation"))
+		}
+		if !deprecated && w.Header().Get("Sunset") != "" {
+			t.Fatalf("%s: unexpected Sunset header %q", path, w.Header().Get("Sunset"))
+		}
+		if deprecated {
+			if want := fmt.Sprintf("@%d", legacyAPIAlias.Since.Unix()); w.Header().Get("Deprecation") != want {
+				t.Fatalf("expected Deprecation %s, got %q", want, w.Header().Get("Deprecation"))
+			}
+			if want := "Sun, 18 Apr 2027 00:00:00 GMT"; w.Header().Get("Sunset") != want {
+				t.Fatalf("expected Sunset %s, got %q", want, w.Header().Get("Sunset"))
+			}
+			if want := `</api/v1/auth/methods>; rel="successor-version"`; w.Header().Get("Link") != want {
+				t.Fatalf("expected Link %s, got %q", want, w.Header().Get("Link"))
+			}
+		}
+	}
+}
+
+func TestAPIRouterRegistersPerVersionHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	api := newAPIRouter(r.Group(apiBasePath))
+	api.GET("/shared", func(c *gin.Context) { c.String(http.StatusOK, "shared") })
+	api.Version(currentAPIVersion).GET("/only", func(c *gin.Context) { c.String(http.StatusOK, "v1") })
+	api.Version("v2").GET("/future", func(c *gin.Context) { c.String(http.StatusOK, "v2") })
+
+	routes := make(map[string]bool)
+	for _, route := range r.Routes() {
+		routes[route.Path] = true
+	}
+	for _, path := range []string{"/api/shared", "/api/v1/shared", "/api/only", "/api/v1/only"} {
+		if !routes[path] {
+			t.Errorf("expected route %s to be registered", path)
+		}
+	}
+	for path := range routes {
+		if strings.HasSuffix(path, "/future") {
+			t.Errorf("route %s registered for a version without mounts", path)
+		}
+	}
+}
+
+func TestDeprecatedSetsSunsetAndThrottlesWarnings(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	sunset := time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/old", middleware.Deprecated(middleware.Deprecation{Since: sunset.AddDate(0, -3, 0), Sunset: sunset}), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	for range 3 {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/old", nil))
+		if got := w.Header().Get("Sunset"); got != "Fri, 01 Jan 2027 00:00:00 GMT" {
+			t.Fatalf("unexpected Sunset header %q", got)
+		}
+		if w.Header().Get("Link") != "" {
+			t.Fatalf("expected no Link header without successor, got %q", w.Header().Get("Link"))
+		}
+	}
+	if n := strings.Count(logs.String(), "deprecated api endpoint called"); n != 1 {
+		t.Fatalf("expected exactly one warning, got %d:\n%s", n, logs.String())
+	}
+}
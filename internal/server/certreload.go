@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CertReloader 持有当前服务端证书，证书文件内容变化时原子替换，
+// 用于 cert-manager、certbot 等定期续期证书的场景，无需重启即可生效。
+// 已建立的连接继续使用旧证书，新握手使用新证书。
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	cert atomic.Pointer[tls.Certificate]
+
+	mu     sync.Mutex
+	digest [sha256.Size]byte
+}
+
+// NewCertReloader 加载证书与私钥，首次加载失败时返回错误
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate 供 tls.Config.GetCertificate 使用，返回当前证书
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// Reload 重新读取证书文件，内容未变化时返回 false。
+// 加载失败（如续期过程中证书与私钥尚未同时写完）时保留当前证书
+func (r *CertReloader) Reload() (bool, error) {
+	certPEM, err := os.ReadFile(r.certFile)
+	if err != nil {
+		return false, fmt.Errorf("server: failed to read tls certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("server: failed to read tls key: %w", err)
+	}
+
+	h := sha256.New()
+	h.Write(certPEM)
+	h.Write(keyPEM)
+	var digest [sha256.Size]byte
+	h.Sum(digest[:0])
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cert.Load() != nil && digest == r.digest {
+		return false, nil
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return false, fmt.Errorf("server: failed to load tls certificate: %w", err)
+	}
+	r.cert.Store(&cert)
+	r.digest = digest
+	return true, nil
+}
+
+// Run 按 interval 检查证书文件，直到 ctx 取消；interval 不大于 0 时不检查
+func (r *CertReloader) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := r.Reload()
+			if err != nil {
+				slog.Warn(
+					"tls certificate reload failed, keeping current certificate",
+					"cert_file",
+					r.certFile,
+					"error",
+					err,
+				)
+				continue
+			}
+			if changed {
+				leaf := r.cert.Load().Leaf
+				slog.Info(
+					"TLS 证书已重新加载",
+					"cert_file",
+					r.certFile,
+					"subject",
+					leaf.Subject.String(),
+					"not_after",
+					leaf.NotAfter.Format(time.RFC3339),
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/quic-go/quic-go/http3"
+
+	"main/internal/config"
+)
+
+// NewHTTP3Server 创建与 HTTPS 服务器共用 handler 与 TLS 配置的 HTTP/3 服务器，监听同一主机地址与端口号的 UDP。
+// 证书热更新与客户端证书校验沿用 tlsConfig；HTTP/3 没有整体读写超时，只限制空闲时间与请求头大小
+func NewHTTP3Server(cfg *config.Config, handler http.Handler, tlsConfig *tls.Config) *http3.Server {
+	return &http3.Server{
+		Addr:           net.JoinHostPort(cfg.ListenHost, strconv.Itoa(cfg.Port)),
+		Handler:        handler,
+		TLSConfig:      tlsConfig,
+		IdleTimeout:    cfg.HTTPIdleTimeout,
+		MaxHeaderBytes: cfg.HTTPMaxHeaderBytes,
+	}
+}
+
+// WithAltSvc 在 HTTP/1.1 与 HTTP/2 响应中通过 Alt-Svc 声明 HTTP/3 端点，浏览器后续请求会尝试切换到 QUIC
+func WithAltSvc(h3 *http3.Server, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor < 3 {
+			// UDP 监听尚未建立时没有可声明的端口，此时不发送
+			_ = h3.SetQUICHeaders(w.Header())
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+
+	"main/internal/config"
+	"main/internal/handlers"
+	"main/internal/mtls/mtlstest"
+	"main/internal/requestid"
+	"main/internal/stream"
+)
+
+// startHTTP3TestServer 在随机 UDP 端口上以 SPA 与日志流路由启动 HTTP/3 服务器，返回地址与信任该证书的客户端
+func startHTTP3TestServer(t *testing.T, broadcaster *stream.LogBroadcaster) (*http3.Server, string, *http.Client) {
+	t.Helper()
+
+	r := newSPATestRouter(t)
+	r.Use(requestid.Middleware())
+	r.GET("/stream", handlers.NewLogsHandler(broadcaster).StreamLogs)
+
+	ca := mtlstest.NewCA("http3-ca")
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{ca.IssueServer()}}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen udp: %v", err)
+	}
+	srv := NewHTTP3Server(&config.Config{HTTPIdleTimeout: time.Minute, HTTPMaxHeaderBytes: 64 << 10}, r, tlsConfig)
+	go func() { _ = srv.Serve(conn) }()
+	t.Cleanup(func() {
+		_ = srv.Close()
+		_ = conn.Close()
+	})
+
+	transport := &http3.Transport{TLSClientConfig: &tls.Config{RootCAs: ca.Pool()}}
+	t.Cleanup(func() { _ = transport.Close() })
+	return srv, "https://" + conn.LocalAddr().String(), &http.Client{Transport: transport, Timeout: 5 * time.Second}
+}
+
+func TestHTTP3ServesPrecompressedAssets(t *testing.T) {
+	_, baseURL, client := startHTTP3TestServer(t, stream.NewLogBroadcaster())
+
+	req, _ := http.NewRequest(http.MethodGet, baseURL+"/assets/index.js", nil)
+	req.Header.Set("Accept-Encoding", "br, gzip")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request over http3: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.ProtoMajor != 3 || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 over HTTP/3, got %s %d", resp.Proto, resp.StatusCode)
+	}
+	if resp.Header.Get("Content-Encoding") != "br" || string(body) != "brotli" {
+		t.Fatalf("expected precompressed brotli asset, got encoding=%q body=%q", resp.Header.Get("Content-Encoding"), body)
+	}
+}
+
+func TestHTTP3StreamsLogs(t *testing.T) {
+	broadcaster := stream.NewLogBroadcaster()
+	_, baseURL, client := startHTTP3TestServer(t, broadcaster)
+
+	resp, err := client.Get(baseURL + "/stream?history=0")
+	if err != nil {
+		t.Fatalf("open stream over http3: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.ProtoMajor != 3 || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("unexpected stream response: %s %v", resp.Proto, resp.Header)
+	}
+
+	broadcaster.Broadcast(stream.LogEntry{Time: "now", Level: "INFO", Message: "over quic"})
+
+	lines := make(chan string, 64)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatal("stream closed before log entry arrived")
+			}
+			if strings.Contains(line, "over quic") {
+				return
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for log entry")
+		}
+	}
+}
+
+func TestWithAltSvcAdvertisesHTTP3(t *testing.T) {
+	h3, baseURL, _ := startHTTP3TestServer(t, stream.NewLogBroadcaster())
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(baseURL, "https://"))
+
+	ts := httptest.NewTLSServer(WithAltSvc(h3, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+	defer ts.Close()
+
+	resp, err := ts.Client().Get(ts.URL)
+	if err != nil {
+		t.Fatalf("request over tcp: %v", err)
+	}
+	resp.Body.Close()
+	if want := fmt.Sprintf(`h3=":%s"`, port); !strings.HasPrefix(resp.Header.Get("Alt-Svc"), want) {
+		t.Fatalf("expected Alt-Svc %s, got %q", want, resp.Header.Get("Alt-Svc"))
+	}
+}
+
+func TestHTTP3ServerBindsListenHost(t *testing.T) {
+	cfg := &config.Config{ListenHost: "127.0.0.1", Port: 8443}
+	if addr := NewHTTP3Server(cfg, http.NotFoundHandler(), nil).Addr; addr != "127.0.0.1:8443" {
+		t.Fatalf("expected HTTP/3 on LISTEN_ADDR host, got %q", addr)
+	}
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/config"
+	"main/internal/handlers"
+	"main/internal/requestid"
+	"main/internal/stream"
+)
+
+func newTimeoutTestServer(t *testing.T, broadcaster *stream.LogBroadcaster) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(requestid.Middleware())
+	r.GET("/stream", handlers.NewLogsHandler(broadcaster).StreamLogs)
+	r.GET("/slow", func(c *gin.Context) {
+		time.Sleep(300 * time.Millisecond)
+		c.String(http.StatusOK, "late")
+	})
+
+	cfg := &config.Config{
+		HTTPReadHeaderTimeout: 100 * time.Millisecond,
+		HTTPReadTimeout:       time.Second,
+		HTTPWriteTimeout:      150 * time.Millisecond,
+		HTTPIdleTimeout:       time.Second,
+		HTTPMaxHeaderBytes:    4 << 10,
+	}
+	ts := httptest.NewUnstartedServer(r)
+	ts.Config = NewHTTPServer(cfg, r, nil)
+	ts.Start()
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestHTTPServerClosesSlowHeaderConnections(t *testing.T) {
+	ts := newTimeoutTestServer(t, stream.NewLogBroadcaster())
+
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("GET /slow HTTP/1.1\r\nHost: example.com\r\n")); err != nil {
+		t.Fatalf("write partial header: %v", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 1)
+	if _, err := conn.Read(buf); err == nil || strings.Contains(err.Error(), "timeout") {
+		t.Fatalf("expected server to close slow connection, got err=%v", err)
+	}
+}
+
+func TestHTTPServerWriteTimeoutExemptsLogStream(t *testing.T) {
+	broadcaster := stream.NewLogBroadcaster()
+	ts := newTimeoutTestServer(t, broadcaster)
+
+	if _, err := ts.Client().Get(ts.URL + "/slow"); err == nil {
+		t.Fatal("expected regular request exceeding write timeout to fail")
+	}
+
+	resp, err := ts.Client().Get(ts.URL + "/stream?history=0")
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// 超过写超时后仍能收到推送
+	time.Sleep(400 * time.Millisecond)
+	broadcaster.Broadcast(stream.LogEntry{Time: "now", Level: "INFO", Message: "after write timeout"})
+
+	lines := make(chan string, 64)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatal("stream closed before log entry arrived")
+			}
+			if strings.Contains(line, "after write timeout") {
+				return
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for log entry")
+		}
+	}
+}
+
+func TestHTTPServerRejectsOversizedHeaders(t *testing.T) {
+	ts := newTimeoutTestServer(t, stream.NewLogBroadcaster())
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/slow", nil)
+	req.Header.Set("X-Padding", strings.Repeat("a", 16<<10))
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestHeaderFieldsTooLarge {
+		t.Fatalf("expected 431, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPServerShutdownNotifiesLogStream(t *testing.T) {
+	broadcaster := stream.NewLogBroadcaster()
+	ts := newTimeoutTestServer(t, broadcaster)
+	ts.Config.RegisterOnShutdown(broadcaster.Shutdown)
+
+	resp, err := ts.Client().Get(ts.URL + "/stream?history=0")
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := ts.Config.Shutdown(ctx); err != nil {
+		t.Fatalf("expected shutdown to drain log stream, got %v", err)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "event:shutdown") || !strings.Contains(string(body), "retry:3000") {
+		t.Fatalf("expected shutdown event with retry hint, got %q", body)
+	}
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		port   int
+		method string
+		target string
+		want   string
+	}{
+		{8443, http.MethodGet, "http://example.com:8080/logs?level=warn", "https://example.com:8443/logs?level=warn"},
+		{443, http.MethodPost, "http://example.com/api/login", "https://example.com/api/login"},
+		{443, http.MethodGet, "http://[::1]:8080/", "https://[::1]/"},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		redirectToHTTPS(tt.port).ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.target, nil))
+		if recorder.Code != http.StatusPermanentRedirect {
+			t.Fatalf("%s %s: expected 308, got %d", tt.method, tt.target, recorder.Code)
+		}
+		if got := recorder.Header().Get("Location"); got != tt.want {
+			t.Fatalf("%s %s: expected Location %q, got %q", tt.method, tt.target, tt.want, got)
+		}
+	}
+}
+
+func TestRedirectServerBindsListenHost(t *testing.T) {
+	cfg := &config.Config{ListenHost: "127.0.0.1", Port: 8443, HTTPRedirectPort: 8080}
+	if addr := NewRedirectServer(cfg).Addr; addr != "127.0.0.1:8080" {
+		t.Fatalf("expected redirect server on LISTEN_ADDR host, got %q", addr)
+	}
+	cfg.ListenHost = "::1"
+	if addr := NewRedirectServer(cfg).Addr; addr != "[::1]:8080" {
+		t.Fatalf("expected bracketed IPv6 redirect address, got %q", addr)
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"main/internal/config"
+	"main/internal/systemd"
+)
+
+// Listen 创建主服务的监听器：由 systemd 套接字激活时使用传入的第一个套接字，
+// 否则按 LISTEN_ADDR 监听 Unix 域套接字或 TCP 地址
+func Listen(cfg *config.Config) (net.Listener, error) {
+	activated, err := systemd.Listeners()
+	if err != nil {
+		return nil, err
+	}
+	if len(activated) > 0 {
+		for _, extra := range activated[1:] {
+			slog.Warn("ignoring extra systemd socket", "address", extra.Addr().String())
+			extra.Close()
+		}
+		slog.Info("using systemd socket activation", "address", activated[0].Addr().String())
+		return wrapUnixListener(activated[0]), nil
+	}
+
+	if path, ok := strings.CutPrefix(cfg.ListenAddr, config.UnixSocketPrefix); ok {
+		return listenUnix(path, cfg.ListenSocketMode)
+	}
+	return net.Listen("tcp", cfg.ListenAddr)
+}
+
+// listenUnix 监听 Unix 域套接字并设置文件权限；上次异常退出遗留的套接字文件会被清理，
+// 关闭监听器时删除套接字文件
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("server: listen on unix socket %s: %w", path, err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("server: chmod unix socket %s: %w", path, err)
+	}
+	return wrapUnixListener(listener), nil
+}
+
+// removeStaleSocket 删除无进程监听的遗留套接字文件；仍有进程监听或路径不是套接字时返回错误，避免误删
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("server: stat unix socket %s: %w", path, err)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("server: %s exists and is not a unix socket", path)
+	}
+
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("server: unix socket %s is already in use", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("server: remove stale unix socket %s: %w", path, err)
+	}
+	slog.Info("removed stale unix socket", "path", path)
+	return nil
+}
+
+// loopbackAddr Unix 域套接字连接对外报告的对端地址
+var loopbackAddr = &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}
+
+// unixListener 将 Unix 域套接字连接的对端地址报告为 127.0.0.1。
+// 连接没有 IP 地址，否则 ClientIP 为空，所有请求共用同一限流额度且无法信任反向代理的 X-Forwarded-For；
+// 能连接套接字即意味着位于本机，将 127.0.0.1 加入 TRUSTED_PROXIES 后即可按代理转发的地址识别客户端
+type unixListener struct {
+	net.Listener
+}
+
+type unixConn struct {
+	net.Conn
+}
+
+func (c unixConn) RemoteAddr() net.Addr {
+	return loopbackAddr
+}
+
+// CloseWrite 保留半关闭能力，http.Server 关闭连接前会先关闭写方向
+func (c unixConn) CloseWrite() error {
+	if cw, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+	return nil
+}
+
+func (l unixListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return unixConn{Conn: conn}, nil
+}
+
+func wrapUnixListener(listener net.Listener) net.Listener {
+	if listener.Addr().Network() != "unix" {
+		return listener
+	}
+	return unixListener{Listener: listener}
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"main/internal/config"
+)
+
+func unixClient(path string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+}
+
+func TestListenServesOverUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+	cfg := &config.Config{ListenAddr: config.UnixSocketPrefix + path, ListenSocketMode: 0o660}
+
+	listener, err := Listen(cfg)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat socket: %v", err)
+	}
+	if info.Mode().Perm() != 0o660 {
+		t.Fatalf("expected socket mode 0660, got %s", info.Mode().Perm())
+	}
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.RemoteAddr)
+	})}
+	go func() { _ = srv.Serve(listener) }()
+
+	resp, err := unixClient(path).Get("http://app/")
+	if err != nil {
+		t.Fatalf("request over unix socket: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if host, _, _ := net.SplitHostPort(string(body)); host != "127.0.0.1" {
+		t.Fatalf("expected unix socket peers to be reported as 127.0.0.1, got %q", body)
+	}
+
+	if err := srv.Close(); err != nil {
+		t.Fatalf("close server: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected socket file to be removed on close, got %v", err)
+	}
+}
+
+func TestListenRemovesStaleUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	// 模拟进程异常退出：监听已关闭但套接字文件仍在
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := Listen(&config.Config{ListenAddr: config.UnixSocketPrefix + path, ListenSocketMode: 0o600})
+	if err != nil {
+		t.Fatalf("expected stale socket to be replaced, got %v", err)
+	}
+	defer listener.Close()
+
+	if _, err := Listen(&config.Config{ListenAddr: config.UnixSocketPrefix + path, ListenSocketMode: 0o600}); err == nil ||
+		!strings.Contains(err.Error(), "already in use") {
+		t.Fatalf("expected in-use socket to be rejected, got %v", err)
+	}
+}
+
+func TestListenRefusesToReplaceRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	if _, err := Listen(&config.Config{ListenAddr: config.UnixSocketPrefix + path, ListenSocketMode: 0o600}); err == nil {
+		t.Fatal("expected error when the socket path is a regular file")
+	}
+	if content, err := os.ReadFile(path); err != nil || string(content) != "data" {
+		t.Fatalf("expected regular file to be left untouched, got %q (%v)", content, err)
+	}
+}
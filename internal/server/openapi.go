@@ -0,0 +1,364 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apierror"
+	"main/internal/apitoken"
+	"main/internal/audit"
+	"main/internal/handlers"
+	"main/internal/maintenance"
+	"main/internal/openapi"
+	"main/internal/session"
+	"main/internal/stream"
+	"main/internal/user"
+)
+
+// apiDocVersion 接口文档版本，接口出现不兼容变更时递增
+const apiDocVersion = "1.0.0"
+
+// apiAccess 接口接受的认证方式
+type apiAccess int
+
+const (
+	accessPublic apiAccess = iota
+	accessSession
+	accessSessionOrToken
+)
+
+// apiRoute 描述一个接口，path 使用 gin 路由语法且不含 /api/v1 前缀。
+// 新增路由时必须在 apiRoutes 中登记，TestOpenAPICoversAllRoutes 核对路由是否一致，
+// TestOpenAPIAccessMatchesMiddleware 以不同身份请求每个接口，核对 access、role 与 scope 是否与实际挂载的中间件一致
+type apiRoute struct {
+	method   string
+	path     string
+	summary  string
+	tag      string
+	access   apiAccess
+	role     user.Role
+	scope    apitoken.Scope
+	query    []openapi.Parameter
+	request  any
+	status   int
+	response any
+	// stream 为 true 时响应为 SSE，每条 data 为一个 response 类型的 JSON
+	stream bool
+}
+
+var apiRoutes = []apiRoute{
+	{
+		method: http.MethodPost, path: "/login", summary: "用户名密码登录", tag: "auth",
+		request: handlers.LoginRequest{}, status: http.StatusOK, response: handlers.LoginResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/login/totp", summary: "登录第二步：校验两步验证码或恢复码", tag: "auth",
+		request: handlers.LoginTOTPRequest{}, status: http.StatusOK, response: handlers.LoginResponse{},
+	},
+	{
+		method: http.MethodGet, path: "/session", summary: "查询当前会话", tag: "auth",
+		status: http.StatusOK, response: handlers.SessionStatusResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/logout", summary: "登出", tag: "auth",
+		status: http.StatusOK, response: handlers.LogoutResponse{},
+	},
+	{
+		method: http.MethodGet, path: "/auth/methods", summary: "可用的登录方式", tag: "auth",
+		status: http.StatusOK, response: handlers.AuthMethodsResponse{},
+	},
+	{
+		method: http.MethodGet, path: "/auth/oidc/login", summary: "跳转到 OIDC 身份提供方登录", tag: "auth",
+		query:  []openapi.Parameter{queryParam("redirect", "登录成功后跳转的站内路径", "")},
+		status: http.StatusFound,
+	},
+	{
+		method: http.MethodGet, path: "/auth/oidc/callback", summary: "OIDC 登录回调", tag: "auth",
+		query: []openapi.Parameter{
+			queryParam("code", "授权码", ""),
+			queryParam("state", "登录请求的 state", ""),
+			queryParam("error", "身份提供方返回的错误", ""),
+			queryParam("error_description", "身份提供方返回的错误说明", ""),
+		},
+		status: http.StatusFound,
+	},
+	{
+		method: http.MethodGet, path: "/auth/magic", summary: "消费一次性登录链接", tag: "auth",
+		query:  []openapi.Parameter{queryParam("token", "登录链接令牌", "")},
+		status: http.StatusFound,
+	},
+	{
+		method: http.MethodGet, path: "/openapi.json", summary: "本接口文档", tag: "meta",
+		status: http.StatusOK, response: map[string]any{},
+	},
+	{
+		method: http.MethodGet, path: "/maintenance", summary: "当前维护状态", tag: "maintenance",
+		status: http.StatusOK, response: maintenance.PublicState{},
+	},
+	{
+		method: http.MethodGet, path: "/dashboard/stats", summary: "系统状态", tag: "dashboard",
+		access: accessSessionOrToken, role: user.RoleViewer, scope: apitoken.ScopeStatsRead,
+		status: http.StatusOK, response: handlers.SystemStatsResponse{},
+	},
+	{
+		method: http.MethodGet, path: "/logs/stream", summary: "实时日志流（SSE）", tag: "logs",
+		access: accessSessionOrToken, role: user.RoleOperator, scope: apitoken.ScopeLogsRead,
+		query:  []openapi.Parameter{queryParam("history", "为 0 时不推送历史日志", "")},
+		status: http.StatusOK, response: stream.LogEntry{}, stream: true,
+	},
+	{
+		method: http.MethodGet, path: "/logs/history", summary: "历史日志", tag: "logs",
+		access: accessSessionOrToken, role: user.RoleOperator, scope: apitoken.ScopeLogsRead,
+		status: http.StatusOK, response: handlers.LogsHistoryResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/account/totp/setup", summary: "开始两步验证注册", tag: "account",
+		access: accessSession, role: user.RoleViewer,
+		status: http.StatusOK, response: handlers.TOTPSetupResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/account/totp/confirm", summary: "确认启用两步验证", tag: "account",
+		access: accessSession, role: user.RoleViewer,
+		request: handlers.TOTPCodeRequest{}, status: http.StatusOK, response: handlers.TOTPConfirmResponse{},
+	},
+	{
+		method: http.MethodDelete, path: "/account/totp", summary: "关闭两步验证", tag: "account",
+		access: accessSession, role: user.RoleViewer,
+		request: handlers.TOTPCodeRequest{}, status: http.StatusNoContent,
+	},
+	{
+		method: http.MethodGet, path: "/account/tokens", summary: "个人访问令牌列表", tag: "account",
+		access: accessSession, role: user.RoleViewer,
+		status: http.StatusOK, response: handlers.TokenListResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/account/tokens", summary: "创建个人访问令牌", tag: "account",
+		access: accessSession, role: user.RoleViewer,
+		request: handlers.CreateTokenRequest{}, status: http.StatusCreated, response: handlers.CreateTokenResponse{},
+	},
+	{
+		method: http.MethodDelete, path: "/account/tokens/:id", summary: "吊销个人访问令牌", tag: "account",
+		access: accessSession, role: user.RoleViewer,
+		status: http.StatusNoContent,
+	},
+	{
+		method: http.MethodGet, path: "/maintenance/stream", summary: "维护状态推送（SSE）", tag: "maintenance",
+		access: accessSession, role: user.RoleViewer,
+		status: http.StatusOK, response: maintenance.State{}, stream: true,
+	},
+	{
+		method: http.MethodGet, path: "/users", summary: "用户列表", tag: "users",
+		access: accessSession, role: user.RoleAdmin,
+		status: http.StatusOK, response: handlers.UserListResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/users", summary: "创建用户", tag: "users",
+		access: accessSession, role: user.RoleAdmin,
+		request: handlers.CreateUserRequest{}, status: http.StatusCreated, response: user.User{},
+	},
+	{
+		method: http.MethodPatch, path: "/users/:id", summary: "更新用户", tag: "users",
+		access: accessSession, role: user.RoleAdmin,
+		request: handlers.UpdateUserRequest{}, status: http.StatusOK, response: user.User{},
+	},
+	{
+		method: http.MethodDelete, path: "/users/:id", summary: "删除用户", tag: "users",
+		access: accessSession, role: user.RoleAdmin,
+		status: http.StatusNoContent,
+	},
+	{
+		method: http.MethodDelete, path: "/users/:id/totp", summary: "重置用户的两步验证", tag: "users",
+		access: accessSession, role: user.RoleAdmin,
+		status: http.StatusNoContent,
+	},
+	{
+		method: http.MethodGet, path: "/audit", summary: "查询审计日志", tag: "audit",
+		access: accessSession, role: user.RoleAdmin,
+		query: []openapi.Parameter{
+			queryParam("from", "起始时间，RFC 3339 或 Unix 秒", ""),
+			queryParam("to", "结束时间，RFC 3339 或 Unix 秒", ""),
+			queryParam("actor", "操作者用户名", ""),
+			queryParam("action", "事件类型", ""),
+			queryParam("cursor", "上一页返回的 next_cursor", ""),
+			queryParam("limit", fmt.Sprintf("每页条数，默认 %d，最大 %d", audit.DefaultPageSize, audit.MaxPageSize), "integer"),
+		},
+		status: http.StatusOK, response: audit.Page{},
+	},
+	{
+		method: http.MethodGet, path: "/audit/verify", summary: "校验审计哈希链", tag: "audit",
+		access: accessSession, role: user.RoleAdmin,
+		status: http.StatusOK, response: audit.VerifyResult{},
+	},
+	{
+		method: http.MethodPut, path: "/maintenance", summary: "开启或关闭维护模式", tag: "maintenance",
+		access: accessSession, role: user.RoleAdmin,
+		request: handlers.UpdateMaintenanceRequest{}, status: http.StatusOK, response: maintenance.State{},
+	},
+}
+
+func queryParam(name, description, typ string) openapi.Parameter {
+	if typ == "" {
+		typ = "string"
+	}
+	return openapi.Parameter{Name: name, In: "query", Description: description, Schema: &openapi.Schema{Type: typ}}
+}
+
+// newOpenAPIDocument 由 apiRoutes 与处理器的请求、响应类型生成 OpenAPI 文档
+func newOpenAPIDocument() *openapi.Document {
+	gen := openapi.NewGenerator()
+	openapi.Enum(gen, user.RoleAdmin, user.RoleOperator, user.RoleViewer)
+	openapi.Enum(gen, apitoken.ScopeStatsRead, apitoken.ScopeLogsRead)
+	openapi.Enum(gen, audit.OutcomeSuccess, audit.OutcomeFailure)
+	openapi.Enum(gen, apierror.Codes()...)
+	openapi.Name[apierror.Response](gen, "ErrorResponse")
+	openapi.Name[apierror.Body](gen, "Error")
+	openapi.Name[audit.Page](gen, "AuditPage")
+	openapi.Name[audit.Event](gen, "AuditEvent")
+	openapi.Name[audit.VerifyResult](gen, "AuditVerifyResult")
+	openapi.Name[maintenance.State](gen, "MaintenanceState")
+	openapi.Name[maintenance.PublicState](gen, "MaintenancePublicState")
+
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:   "Vue-Go Session API",
+			Version: apiDocVersion,
+			Description: "错误响应统一为 ErrorResponse，message 按 Accept-Language 本地化。" +
+				"使用会话 Cookie 的写请求需在 " + session.CSRFHeaderName + " 头中携带 /session 下发的 CSRF 令牌。" +
+				"维护模式下非管理员的写请求返回 503 maintenance 与 Retry-After。",
+		},
+		Servers: []openapi.Server{
+			{URL: apiBasePath + "/" + currentAPIVersion},
+			{URL: apiBasePath, Description: "v1 的别名，已弃用，响应带 Deprecation 与 Sunset 头"},
+		},
+		Paths: make(map[string]*openapi.PathItem),
+	}
+	errorResponse := &openapi.Response{
+		Description: "错误",
+		Content:     openapi.JSON(gen.Schema(apierror.Response{}, openapi.ForResponse)),
+	}
+
+	for _, route := range apiRoutes {
+		path, params := openAPIPath(route.path)
+		op := &openapi.Operation{
+			OperationID: operationID(route.method, route.path),
+			Summary:     route.summary,
+			Tags:        []string{route.tag},
+			Parameters:  append(params, route.query...),
+			Responses:   map[string]*openapi.Response{"default": errorResponse},
+			Security:    securityFor(route),
+		}
+		if route.role != "" {
+			op.Description = "需要 " + string(route.role) + " 及以上角色"
+		}
+		if route.request != nil {
+			op.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content:  openapi.JSON(gen.Schema(route.request, openapi.ForRequest)),
+			}
+		}
+		op.Responses[fmt.Sprint(route.status)] = successResponse(gen, route)
+
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &openapi.PathItem{}
+			doc.Paths[path] = item
+		}
+		(*item)[strings.ToLower(route.method)] = op
+	}
+
+	doc.Components = openapi.Components{
+		Schemas: gen.Components(),
+		SecuritySchemes: map[string]*openapi.SecurityScheme{
+			"sessionCookie": {
+				Type:        "apiKey",
+				In:          "cookie",
+				Name:        session.SessionCookieName,
+				Description: "登录后下发的会话 Cookie",
+			},
+			"accessToken": {
+				Type:        "http",
+				Scheme:      "bearer",
+				Description: "个人访问令牌，只能访问令牌 scope 覆盖的只读接口",
+			},
+		},
+	}
+	return doc
+}
+
+func successResponse(gen *openapi.Generator, route apiRoute) *openapi.Response {
+	resp := &openapi.Response{Description: http.StatusText(route.status)}
+	switch {
+	case route.status == http.StatusFound:
+		resp.Headers = map[string]openapi.Header{
+			"Location": {Description: "跳转地址，失败时跳转到登录页并带 error 参数", Schema: &openapi.Schema{Type: "string"}},
+		}
+	case route.stream:
+		resp.Content = map[string]openapi.MediaType{
+			"text/event-stream": {Schema: gen.Schema(route.response, openapi.ForResponse)},
+		}
+	case route.response != nil:
+		resp.Content = openapi.JSON(gen.Schema(route.response, openapi.ForResponse))
+	}
+	return resp
+}
+
+func securityFor(route apiRoute) []openapi.SecurityRequirement {
+	switch route.access {
+	case accessSession:
+		return []openapi.SecurityRequirement{{"sessionCookie": {}}}
+	case accessSessionOrToken:
+		return []openapi.SecurityRequirement{
+			{"sessionCookie": {}},
+			{"accessToken": {string(route.scope)}},
+		}
+	}
+	return nil
+}
+
+// openAPIPath 将 gin 路由中的 :name 参数转换为 OpenAPI 的 {name}，并生成对应的路径参数；当前路径参数均为整数 ID
+func openAPIPath(ginPath string) (string, []openapi.Parameter) {
+	segments := strings.Split(ginPath, "/")
+	var params []openapi.Parameter
+	for i, segment := range segments {
+		name, ok := strings.CutPrefix(segment, ":")
+		if !ok {
+			continue
+		}
+		segments[i] = "{" + name + "}"
+		params = append(params, openapi.Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &openapi.Schema{Type: "integer", Format: "int64"},
+		})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID 由方法与路径生成，如 DELETE /users/:id/totp → deleteUsersIdTotp
+func operationID(method, ginPath string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(ginPath, func(r rune) bool {
+		return r == '/' || r == ':' || r == '.' || r == '_'
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// openAPIHandler 返回启动时生成的文档，内容在进程生命周期内不变
+func openAPIHandler() gin.HandlerFunc {
+	body, err := json.Marshal(newOpenAPIDocument())
+	if err != nil {
+		panic(fmt.Errorf("server: marshal openapi document: %w", err))
+	}
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+	}
+}
@@ -0,0 +1,307 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apierror"
+	"main/internal/apitoken"
+	"main/internal/config"
+	"main/internal/database"
+	"main/internal/handlers"
+	"main/internal/maintenance"
+	"main/internal/openapi"
+	"main/internal/session"
+	"main/internal/stream"
+	"main/internal/user"
+)
+
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+
+	r, _, err := openTestRouter(t, &config.Config{})
+	if err != nil {
+		t.Fatalf("new router: %v", err)
+	}
+	return r
+}
+
+// openTestRouter 以独立数据库与 DATA_DIR 创建路由，cfg 中未设置的 DataDir 与 AuthKey 由测试填充
+func openTestRouter(t *testing.T, cfg *config.Config) (*gin.Engine, *database.DBContainer, error) {
+	t.Helper()
+
+	dbContainer, err := database.Open(t.Context(), database.Options{
+		Path: filepath.Join(t.TempDir(), "data.db"),
+	})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	t.Cleanup(func() { _ = dbContainer.Close() })
+
+	cfg.DataDir = t.TempDir()
+	cfg.AuthKey = "openapi-test-key"
+	mode, err := maintenance.Open(cfg.DataDir)
+	if err != nil {
+		t.Fatalf("open maintenance mode: %v", err)
+	}
+	r, err := NewRouter(cfg, dbContainer, stream.NewLogBroadcaster(), mode, 1, embed.FS{})
+	return r, dbContainer, err
+}
+
+func fetchOpenAPIDocument(t *testing.T, r http.Handler) *openapi.Document {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		t.Fatalf("expected JSON document, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	var doc openapi.Document
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decode openapi document: %v", err)
+	}
+	return &doc
+}
+
+func TestOpenAPICoversAllRoutes(t *testing.T) {
+	r := newTestRouter(t)
+	doc := fetchOpenAPIDocument(t, r)
+
+	documented := make(map[string]bool)
+	for path, item := range doc.Paths {
+		for method := range *item {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	versionPrefix := apiBasePath + "/" + currentAPIVersion
+	versioned := make(map[string]bool)
+	for _, route := range r.Routes() {
+		path, ok := strings.CutPrefix(route.Path, versionPrefix)
+		if !ok {
+			continue
+		}
+		path, _ = openAPIPath(path)
+		key := route.Method + " " + path
+		versioned[route.Method+" "+route.Path] = true
+		if !documented[key] {
+			t.Errorf("route %s %s is missing from the OpenAPI document, add it to apiRoutes", route.Method, route.Path)
+		}
+		delete(documented, key)
+	}
+	for key := range documented {
+		t.Errorf("OpenAPI document lists %s but no such route is registered", key)
+	}
+
+	// 无版本号的 /api 别名必须与 v1 一一对应
+	for _, route := range r.Routes() {
+		path, ok := strings.CutPrefix(route.Path, apiBasePath)
+		// 预检路由只服务于 CORS 中间件，不属于业务接口
+		if !ok || strings.HasPrefix(route.Path, versionPrefix) || route.Method == http.MethodOptions {
+			continue
+		}
+		key := route.Method + " " + versionPrefix + path
+		if !versioned[key] {
+			t.Errorf("alias route %s %s has no %s counterpart", route.Method, route.Path, currentAPIVersion)
+		}
+		delete(versioned, key)
+	}
+	for key := range versioned {
+		t.Errorf("route %s has no unversioned alias", key)
+	}
+}
+
+// probeCredential 探测请求携带的身份：会话 Cookie 与对应的 CSRF 令牌，或 Bearer 令牌
+type probeCredential struct {
+	cookie *http.Cookie
+	csrf   string
+	bearer string
+}
+
+// probe 以 cred 请求 route 的 v1 路径，返回状态码与错误码。
+// 请求带超时，通过认证的 SSE 接口在超时后结束
+func probe(t *testing.T, r http.Handler, route apiRoute, cred probeCredential) (int, apierror.Code) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	path := strings.ReplaceAll(route.path, ":id", "999999")
+	req := httptest.NewRequestWithContext(ctx, route.method, apiBasePath+"/"+currentAPIVersion+path, nil)
+	if cred.cookie != nil {
+		req.AddCookie(cred.cookie)
+		req.Header.Set(session.CSRFHeaderName, cred.csrf)
+	}
+	if cred.bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+cred.bearer)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var body apierror.Response
+	_ = json.Unmarshal(w.Body.Bytes(), &body)
+	return w.Code, body.Error.Code
+}
+
+// loginProbeUser 创建 role 角色的用户并登录，返回会话 Cookie 与 CSRF 令牌
+func loginProbeUser(t *testing.T, r http.Handler, users *user.Store, role user.Role) probeCredential {
+	t.Helper()
+
+	const password = "probe-password-123"
+	username := "probe-" + string(role)
+	if _, err := users.Create(t.Context(), username, password, role); err != nil {
+		t.Fatalf("create %s user: %v", role, err)
+	}
+	body, _ := json.Marshal(handlers.LoginRequest{Username: username, Password: password})
+	req := httptest.NewRequest(http.MethodPost, apiBasePath+"/"+currentAPIVersion+"/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("login as %s: %d %s", role, w.Code, w.Body.String())
+	}
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == session.SessionCookieName {
+			return probeCredential{cookie: cookie, csrf: w.Header().Get(session.CSRFHeaderName)}
+		}
+	}
+	t.Fatalf("login as %s returned no session cookie", role)
+	return probeCredential{}
+}
+
+// TestOpenAPIAccessMatchesMiddleware 以不同身份请求每个已登记的接口，
+// 核对文档中的 access、role 与 scope 与实际挂载的认证、角色和 scope 中间件一致
+func TestOpenAPIAccessMatchesMiddleware(t *testing.T) {
+	r, dbContainer, err := openTestRouter(t, &config.Config{MaxRequestBodyBytes: 1 << 20})
+	if err != nil {
+		t.Fatalf("new router: %v", err)
+	}
+	users := user.NewStore(dbContainer.DB())
+	tokens := apitoken.NewStore(dbContainer.DB())
+
+	roles := []user.Role{user.RoleViewer, user.RoleOperator, user.RoleAdmin}
+	sessions := make(map[user.Role]probeCredential, len(roles))
+	for _, role := range roles {
+		sessions[role] = loginProbeUser(t, r, users, role)
+	}
+	admin, err := users.GetByUsername(t.Context(), "probe-admin")
+	if err != nil {
+		t.Fatalf("load admin: %v", err)
+	}
+	var scopes []apitoken.Scope
+	for _, route := range apiRoutes {
+		if route.scope != "" && !slices.Contains(scopes, route.scope) {
+			scopes = append(scopes, route.scope)
+		}
+	}
+	// newToken 以 admin 身份签发令牌，所有者角色不会限制 scope
+	newToken := func(include func(apitoken.Scope) bool) probeCredential {
+		var names []string
+		for _, scope := range scopes {
+			if include(scope) {
+				names = append(names, string(scope))
+			}
+		}
+		_, raw, err := tokens.Create(t.Context(), admin, apitoken.CreateParams{Name: "probe", Scopes: names})
+		if err != nil {
+			t.Fatalf("create token %v: %v", names, err)
+		}
+		return probeCredential{bearer: raw}
+	}
+	allScopes := newToken(func(apitoken.Scope) bool { return true })
+
+	rejected := func(code apierror.Code) bool {
+		switch code {
+		case apierror.CodeUnauthorized, apierror.CodeInvalidAccessToken, apierror.CodeForbidden, apierror.CodeInsufficientScope:
+			return true
+		}
+		return false
+	}
+
+	for _, route := range apiRoutes {
+		name := route.method + " " + route.path
+
+		// 无效的 Bearer 令牌只会被认证中间件拒绝，公开接口不挂载认证中间件
+		_, code := probe(t, r, route, probeCredential{bearer: "invalid"})
+		if route.access == accessPublic {
+			if code == apierror.CodeInvalidAccessToken {
+				t.Errorf("%s is documented as public but requires authentication", name)
+			}
+			continue
+		}
+		if code != apierror.CodeInvalidAccessToken {
+			t.Errorf("%s is documented as authenticated but accepted an invalid token (%s)", name, code)
+		}
+
+		// 文档角色可以访问，低一级的角色被拒绝
+		roleIndex := slices.Index(roles, route.role)
+		if roleIndex < 0 {
+			t.Errorf("%s documents unknown role %q", name, route.role)
+			continue
+		}
+		if status, code := probe(t, r, route, sessions[route.role]); rejected(code) {
+			t.Errorf("%s is documented for role %s but %s was rejected: %d %s", name, route.role, route.role, status, code)
+		}
+		if roleIndex > 0 {
+			lower := roles[roleIndex-1]
+			if _, code := probe(t, r, route, sessions[lower]); code != apierror.CodeForbidden {
+				t.Errorf("%s is documented for role %s but %s got %q instead of forbidden", name, route.role, lower, code)
+			}
+		}
+
+		switch route.access {
+		case accessSession:
+			if _, code := probe(t, r, route, allScopes); code != apierror.CodeInvalidAccessToken {
+				t.Errorf("%s is documented as session-only but accepted a token (%s)", name, code)
+			}
+		case accessSessionOrToken:
+			if route.scope == "" {
+				t.Errorf("%s accepts tokens but documents no scope", name)
+				continue
+			}
+			only := newToken(func(scope apitoken.Scope) bool { return scope == route.scope })
+			if status, code := probe(t, r, route, only); rejected(code) {
+				t.Errorf("%s is documented for scope %s but a %s token was rejected: %d %s", name, route.scope, route.scope, status, code)
+			}
+			others := newToken(func(scope apitoken.Scope) bool { return scope != route.scope })
+			if _, code := probe(t, r, route, others); code != apierror.CodeInsufficientScope {
+				t.Errorf("%s is documented for scope %s but a token without it got %q", name, route.scope, code)
+			}
+		}
+	}
+}
+
+func TestOpenAPIIncludesValidationConstraints(t *testing.T) {
+	doc := fetchOpenAPIDocument(t, newTestRouter(t))
+
+	stats := doc.Components.Schemas["SystemStatsResponse"]
+	if stats == nil {
+		t.Fatal("SystemStatsResponse schema missing")
+	}
+	percent := stats.Properties["memory_percent"]
+	if percent.Minimum == nil || *percent.Minimum != 0 || percent.Maximum == nil || *percent.Maximum != 100 {
+		t.Fatalf("memory_percent must be constrained to 0-100, got %+v", percent)
+	}
+
+	createUser := doc.Components.Schemas["CreateUserRequest"]
+	if createUser == nil || len(createUser.Required) != 3 || len(createUser.Properties["role"].Enum) != 3 {
+		t.Fatalf("CreateUserRequest must require all fields and enumerate roles, got %+v", createUser)
+	}
+
+	errorBody := doc.Components.Schemas["Error"]
+	if errorBody == nil || len(errorBody.Properties["code"].Enum) == 0 {
+		t.Fatalf("error schema must enumerate error codes, got %+v", errorBody)
+	}
+}
@@ -2,10 +2,12 @@ package server
 
 import (
 	"embed"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/gin-contrib/sessions"
@@ -13,25 +15,67 @@ import (
 	gorillasessions "github.com/gorilla/sessions"
 	sloggin "github.com/samber/slog-gin"
 
+	"main/internal/apierror"
+	"main/internal/apitoken"
+	"main/internal/audit"
 	"main/internal/config"
+	"main/internal/database"
 	"main/internal/handlers"
+	"main/internal/loginlink"
+	"main/internal/maintenance"
 	"main/internal/middleware"
+	"main/internal/mtls"
+	"main/internal/oidc"
+	"main/internal/proxyauth"
+	"main/internal/ratelimit"
+	"main/internal/requestid"
 	"main/internal/session"
 	"main/internal/stream"
+	"main/internal/user"
 )
 
+// NewRouter 创建完整的路由；已启用的认证方式无法初始化时返回错误，不能在认证方式缺失的情况下启动
 func NewRouter(
 	cfg *config.Config,
+	dbContainer *database.DBContainer,
 	logBroadcaster *stream.LogBroadcaster,
+	maintenanceMode *maintenance.Mode,
 	startTime int64,
 	distFS embed.FS,
-) *gin.Engine {
-	authHandler := handlers.NewAuthHandler(cfg.AuthKey, cfg.CookieSecure)
+) (*gin.Engine, error) {
+	users := user.NewStore(dbContainer.DB())
+	tokens := apitoken.NewStore(dbContainer.DB())
+	auditLog := audit.NewStore(dbContainer.DB())
+	proxyAuth, err := newProxyAuthenticator(cfg)
+	if err != nil {
+		return nil, err
+	}
+	certAuth, err := newCertAuthenticator(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cookies := newCookiePolicy(cfg)
+	authHandler := handlers.NewAuthHandler(users, auditLog, cookies)
 	logsHandler := handlers.NewLogsHandler(logBroadcaster)
-	systemHandler := handlers.NewSystemHandler(startTime)
+	panics := middleware.NewPanicCounter()
+	systemHandler := handlers.NewSystemHandler(startTime, panics)
+	userHandler := handlers.NewUserHandler(users, auditLog)
+	totpHandler := handlers.NewTOTPHandler(users, auditLog, cfg.TOTPIssuer)
+	oidcHandler := handlers.NewOIDCHandler(authHandler, users, newOIDCProvider(cfg))
+	tokenHandler := handlers.NewTokenHandler(users, tokens, auditLog)
+	loginLinkHandler := handlers.NewLoginLinkHandler(authHandler, users, loginlink.NewStore(dbContainer.DB(), cfg.DataDir))
+	auditHandler := handlers.NewAuditHandler(auditLog, audit.NewCheckpoints(cfg.DataDir))
+	maintenanceHandler := handlers.NewMaintenanceHandler(maintenanceMode, auditLog)
 
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.New()
+	// 仅信任 TRUSTED_PROXIES 中代理的 X-Forwarded-For，未配置时 ClientIP 即直连地址
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("server: failed to set trusted proxies: %w", err)
+	}
+	// 请求 ID 由 requestid 中间件生成并以自定义属性写入访问日志，不使用 slog-gin 自带的 ID
+	r.Use(requestid.Middleware())
 	httpLogConfig := sloggin.DefaultConfig()
 	httpLogConfig.WithRequestID = false
 	if cfg.DisableStaticAssetLogs {
@@ -40,26 +84,188 @@ func NewRouter(
 		})
 	}
 	r.Use(sloggin.NewWithConfig(slog.Default().WithGroup("http"), httpLogConfig))
-	r.Use(gin.Recovery())
+	r.Use(middleware.Recovery(panics))
+	r.Use(middleware.SecurityHeaders(newSecurityHeadersConfig(cfg)))
 	r.Use(sessions.Sessions(session.SessionCookieName, newSessionStore(cfg)))
 
-	api := r.Group("/api")
+	base := r.Group(apiBasePath)
+	if cfg.CompressionEnabled {
+		// 最先包装 Writer，限流、CORS 等中间件直接返回的错误响应同样按协商结果压缩
+		base.Use(middleware.Compress(middleware.CompressConfig{
+			Encodings: cfg.CompressionEncodings,
+			MinSize:   cfg.CompressionMinSize,
+		}))
+	}
+	base.Use(middleware.CORS(cfg.CORSAllowedOrigins, cfg.CORSMaxAge))
+	base.Use(middleware.RateLimit(
+		ratelimit.New(cfg.RateLimitAPIIP, cfg.RateLimitMaxClients),
+		ratelimit.New(cfg.RateLimitAPI, cfg.RateLimitMaxClients),
+	))
+	base.Use(middleware.BodyLimit(cfg.MaxRequestBodyBytes))
+	base.Use(middleware.CSRF(append(slices.Clone(cfg.CSRFTrustedOrigins), cfg.CORSAllowedOrigins...)))
+	// 预检请求由 CORS 中间件应答，这里只为其提供可匹配的路由，覆盖全部版本前缀
+	base.OPTIONS("/*path", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	api := newAPIRouter(base)
 	{
-		api.POST("/login", authHandler.Login)
-		api.GET("/session", authHandler.Session)
+		// 登录类接口共享更严格的限额，限制暴力尝试；只按 IP 计数，重新登录换取新会话不会得到新的额度
+		authLimit := middleware.RateLimit(ratelimit.New(cfg.RateLimitAuth, cfg.RateLimitMaxClients), nil)
+		api.POST("/login", authLimit, authHandler.Login)
+		api.POST("/login/totp", authLimit, authHandler.LoginTOTP)
+		api.GET("/session", middleware.ProxySession(users, proxyAuth, auditLog, cookies), authHandler.Session)
 		api.POST("/logout", authHandler.Logout)
+		api.GET("/auth/methods", oidcHandler.Methods)
+		api.GET("/auth/oidc/login", authLimit, oidcHandler.Login)
+		api.GET("/auth/oidc/callback", authLimit, oidcHandler.Callback)
+		api.GET("/auth/magic", authLimit, loginLinkHandler.Consume)
+		api.GET("/openapi.json", openAPIHandler())
+		api.GET("/maintenance", maintenanceHandler.GetStatus)
 
+		// 只读接口同时接受个人访问令牌，令牌请求需具备对应 scope
+		scoped := api.Group("")
+		scoped.Use(middleware.AuthMiddleware(users, middleware.AuthOptions{
+			Tokens:  tokens,
+			Proxy:   proxyAuth,
+			Certs:   certAuth,
+			Audit:   auditLog,
+			Cookies: cookies,
+		}))
+		scoped.Use(middleware.Maintenance(maintenanceMode))
+		{
+			scoped.GET(
+				"/dashboard/stats",
+				middleware.RequireRole(user.RoleViewer),
+				middleware.RequireScope(apitoken.ScopeStatsRead),
+				middleware.RateLimit(nil, ratelimit.New(cfg.RateLimitStats, cfg.RateLimitMaxClients)),
+				systemHandler.GetStats,
+			)
+			scoped.GET(
+				"/logs/stream",
+				middleware.RequireRole(user.RoleOperator),
+				middleware.RequireScope(apitoken.ScopeLogsRead),
+				logsHandler.StreamLogs,
+			)
+			scoped.GET(
+				"/logs/history",
+				middleware.RequireRole(user.RoleOperator),
+				middleware.RequireScope(apitoken.ScopeLogsRead),
+				logsHandler.GetHistory,
+			)
+		}
+
+		// 其余接口仅接受会话 Cookie。维护模式只拦截认证后的写请求，登录登出不受影响，管理员才能登录后关闭维护模式
 		authenticated := api.Group("")
-		authenticated.Use(middleware.AuthMiddleware(cfg.CookieSecure))
+		authenticated.Use(middleware.AuthMiddleware(users, middleware.AuthOptions{
+			Proxy:   proxyAuth,
+			Certs:   certAuth,
+			Audit:   auditLog,
+			Cookies: cookies,
+		}))
+		authenticated.Use(middleware.Maintenance(maintenanceMode))
+
+		viewer := authenticated.Group("")
+		viewer.Use(middleware.RequireRole(user.RoleViewer))
 		{
-			authenticated.GET("/dashboard/stats", systemHandler.GetStats)
-			authenticated.GET("/logs/stream", logsHandler.StreamLogs)
-			authenticated.GET("/logs/history", logsHandler.GetHistory)
+			viewer.POST("/account/totp/setup", totpHandler.Setup)
+			viewer.POST("/account/totp/confirm", totpHandler.Confirm)
+			viewer.DELETE("/account/totp", totpHandler.Disable)
+			viewer.GET("/account/tokens", tokenHandler.ListTokens)
+			viewer.POST("/account/tokens", tokenHandler.CreateToken)
+			viewer.DELETE("/account/tokens/:id", tokenHandler.RevokeToken)
+			viewer.GET("/maintenance/stream", maintenanceHandler.StreamStatus)
 		}
+
+		admin := authenticated.Group("")
+		admin.Use(middleware.RequireRole(user.RoleAdmin))
+		{
+			admin.GET("/users", userHandler.ListUsers)
+			admin.POST("/users", userHandler.CreateUser)
+			admin.PATCH("/users/:id", userHandler.UpdateUser)
+			admin.DELETE("/users/:id", userHandler.DeleteUser)
+			admin.DELETE("/users/:id/totp", userHandler.ResetTOTP)
+			admin.GET("/audit", auditHandler.ListEvents)
+			admin.GET("/audit/verify", auditHandler.Verify)
+			admin.PUT("/maintenance", maintenanceHandler.Update)
+		}
+	}
+
+	r.NoRoute(apiNotFound, spaHandler(distFS))
+	return r, nil
+}
+
+// newCookiePolicy 按配置生成会话 Cookie 策略，COOKIE_SAMESITE 已在加载配置时校验
+func newCookiePolicy(cfg *config.Config) session.CookiePolicy {
+	sameSite, _ := config.ParseSameSite(cfg.CookieSameSite)
+	return session.CookiePolicy{Secure: cfg.CookieSecure, SameSite: sameSite}
+}
+
+// newSecurityHeadersConfig 只有通过 HTTPS 访问（直接 TLS 或反向代理终止 TLS 并启用 Secure Cookie）时才发送 HSTS，
+// 避免纯 HTTP 部署被浏览器强制升级后无法访问
+func newSecurityHeadersConfig(cfg *config.Config) middleware.SecurityHeadersConfig {
+	headers := middleware.SecurityHeadersConfig{
+		HSTSIncludeSubdomains: cfg.HSTSIncludeSubdomains,
+		FrameAncestors:        cfg.FrameAncestors,
+		ReferrerPolicy:        cfg.ReferrerPolicy,
+		PermissionsPolicy:     cfg.PermissionsPolicy,
+		ContentSecurityPolicy: cfg.ContentSecurityPolicy,
 	}
+	if cfg.TLSCertFile != "" || cfg.CookieSecure {
+		headers.HSTSMaxAge = cfg.HSTSMaxAge
+	}
+	return headers
+}
+
+// newProxyAuthenticator 未启用代理头认证时返回 nil，身份请求头一律被忽略
+func newProxyAuthenticator(cfg *config.Config) (*proxyauth.Authenticator, error) {
+	if !cfg.ProxyAuthEnabled {
+		return nil, nil
+	}
+	authenticator, err := proxyauth.New(proxyauth.Config{
+		TrustedProxies: cfg.TrustedProxies,
+		UserHeader:     cfg.ProxyAuthUserHeader,
+		EmailHeader:    cfg.ProxyAuthEmailHeader,
+		DefaultRole:    user.Role(cfg.ProxyAuthDefaultRole),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("server: failed to initialize proxy authentication: %w", err)
+	}
+	return authenticator, nil
+}
 
-	r.NoRoute(spaHandler(distFS))
-	return r
+// newCertAuthenticator 未配置客户端 CA 时返回 nil，请求不会携带已校验的客户端证书
+func newCertAuthenticator(cfg *config.Config) (*mtls.Authenticator, error) {
+	if cfg.TLSClientCAFile == "" {
+		return nil, nil
+	}
+	authenticator, err := mtls.New(mtls.Config{
+		UsernameField: cfg.MTLSUsernameField,
+		DefaultRole:   user.Role(cfg.MTLSDefaultRole),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("server: failed to initialize client certificate authentication: %w", err)
+	}
+	return authenticator, nil
+}
+
+// newOIDCProvider 未启用 OIDC 时返回 nil，对应路由将返回 404
+func newOIDCProvider(cfg *config.Config) *oidc.Provider {
+	if !cfg.OIDCEnabled {
+		return nil
+	}
+
+	return oidc.NewProvider(oidc.Config{
+		IssuerURL:      cfg.OIDCIssuerURL,
+		ClientID:       cfg.OIDCClientID,
+		ClientSecret:   cfg.OIDCClientSecret,
+		RedirectURL:    cfg.OIDCRedirectURL,
+		Scopes:         cfg.OIDCScopes,
+		UsernameClaim:  cfg.OIDCUsernameClaim,
+		GroupsClaim:    cfg.OIDCGroupsClaim,
+		AdminGroups:    cfg.OIDCAdminGroups,
+		OperatorGroups: cfg.OIDCOperatorGroups,
+		ViewerGroups:   cfg.OIDCViewerGroups,
+		DefaultRole:    user.Role(cfg.OIDCDefaultRole),
+	})
 }
 
 func newSessionStore(cfg *config.Config) sessions.Store {
@@ -71,13 +277,7 @@ func newSessionStore(cfg *config.Config) sessions.Store {
 	store := gorillasessions.NewFilesystemStore(sessionDir, []byte(cfg.AuthKey))
 
 	wrappedStore := &filesystemSessionStore{FilesystemStore: store}
-	wrappedStore.Options(sessions.Options{
-		Path:     "/",
-		MaxAge:   session.SessionMaxAgeSeconds,
-		HttpOnly: true,
-		Secure:   cfg.CookieSecure,
-		SameSite: http.SameSiteLaxMode,
-	})
+	wrappedStore.Options(newCookiePolicy(cfg).Options(session.SessionMaxAgeSeconds))
 
 	return wrappedStore
 }
@@ -125,3 +325,10 @@ func shouldSkipStaticAssetAccessLog(path string) bool {
 
 	return false
 }
+
+// apiNotFound 未匹配的 /api 路径返回 JSON 错误，而不是回退到前端页面
+func apiNotFound(c *gin.Context) {
+	if path := c.Request.URL.Path; path == apiBasePath || strings.HasPrefix(path, apiBasePath+"/") {
+		apierror.Respond(c, apierror.CodeNotFound)
+	}
+}
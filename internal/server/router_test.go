@@ -1,6 +1,10 @@
 package server
 
-import "testing"
+import (
+	"testing"
+
+	"main/internal/config"
+)
 
 func TestShouldSkipStaticAssetAccessLog(t *testing.T) {
 	tests := []struct {
@@ -26,3 +30,47 @@ func TestShouldSkipStaticAssetAccessLog(t *testing.T) {
 		})
 	}
 }
+
+func TestNewSecurityHeadersConfigSendsHSTSOnlyOverHTTPS(t *testing.T) {
+	cfg := &config.Config{HSTSMaxAge: 3600, FrameAncestors: "'self'"}
+	if got := newSecurityHeadersConfig(cfg); got.HSTSMaxAge != 0 || got.FrameAncestors != "'self'" {
+		t.Fatalf("expected no HSTS over plain HTTP, got %+v", got)
+	}
+
+	cfg.CookieSecure = true
+	if got := newSecurityHeadersConfig(cfg); got.HSTSMaxAge != 3600 {
+		t.Fatalf("expected HSTS behind TLS-terminating proxy, got %+v", got)
+	}
+
+	cfg.CookieSecure = false
+	cfg.TLSCertFile = "server.crt"
+	if got := newSecurityHeadersConfig(cfg); got.HSTSMaxAge != 3600 {
+		t.Fatalf("expected HSTS with direct TLS, got %+v", got)
+	}
+}
+
+func TestNewRouterFailsWhenProxyAuthCannotStart(t *testing.T) {
+	// 只含空白项的 TRUSTED_PROXIES 能通过配置校验，但无法解析出任何可信代理
+	cfg := &config.Config{
+		ProxyAuthEnabled:     true,
+		TrustedProxies:       []string{" "},
+		ProxyAuthDefaultRole: "viewer",
+	}
+	if _, _, err := openTestRouter(t, cfg); err == nil {
+		t.Fatal("expected router to fail when proxy authentication cannot be initialized")
+	}
+}
+
+func TestNewRouterFailsWhenTrustedProxiesAreInvalid(t *testing.T) {
+	cfg := &config.Config{TrustedProxies: []string{" "}}
+	if _, _, err := openTestRouter(t, cfg); err == nil {
+		t.Fatal("expected router to fail when trusted proxies cannot be applied")
+	}
+}
+
+func TestNewRouterFailsWhenCertAuthCannotStart(t *testing.T) {
+	cfg := &config.Config{TLSClientCAFile: "client-ca.crt", MTLSUsernameField: "uid"}
+	if _, _, err := openTestRouter(t, cfg); err == nil {
+		t.Fatal("expected router to fail when client certificate authentication cannot be initialized")
+	}
+}
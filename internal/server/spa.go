@@ -1,24 +1,34 @@
 package server
 
 import (
-	"embed"
+	"bytes"
+	"compress/gzip"
 	"io"
 	"io/fs"
+	"log/slog"
 	"mime"
 	"net/http"
 	"path/filepath"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	"main/internal/middleware"
 )
 
-// spaHandler 实现支持预压缩文件的 SPA 路由逻辑
-func spaHandler(distFS embed.FS) gin.HandlerFunc {
+// spaHandler 实现支持预压缩文件的 SPA 路由逻辑。
+// index.html 每次响应都要写入当前请求的 CSP nonce，因此不直接发送预压缩文件，而是渲染后原样返回
+func spaHandler(distFS fs.FS) gin.HandlerFunc {
 	subFS, err := fs.Sub(distFS, "web/dist")
 	if err != nil {
 		panic(err)
 	}
 
+	index, err := loadIndexTemplate(subFS)
+	if err != nil {
+		slog.Warn("failed to load index.html, SPA routes will return 404", "error", err)
+	}
+
 	// 压缩格式优先级：br > gzip
 	compressionFormats := []struct {
 		encoding string
@@ -30,8 +40,9 @@ func spaHandler(distFS embed.FS) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 		path := strings.TrimPrefix(c.Request.URL.Path, "/")
-		if path == "" {
-			path = "index.html"
+		if path == "" || path == "index.html" {
+			serveIndex(c, index)
+			return
 		}
 
 		ae := c.GetHeader("Accept-Encoding")
@@ -50,16 +61,8 @@ func spaHandler(distFS embed.FS) gin.HandlerFunc {
 
 		// SPA Fallback
 		if filepath.Ext(path) == "" || filepath.Ext(path) == ".html" {
-			// 对 index.html 尝试压缩版本
-			for _, cf := range compressionFormats {
-				if strings.Contains(ae, cf.encoding) && tryServeFile(c, subFS, "index.html"+cf.ext, cf.encoding) {
-					return
-				}
-			}
-			// 最终回退到 gzip 版本的 index.html
-			if tryServeFile(c, subFS, "index.html.gz", "gzip") {
-				return
-			}
+			serveIndex(c, index)
+			return
 		}
 
 		c.Status(http.StatusNotFound)
@@ -116,3 +119,68 @@ func tryServeFile(c *gin.Context, subFS fs.FS, filePath string, encoding string)
 	http.ServeContent(c.Writer, c.Request, filePath, stat.ModTime(), readSeeker)
 	return true
 }
+
+// indexTemplate 在 index.html 每个 <script>/<style> 标签名之后预留 nonce 属性的插入位置
+type indexTemplate struct {
+	parts [][]byte
+}
+
+// loadIndexTemplate 优先读取未压缩的 index.html，构建产物只保留压缩文件时解压 .gz 版本
+// （.br 与 .gz 由同一份 HTML 生成，内容一致）
+func loadIndexTemplate(fsys fs.FS) (*indexTemplate, error) {
+	html, err := fs.ReadFile(fsys, "index.html")
+	if err != nil {
+		compressed, gzErr := fs.ReadFile(fsys, "index.html.gz")
+		if gzErr != nil {
+			return nil, gzErr
+		}
+		zr, gzErr := gzip.NewReader(bytes.NewReader(compressed))
+		if gzErr != nil {
+			return nil, gzErr
+		}
+		html, gzErr = io.ReadAll(zr)
+		if gzErr != nil {
+			return nil, gzErr
+		}
+	}
+	return newIndexTemplate(html), nil
+}
+
+func newIndexTemplate(html []byte) *indexTemplate {
+	t := &indexTemplate{}
+	start := 0
+	for i := 0; i < len(html); i++ {
+		if html[i] != '<' {
+			continue
+		}
+		for _, tag := range []string{"script", "style"} {
+			end := i + 1 + len(tag)
+			if end < len(html) && bytes.EqualFold(html[i+1:end], []byte(tag)) &&
+				(html[end] == ' ' || html[end] == '>' || html[end] == '\n' || html[end] == '\t') {
+				t.parts = append(t.parts, html[start:end])
+				start = end
+				break
+			}
+		}
+	}
+	t.parts = append(t.parts, html[start:])
+	return t
+}
+
+// render 在每个插入位置写入 nonce 属性，nonce 为空时还原原始 HTML
+func (t *indexTemplate) render(nonce string) []byte {
+	if nonce == "" {
+		return bytes.Join(t.parts, nil)
+	}
+	return bytes.Join(t.parts, []byte(` nonce="`+nonce+`"`))
+}
+
+func serveIndex(c *gin.Context, index *indexTemplate) {
+	if index == nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	// 内容随 nonce 变化，不设置 ETag，只允许协商后使用
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", index.render(middleware.CSPNonce(c)))
+}
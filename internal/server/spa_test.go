@@ -0,0 +1,156 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+
+	"main/internal/apierror"
+	"main/internal/config"
+	"main/internal/middleware"
+)
+
+const testIndexHTML = `<!doctype html><html><head>` +
+	`<script type="module" crossorigin src="/assets/index.js"></script>` +
+	`<link rel="stylesheet" href="/assets/index.css"><style>body{margin:0}</style>` +
+	`</head><body><div id="app"></div><scripts-not-a-tag></scripts-not-a-tag></body></html>`
+
+func gzipBytes(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(data)); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func newSPATestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	// 与 Vite 构建产物一致：只保留压缩文件，.br 内容无法被服务端改写
+	distFS := fstest.MapFS{
+		"web/dist/index.html.gz":        {Data: gzipBytes(t, testIndexHTML)},
+		"web/dist/index.html.br":        {Data: []byte("not rewritable")},
+		"web/dist/assets/index.js.gz":   {Data: gzipBytes(t, "console.log(1)")},
+		"web/dist/assets/index.js.br":   {Data: []byte("brotli")},
+		"web/dist/assets/index.css.gz":  {Data: gzipBytes(t, "body{}")},
+		"web/dist/assets/index.css.br":  {Data: []byte("brotli")},
+		"web/dist/assets/unrelated.txt": {Data: []byte("x")},
+	}
+
+	r := gin.New()
+	r.Use(middleware.SecurityHeaders(middleware.SecurityHeadersConfig{
+		FrameAncestors:        "'none'",
+		ContentSecurityPolicy: config.DefaultContentSecurityPolicy,
+	}))
+	r.NoRoute(spaHandler(distFS))
+	return r
+}
+
+var cspNoncePattern = regexp.MustCompile(`'nonce-([^']+)'`)
+
+func TestSPAHandlerInjectsPerRequestNonce(t *testing.T) {
+	r := newSPATestRouter(t)
+
+	seen := make(map[string]bool)
+	for _, path := range []string{"/", "/index.html", "/settings/security"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", "br, gzip")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", path, w.Code)
+		}
+		if w.Header().Get("Content-Encoding") != "" {
+			t.Fatalf("%s: expected rendered html without content encoding, got %q", path, w.Header().Get("Content-Encoding"))
+		}
+
+		csp := w.Header().Get("Content-Security-Policy")
+		match := cspNoncePattern.FindStringSubmatch(csp)
+		if match == nil {
+			t.Fatalf("%s: expected nonce in CSP, got %q", path, csp)
+		}
+		nonce := match[1]
+		if seen[nonce] {
+			t.Fatalf("%s: nonce %q reused across requests", path, nonce)
+		}
+		seen[nonce] = true
+		if !strings.Contains(csp, "frame-ancestors 'none'") {
+			t.Fatalf("%s: expected frame-ancestors in CSP, got %q", path, csp)
+		}
+
+		body := w.Body.String()
+		attr := `nonce="` + nonce + `"`
+		if strings.Count(body, attr) != 2 ||
+			!strings.Contains(body, `<script `+attr+` type="module"`) ||
+			!strings.Contains(body, `<style `+attr+`>`) {
+			t.Fatalf("%s: expected nonce on script and style tags, got %s", path, body)
+		}
+		if !strings.Contains(body, "<scripts-not-a-tag>") {
+			t.Fatalf("%s: unexpected rewrite of non-script tag: %s", path, body)
+		}
+	}
+}
+
+func TestSPAHandlerServesPrecompressedAssets(t *testing.T) {
+	r := newSPATestRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/assets/index.js", nil)
+	req.Header.Set("Accept-Encoding", "br, gzip")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != "br" || w.Body.String() != "brotli" {
+		t.Fatalf("expected brotli asset, got status=%d encoding=%q", w.Code, w.Header().Get("Content-Encoding"))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/assets/missing.js", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for missing asset, got %d", w.Code)
+	}
+}
+
+func TestSPAHandlerWithoutIndex(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.NoRoute(spaHandler(fstest.MapFS{"web/dist/assets/app.js.gz": {Data: []byte("x")}}))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/dashboard", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without index.html, got %d", w.Code)
+	}
+}
+
+func TestUnknownAPIPathReturnsJSONError(t *testing.T) {
+	r := newSPATestRouter(t)
+	r.NoRoute(apiNotFound, spaHandler(fstest.MapFS{"web/dist/index.html": {Data: []byte(testIndexHTML)}}))
+
+	for path, wantJSON := range map[string]bool{"/api/unknown": true, "/api": true, "/apiary": false} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var body apierror.Response
+		isJSON := json.Unmarshal(w.Body.Bytes(), &body) == nil && body.Error.Code == apierror.CodeNotFound
+		if isJSON != wantJSON || (wantJSON && w.Code != http.StatusNotFound) {
+			t.Fatalf("%s: json error=%v (want %v), status %d", path, isJSON, wantJSON, w.Code)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"crypto/tls"
+
+	"main/internal/config"
+	"main/internal/mtls"
+)
+
+// NewTLSConfig 根据配置构建 HTTPS 服务端 TLS 配置，未配置证书时返回 nil 表示使用 HTTP。
+// 服务端证书由返回的 CertReloader 提供，调用方运行其 Run 以在证书续期后自动加载。
+// 配置了 TLS_CLIENT_CA_FILE 时在握手阶段校验客户端证书。
+func NewTLSConfig(cfg *config.Config) (*tls.Config, *CertReloader, error) {
+	if cfg.TLSCertFile == "" {
+		return nil, nil, nil
+	}
+
+	certs, err := NewCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.GetCertificate,
+	}
+
+	if cfg.TLSClientCAFile != "" {
+		pool, err := mtls.LoadCertPool(cfg.TLSClientCAFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		clientAuth, err := mtls.ParseClientAuth(cfg.TLSClientAuth)
+		if err != nil {
+			return nil, nil, err
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = clientAuth
+	}
+
+	return tlsConfig, certs, nil
+}
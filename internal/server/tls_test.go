@@ -0,0 +1,108 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"main/internal/config"
+	"main/internal/mtls/mtlstest"
+)
+
+func TestNewTLSConfigLoadsClientCA(t *testing.T) {
+	if tlsConfig, _, err := NewTLSConfig(&config.Config{}); err != nil || tlsConfig != nil {
+		t.Fatalf("expected plain HTTP without certificate, got %v (%v)", tlsConfig, err)
+	}
+
+	ca := mtlstest.NewCA("test-ca")
+	dir := t.TempDir()
+	writeKeyPair(t, dir, ca.IssueServer())
+	if err := os.WriteFile(filepath.Join(dir, "client-ca.crt"), ca.PEM(), 0600); err != nil {
+		t.Fatalf("write client ca: %v", err)
+	}
+
+	tlsConfig, certs, err := NewTLSConfig(&config.Config{
+		TLSCertFile:     filepath.Join(dir, "server.crt"),
+		TLSKeyFile:      filepath.Join(dir, "server.key"),
+		TLSClientCAFile: filepath.Join(dir, "client-ca.crt"),
+		TLSClientAuth:   "require",
+	})
+	if err != nil {
+		t.Fatalf("new tls config: %v", err)
+	}
+	if tlsConfig.ClientAuth != tls.RequireAndVerifyClientCert || tlsConfig.ClientCAs == nil || tlsConfig.MinVersion != tls.VersionTLS12 ||
+		certs == nil || tlsConfig.GetCertificate == nil {
+		t.Fatalf("unexpected tls config: %+v", tlsConfig)
+	}
+
+	if _, _, err := NewTLSConfig(&config.Config{
+		TLSCertFile:     filepath.Join(dir, "server.crt"),
+		TLSKeyFile:      filepath.Join(dir, "server.key"),
+		TLSClientCAFile: filepath.Join(dir, "server.key"),
+		TLSClientAuth:   "request",
+	}); err == nil {
+		t.Fatal("expected client ca file without certificates to be rejected")
+	}
+}
+
+func TestCertReloaderPicksUpRenewedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	writeKeyPair(t, dir, mtlstest.NewCA("old-ca").IssueServer())
+
+	certs, err := NewCertReloader(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"))
+	if err != nil {
+		t.Fatalf("new cert reloader: %v", err)
+	}
+	current := func() string {
+		cert, _ := certs.GetCertificate(nil)
+		return cert.Leaf.Issuer.CommonName
+	}
+	if changed, err := certs.Reload(); err != nil || changed {
+		t.Fatalf("expected unchanged files to be skipped, got changed=%v err=%v", changed, err)
+	}
+
+	// 续期过程中证书已替换而私钥尚未写入，保留原证书
+	renewed := mtlstest.NewCA("new-ca").IssueServer()
+	if err := os.WriteFile(filepath.Join(dir, "server.crt"), certPEM(renewed), 0600); err != nil {
+		t.Fatalf("write certificate: %v", err)
+	}
+	if _, err := certs.Reload(); err == nil {
+		t.Fatal("expected mismatched key pair to fail")
+	}
+	if got := current(); got != "old-ca" {
+		t.Fatalf("expected old certificate kept, got issuer %q", got)
+	}
+
+	writeKeyPair(t, dir, renewed)
+	if changed, err := certs.Reload(); err != nil || !changed {
+		t.Fatalf("expected renewed certificate loaded, got changed=%v err=%v", changed, err)
+	}
+	if got := current(); got != "new-ca" {
+		t.Fatalf("expected renewed certificate, got issuer %q", got)
+	}
+}
+
+func certPEM(cert tls.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})
+}
+
+// writeKeyPair 将证书与私钥写入 dir 下的 server.crt 与 server.key
+func writeKeyPair(t *testing.T, dir string, cert tls.Certificate) {
+	t.Helper()
+	keyDER, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	files := map[string][]byte{
+		"server.crt": certPEM(cert),
+		"server.key": pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+}
@@ -14,3 +14,16 @@ const (
 	CleanupInterval      = 30 * time.Minute
 	CleanupGrace         = 10 * time.Minute
 )
+
+const (
+	// MFAPendingTTL 密码验证通过后等待两步验证码的最长时间
+	MFAPendingTTL        = 5 * time.Minute
+	MFAPendingMaxAge     = int(MFAPendingTTL / time.Second)
+	MFAMaxFailedAttempts = 5
+)
+
+const (
+	// OIDCStateTTL 跳转到 OIDC Provider 后等待回调的最长时间
+	OIDCStateTTL    = 10 * time.Minute
+	OIDCStateMaxAge = int(OIDCStateTTL / time.Second)
+)
@@ -6,19 +6,38 @@ import (
 	ginsessions "github.com/gin-contrib/sessions"
 )
 
-// SetCookieOptions applies a consistent cookie policy for session operations.
-func SetCookieOptions(sess ginsessions.Session, secure bool, maxAge int) {
-	sess.Options(ginsessions.Options{
+// CookiePolicy 会话 Cookie 的安全属性，零值为 SameSite=Lax 且不启用 Secure
+type CookiePolicy struct {
+	Secure bool
+	// SameSite 为 0 时使用 Lax；前端与 API 跨站部署时需要 None，且必须同时启用 Secure
+	SameSite http.SameSite
+}
+
+func (p CookiePolicy) sameSite() http.SameSite {
+	if p.SameSite == 0 {
+		return http.SameSiteLaxMode
+	}
+	return p.SameSite
+}
+
+// Options 返回应用该策略的 Cookie 选项
+func (p CookiePolicy) Options(maxAge int) ginsessions.Options {
+	return ginsessions.Options{
 		Path:     "/",
 		MaxAge:   maxAge,
 		HttpOnly: true,
-		Secure:   secure,
-		SameSite: http.SameSiteLaxMode,
-	})
+		Secure:   p.Secure,
+		SameSite: p.sameSite(),
+	}
+}
+
+// SetCookieOptions applies a consistent cookie policy for session operations.
+func SetCookieOptions(sess ginsessions.Session, policy CookiePolicy, maxAge int) {
+	sess.Options(policy.Options(maxAge))
 }
 
 // ExpireCookie clears session values and expires the session cookie immediately.
-func ExpireCookie(sess ginsessions.Session, secure bool) {
+func ExpireCookie(sess ginsessions.Session, policy CookiePolicy) {
 	sess.Clear()
-	SetCookieOptions(sess, secure, -1)
+	SetCookieOptions(sess, policy, -1)
 }
@@ -0,0 +1,17 @@
+package session
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCookiePolicyOptions(t *testing.T) {
+	if got := (CookiePolicy{}).Options(60); got.SameSite != http.SameSiteLaxMode || got.Secure || !got.HttpOnly || got.MaxAge != 60 {
+		t.Fatalf("unexpected default options: %+v", got)
+	}
+
+	got := CookiePolicy{Secure: true, SameSite: http.SameSiteNoneMode}.Options(-1)
+	if got.SameSite != http.SameSiteNoneMode || !got.Secure || got.MaxAge != -1 {
+		t.Fatalf("unexpected cross-site options: %+v", got)
+	}
+}
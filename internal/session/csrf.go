@@ -0,0 +1,31 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	ginsessions "github.com/gin-contrib/sessions"
+)
+
+// CSRFHeaderName 前端提交 CSRF 令牌使用的请求头，服务端也通过同名响应头下发
+const CSRFHeaderName = "X-CSRF-Token"
+
+const csrfSessionKey = "csrf_token"
+
+// CSRFToken 返回会话中的 CSRF 令牌，不存在时返回空字符串
+func CSRFToken(sess ginsessions.Session) string {
+	token, _ := sess.Get(csrfSessionKey).(string)
+	return token
+}
+
+// RotateCSRFToken 生成新的 CSRF 令牌写入会话，调用方负责 Save
+func RotateCSRFToken(sess ginsessions.Session) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate csrf token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+	sess.Set(csrfSessionKey, token)
+	return token, nil
+}
@@ -0,0 +1,37 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	ginsessions "github.com/gin-contrib/sessions"
+)
+
+// Start 清空旧会话数据并写入已认证会话字段，轮换 CSRF 令牌后保存，返回新的会话 ID
+func Start(sess ginsessions.Session, userID int64, username string, policy CookiePolicy) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate session ID: %w", err)
+	}
+	sessionID := hex.EncodeToString(buf)
+
+	now := time.Now().Unix()
+	sess.Clear()
+	sess.Set("authenticated", true)
+	sess.Set("session_id", sessionID)
+	sess.Set("user_id", userID)
+	sess.Set("username", username)
+	sess.Set("login_at", now)
+	sess.Set("last_seen_at", now)
+	if _, err := RotateCSRFToken(sess); err != nil {
+		return "", err
+	}
+	SetCookieOptions(sess, policy, SessionMaxAgeSeconds)
+	if err := sess.Save(); err != nil {
+		return "", fmt.Errorf("save session: %w", err)
+	}
+
+	return sessionID, nil
+}
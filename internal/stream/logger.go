@@ -13,10 +13,12 @@ import (
 
 // LogEntry 定义发送给前端的日志结构
 type LogEntry struct {
-	Time    string         `json:"time"`
-	Level   string         `json:"level"`
-	Message string         `json:"msg"`
-	Attrs   map[string]any `json:"attrs,omitempty"`
+	Time    string `json:"time"`
+	Level   string `json:"level"`
+	Message string `json:"msg"`
+	// RequestID 产生该日志的请求 ID，前端据此将同一请求的日志归组
+	RequestID string         `json:"request_id,omitempty"`
+	Attrs     map[string]any `json:"attrs,omitempty"`
 }
 
 const maxHistoryLogs = 100
@@ -26,15 +28,31 @@ type LogBroadcaster struct {
 	clients map[chan LogEntry]struct{}
 	history []LogEntry
 	mu      sync.RWMutex
+
+	done         chan struct{}
+	shutdownOnce sync.Once
 }
 
 func NewLogBroadcaster() *LogBroadcaster {
 	return &LogBroadcaster{
 		clients: make(map[chan LogEntry]struct{}),
 		history: make([]LogEntry, 0, maxHistoryLogs),
+		done:    make(chan struct{}),
 	}
 }
 
+// Shutdown 通知所有日志流连接服务即将关闭，可重复调用
+func (b *LogBroadcaster) Shutdown() {
+	b.shutdownOnce.Do(func() {
+		close(b.done)
+	})
+}
+
+// Done 在 Shutdown 后关闭
+func (b *LogBroadcaster) Done() <-chan struct{} {
+	return b.done
+}
+
 // Subscribe 添加一个新的客户端连接
 func (b *LogBroadcaster) Subscribe() chan LogEntry {
 	b.mu.Lock()
@@ -143,6 +161,7 @@ func parseJSONLogLine(line []byte) (LogEntry, bool) {
 	delete(payload, "time")
 	delete(payload, "level")
 	delete(payload, "msg")
+	entry.RequestID = extractRequestID(payload)
 	if len(payload) > 0 {
 		entry.Attrs = payload
 	}
@@ -150,6 +169,21 @@ func parseJSONLogLine(line []byte) (LogEntry, bool) {
 	return entry, true
 }
 
+// extractRequestID 读取顶层 request_id；HTTP 访问日志位于 http 分组下
+func extractRequestID(payload map[string]any) string {
+	if id, ok := payload["request_id"].(string); ok {
+		delete(payload, "request_id")
+		return id
+	}
+	if group, ok := payload["http"].(map[string]any); ok {
+		if id, ok := group["request_id"].(string); ok {
+			delete(group, "request_id")
+			return id
+		}
+	}
+	return ""
+}
+
 func normalizeStringField(raw any, fallback string) string {
 	switch value := raw.(type) {
 	case string:
@@ -84,3 +84,46 @@ func TestSSELogHandlerWithAttrsAndGroups(t *testing.T) {
 		t.Fatalf("expected http.request.method=%q, got %#v", "GET", requestGroup["method"])
 	}
 }
+
+func TestSSELogHandlerExtractsRequestID(t *testing.T) {
+	broadcaster := NewLogBroadcaster()
+	logger := slog.New(NewSSELogHandler(slog.LevelInfo, broadcaster))
+
+	logger.With("request_id", "req-1").Warn("failed to save session")
+	logger.WithGroup("http").Info("request completed", slog.String("request_id", "req-1"), slog.Int("status", 500))
+	logger.Info("background job")
+
+	history := broadcaster.GetHistory()
+	if len(history) != 3 {
+		t.Fatalf("expected 3 history entries, got %d", len(history))
+	}
+	if history[0].RequestID != "req-1" || history[0].Attrs != nil {
+		t.Fatalf("expected top-level request_id to be extracted, got %+v", history[0])
+	}
+	httpGroup, _ := history[1].Attrs["http"].(map[string]any)
+	if history[1].RequestID != "req-1" || httpGroup["request_id"] != nil {
+		t.Fatalf("expected http.request_id to be extracted, got %+v", history[1])
+	}
+	if history[2].RequestID != "" {
+		t.Fatalf("expected no request_id for background log, got %q", history[2].RequestID)
+	}
+}
+
+func TestLogBroadcasterShutdown(t *testing.T) {
+	broadcaster := NewLogBroadcaster()
+
+	select {
+	case <-broadcaster.Done():
+		t.Fatal("expected Done to stay open before Shutdown")
+	default:
+	}
+
+	broadcaster.Shutdown()
+	broadcaster.Shutdown()
+
+	select {
+	case <-broadcaster.Done():
+	default:
+		t.Fatal("expected Done to be closed after Shutdown")
+	}
+}
@@ -0,0 +1,168 @@
+// Package systemd 实现 systemd 的套接字激活（LISTEN_FDS）与 sd_notify 协议，不依赖 libsystemd。
+// 未由 systemd 启动时所有函数均为空操作。
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// listenFDsStart systemd 传入的第一个文件描述符
+const listenFDsStart = 3
+
+// 常用的 sd_notify 状态
+const (
+	StateReady    = "READY=1"
+	StateStopping = "STOPPING=1"
+	StateWatchdog = "WATCHDOG=1"
+)
+
+// Listeners 返回 systemd 套接字激活传入的监听器，顺序与 unit 中的 Listen* 一致。
+// 读取后清除 LISTEN_* 环境变量，避免子进程误用；未激活时返回 nil
+func Listeners() ([]net.Listener, error) {
+	defer func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	count := activatedCount()
+	if count == 0 {
+		return nil, nil
+	}
+
+	var names []string
+	if raw := os.Getenv("LISTEN_FDNAMES"); raw != "" {
+		names = strings.Split(raw, ":")
+	}
+	return listenersFrom(listenFDsStart, count, names)
+}
+
+// Activated 判断当前进程是否由 systemd 套接字激活启动，只读取 LISTEN_* 环境变量而不清除，
+// 供配置校验在 Listeners 之前判断主监听器是否来自 systemd
+func Activated() bool {
+	return activatedCount() > 0
+}
+
+// activatedCount 返回传给当前进程的套接字数量，LISTEN_PID 不是当前进程时为 0
+func activatedCount() int {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return 0
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return 0
+	}
+	return count
+}
+
+// listenersFrom 将 [start, start+count) 的文件描述符转换为监听器
+func listenersFrom(start, count int, names []string) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, count)
+	for i := range count {
+		fd := start + i
+
+		name := "LISTEN_FD_" + strconv.Itoa(fd)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		file := os.NewFile(uintptr(fd), name)
+		// FileListener 以 close-on-exec 方式复制描述符，原描述符随即关闭，不会泄漏给子进程
+		listener, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("systemd: fd %d (%s) is not a listening socket: %w", fd, name, err)
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+// Notify 向 NOTIFY_SOCKET 发送状态，未设置 NOTIFY_SOCKET 时返回 false
+func Notify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+	// 以 @ 开头表示 Linux 抽象命名空间
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, fmt.Errorf("systemd: failed to connect notify socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, fmt.Errorf("systemd: failed to send notification: %w", err)
+	}
+	return true, nil
+}
+
+// WatchdogInterval 返回 unit 配置的 WatchdogSec，未启用或不针对本进程时返回 0
+func WatchdogInterval() (time.Duration, error) {
+	usecStr := os.Getenv("WATCHDOG_USEC")
+	if usecStr == "" {
+		return 0, nil
+	}
+	if pidStr := os.Getenv("WATCHDOG_PID"); pidStr != "" {
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil {
+			return 0, fmt.Errorf("systemd: invalid WATCHDOG_PID %q", pidStr)
+		}
+		if pid != os.Getpid() {
+			return 0, nil
+		}
+	}
+
+	usec, err := strconv.ParseInt(usecStr, 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, fmt.Errorf("systemd: invalid WATCHDOG_USEC %q", usecStr)
+	}
+	return time.Duration(usec) * time.Microsecond, nil
+}
+
+// RunWatchdog 启用了 WatchdogSec 时按其一半的间隔发送 WATCHDOG=1，直到 ctx 结束
+func RunWatchdog(ctx context.Context) {
+	interval, err := WatchdogInterval()
+	if err != nil {
+		slog.Warn("systemd watchdog disabled", "error", err)
+		return
+	}
+	if interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := Notify(StateWatchdog); err != nil {
+				slog.Warn("failed to notify systemd watchdog", "error", err)
+			}
+		}
+	}
+}
+
+// NotifyOrLog 发送状态，失败只记录日志：通知失败不应影响服务本身
+func NotifyOrLog(state string) {
+	if _, err := Notify(state); err != nil {
+		slog.Warn("failed to notify systemd", "state", state, "error", err)
+	}
+}
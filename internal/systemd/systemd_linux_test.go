@@ -0,0 +1,60 @@
+package systemd
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func TestNotifySupportsAbstractSocket(t *testing.T) {
+	name := "vgs-notify-test-" + strconv.Itoa(os.Getpid())
+	conn := listenNotifySocket(t, "\x00"+name)
+	t.Setenv("NOTIFY_SOCKET", "@"+name)
+
+	if _, err := Notify(StateReady); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	if got := readNotification(t, conn); got != StateReady {
+		t.Fatalf("expected %q, got %q", StateReady, got)
+	}
+}
+
+func TestListenersFromInheritedDescriptors(t *testing.T) {
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer tcp.Close()
+	// 复制出一个不归 os.File 管理的描述符，模拟 systemd 传入的套接字
+	file, err := tcp.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf("dup listener: %v", err)
+	}
+	fd, err := syscall.Dup(int(file.Fd()))
+	file.Close()
+	if err != nil {
+		t.Fatalf("dup listener: %v", err)
+	}
+
+	listeners, err := listenersFrom(fd, 1, []string{"http"})
+	if err != nil {
+		t.Fatalf("listeners: %v", err)
+	}
+	defer listeners[0].Close()
+	if got := listeners[0].Addr().String(); got != tcp.Addr().String() {
+		t.Fatalf("expected inherited address %s, got %s", tcp.Addr(), got)
+	}
+
+	go func() {
+		if conn, err := net.Dial("tcp", tcp.Addr().String()); err == nil {
+			conn.Close()
+		}
+	}()
+	conn, err := listeners[0].Accept()
+	if err != nil {
+		t.Fatalf("accept on inherited listener: %v", err)
+	}
+	conn.Close()
+}
@@ -0,0 +1,95 @@
+package systemd
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func listenNotifySocket(t *testing.T, name string) *net.UnixConn {
+	t.Helper()
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: name, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("listen notify socket: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readNotification(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+	buf := make([]byte, 256)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("read notification: %v", err)
+	}
+	return string(buf[:n])
+}
+
+func TestNotifyWithoutSocketIsNoop(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+
+	sent, err := Notify(StateReady)
+	if err != nil || sent {
+		t.Fatalf("expected no notification, got sent=%v err=%v", sent, err)
+	}
+}
+
+func TestNotifySendsToSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn := listenNotifySocket(t, path)
+	t.Setenv("NOTIFY_SOCKET", path)
+
+	for _, state := range []string{StateReady, StateWatchdog, StateStopping} {
+		sent, err := Notify(state)
+		if err != nil || !sent {
+			t.Fatalf("notify %s: sent=%v err=%v", state, sent, err)
+		}
+		if got := readNotification(t, conn); got != state {
+			t.Fatalf("expected %q, got %q", state, got)
+		}
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	cases := []struct {
+		usec    string
+		pid     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{usec: "", want: 0},
+		{usec: "30000000", want: 30 * time.Second},
+		{usec: "30000000", pid: pid, want: 30 * time.Second},
+		{usec: "30000000", pid: "1", want: 0},
+		{usec: "abc", wantErr: true},
+		{usec: "0", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Setenv("WATCHDOG_USEC", tc.usec)
+		t.Setenv("WATCHDOG_PID", tc.pid)
+
+		got, err := WatchdogInterval()
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Fatalf("WATCHDOG_USEC=%q WATCHDOG_PID=%q: got %s, %v", tc.usec, tc.pid, got, err)
+		}
+	}
+}
+
+func TestListenersIgnoresOtherProcesses(t *testing.T) {
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "1")
+
+	listeners, err := Listeners()
+	if err != nil || listeners != nil {
+		t.Fatalf("expected no listeners, got %v (%v)", listeners, err)
+	}
+	if os.Getenv("LISTEN_FDS") != "" {
+		t.Fatal("expected LISTEN_FDS to be cleared")
+	}
+}
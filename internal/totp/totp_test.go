@@ -0,0 +1,84 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret 为 RFC 6238 附录 B 中 SHA1 测试向量使用的密钥 "12345678901234567890"
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeMatchesRFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := Code(rfc6238Secret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("unix %d: unexpected error: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Fatalf("unix %d: got %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateAcceptsSkewAndRejectsReplay(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	previous, err := Code(rfc6238Secret, Step(now)-1)
+	if err != nil {
+		t.Fatalf("compute code: %v", err)
+	}
+
+	step, ok := Validate(rfc6238Secret, previous, now, 0)
+	if !ok || step != Step(now)-1 {
+		t.Fatalf("expected previous step code to be accepted, got step=%d ok=%v", step, ok)
+	}
+
+	if _, ok := Validate(rfc6238Secret, previous, now, step); ok {
+		t.Fatal("expected replayed code to be rejected")
+	}
+
+	stale, err := Code(rfc6238Secret, Step(now)-2)
+	if err != nil {
+		t.Fatalf("compute code: %v", err)
+	}
+	if _, ok := Validate(rfc6238Secret, stale, now, 0); ok {
+		t.Fatal("expected code outside skew window to be rejected")
+	}
+}
+
+func TestRecoveryCodeHashIgnoresFormatting(t *testing.T) {
+	codes, err := GenerateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("generate recovery codes: %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("expected %d codes, got %d", recoveryCodeCount, len(codes))
+	}
+
+	code := codes[0]
+	variant := strings.ToUpper(strings.ReplaceAll(code, "-", " "))
+	if HashRecoveryCode(code) != HashRecoveryCode(variant) {
+		t.Fatalf("expected %q and %q to hash equally", code, variant)
+	}
+}
+
+func TestURIContainsIssuerAndSecret(t *testing.T) {
+	uri := URI("Vue Go", "alice@example.com", "JBSWY3DPEHPK3PXP")
+	for _, part := range []string{"otpauth://totp/Vue%20Go:alice@example.com", "secret=JBSWY3DPEHPK3PXP", "issuer=Vue+Go"} {
+		if !strings.Contains(uri, part) {
+			t.Fatalf("expected URI to contain %q, got %q", part, uri)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// GetExternalUser 按用户名查找允许外部身份源（可信代理、客户端证书）认证的用户。
+// 本地密码账号与 OIDC 账号须由管理员设置 external_auth 显式关联，否则返回 ErrExternalNotLinked。
+func (s *Store) GetExternalUser(ctx context.Context, username string) (*User, error) {
+	u, err := s.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if !u.ExternalAuth {
+		return nil, ErrExternalNotLinked
+	}
+	return u, nil
+}
+
+// EnsureExternalUser 同 GetExternalUser，用户不存在时以 role 创建。
+// 已存在的用户保持原角色；新建用户没有本地密码，无法通过用户名密码登录。
+func (s *Store) EnsureExternalUser(ctx context.Context, username string, role Role) (*User, error) {
+	username = strings.TrimSpace(username)
+	if err := validateUsername(username); err != nil {
+		return nil, err
+	}
+	if _, err := ParseRole(string(role)); err != nil {
+		return nil, err
+	}
+
+	u, err := s.GetExternalUser(ctx, username)
+	if !errors.Is(err, ErrNotFound) {
+		return u, err
+	}
+
+	now := s.now().Unix()
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO users(username, password_hash, role, disabled, external_auth, created_at, updated_at) VALUES (?, '', ?, 0, 1, ?, ?)`,
+		username,
+		string(role),
+		now,
+		now,
+	)
+	if err != nil {
+		// 并发请求可能已创建同名用户
+		if isUniqueViolation(err) {
+			return s.GetExternalUser(ctx, username)
+		}
+		return nil, fmt.Errorf("user: failed to insert external user: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("user: failed to read inserted id: %w", err)
+	}
+
+	return s.GetByID(ctx, id)
+}
@@ -0,0 +1,75 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// UpsertOIDCUser 根据 OIDC issuer 与 subject 查找或创建本地用户，并同步映射后的角色。
+// SSO 用户没有本地密码，无法通过用户名密码登录；用户名已被本地账号占用时返回 ErrUsernameTaken。
+func (s *Store) UpsertOIDCUser(ctx context.Context, issuer, subject, username string, role Role) (*User, error) {
+	if issuer == "" || subject == "" {
+		return nil, errors.New("user: empty oidc issuer or subject")
+	}
+	if _, err := ParseRole(string(role)); err != nil {
+		return nil, err
+	}
+
+	u, err := s.getOIDCUser(ctx, issuer, subject)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	if u != nil {
+		if u.Role == role {
+			return u, nil
+		}
+		updated, err := s.Update(ctx, u.ID, UpdateParams{Role: &role})
+		if errors.Is(err, ErrLastAdmin) {
+			slog.Warn("keep role of last admin during oidc sync", "username", u.Username, "mapped_role", role)
+			return u, nil
+		}
+		return updated, err
+	}
+
+	username = strings.TrimSpace(username)
+	if err := validateUsername(username); err != nil {
+		return nil, err
+	}
+
+	now := s.now().Unix()
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO users(username, password_hash, role, disabled, created_at, updated_at, oidc_issuer, oidc_subject) VALUES (?, '', ?, 0, ?, ?, ?, ?)`,
+		username,
+		string(role),
+		now,
+		now,
+		issuer,
+		subject,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrUsernameTaken
+		}
+		return nil, fmt.Errorf("user: failed to insert oidc user: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("user: failed to read inserted id: %w", err)
+	}
+
+	return s.GetByID(ctx, id)
+}
+
+// getOIDCUser 按 issuer + subject 查找用户
+func (s *Store) getOIDCUser(ctx context.Context, issuer, subject string) (*User, error) {
+	return scanUser(s.db.QueryRowContext(ctx,
+		`SELECT `+userColumns+` FROM users WHERE oidc_issuer = ? AND oidc_subject = ?`,
+		issuer,
+		subject,
+	))
+}
@@ -0,0 +1,30 @@
+package user
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUpsertOIDCUserMatchesIssuerAndSubject(t *testing.T) {
+	store := newTestStore(t, &fakeClock{now: time.Unix(1_700_000_000, 0)})
+	ctx := t.Context()
+
+	alice, err := store.UpsertOIDCUser(ctx, "https://idp-a.example.com", "subject-1", "alice", RoleViewer)
+	if err != nil {
+		t.Fatalf("create oidc user: %v", err)
+	}
+	again, err := store.UpsertOIDCUser(ctx, "https://idp-a.example.com", "subject-1", "alice-renamed", RoleOperator)
+	if err != nil || again.ID != alice.ID || again.Role != RoleOperator {
+		t.Fatalf("expected same user with synced role, got %+v (%v)", again, err)
+	}
+
+	// 另一个 issuer 的同名 subject 是不同的身份，不能登录已有账号
+	other, err := store.UpsertOIDCUser(ctx, "https://idp-b.example.com", "subject-1", "mallory", RoleViewer)
+	if err != nil || other.ID == alice.ID {
+		t.Fatalf("expected separate user for another issuer, got %+v (%v)", other, err)
+	}
+	if _, err := store.UpsertOIDCUser(ctx, "https://idp-b.example.com", "subject-2", "alice", RoleViewer); !errors.Is(err, ErrUsernameTaken) {
+		t.Fatalf("expected username collision to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,334 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const userColumns = `id, username, password_hash, role, disabled, created_at, updated_at, totp_secret, totp_enabled, totp_last_step, oidc_issuer, oidc_subject, external_auth`
+
+// dummyPasswordHash 用于用户不存在时执行等价的 bcrypt 比较，避免通过耗时枚举用户名
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+// Store 基于 SQLite 的用户存储
+type Store struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// NewStore 创建用户存储
+func NewStore(db *sql.DB) *Store {
+	return &Store{
+		db:  db,
+		now: time.Now,
+	}
+}
+
+// UpdateParams 用户更新参数，nil 字段表示不修改
+type UpdateParams struct {
+	Role     *Role
+	Disabled *bool
+	Password *string
+	// ExternalAuth 是否允许可信代理或客户端证书以该用户名登录
+	ExternalAuth *bool
+}
+
+// EnsureBootstrapAdmin 在用户表为空时创建初始管理员，返回是否创建
+func (s *Store) EnsureBootstrapAdmin(ctx context.Context, username, password string) (bool, error) {
+	var count int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		return false, fmt.Errorf("user: failed to count users: %w", err)
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	// 初始管理员密码来自 AUTH_KEY，不套用常规密码长度下限；bcrypt 拒绝超过 72 字节的输入，需提前给出明确错误
+	if len(password) > maxPasswordLength {
+		return false, fmt.Errorf(
+			"%w: AUTH_KEY is used as the initial admin password and must be at most %d bytes, got %d",
+			ErrInvalidPassword,
+			maxPasswordLength,
+			len(password),
+		)
+	}
+	if _, err := s.insert(ctx, username, password, RoleAdmin); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Create 创建用户
+func (s *Store) Create(ctx context.Context, username, password string, role Role) (*User, error) {
+	if err := validatePassword(password); err != nil {
+		return nil, err
+	}
+	return s.insert(ctx, username, password, role)
+}
+
+func (s *Store) insert(ctx context.Context, username, password string, role Role) (*User, error) {
+	username = strings.TrimSpace(username)
+	if err := validateUsername(username); err != nil {
+		return nil, err
+	}
+	if _, err := ParseRole(string(role)); err != nil {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("user: failed to hash password: %w", err)
+	}
+
+	now := s.now().Unix()
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO users(username, password_hash, role, disabled, created_at, updated_at) VALUES (?, ?, ?, 0, ?, ?)`,
+		username,
+		string(hash),
+		string(role),
+		now,
+		now,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrUsernameTaken
+		}
+		return nil, fmt.Errorf("user: failed to insert user: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("user: failed to read inserted id: %w", err)
+	}
+
+	return &User{
+		ID:           id,
+		Username:     username,
+		Role:         role,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		PasswordHash: string(hash),
+	}, nil
+}
+
+// GetByID 按 ID 查询用户
+func (s *Store) GetByID(ctx context.Context, id int64) (*User, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?`, id)
+	return scanUser(row)
+}
+
+// GetByUsername 按用户名查询用户（不区分大小写）
+func (s *Store) GetByUsername(ctx context.Context, username string) (*User, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE username = ?`, strings.TrimSpace(username))
+	return scanUser(row)
+}
+
+// List 返回全部用户，按 ID 升序
+func (s *Store) List(ctx context.Context) ([]User, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+userColumns+` FROM users ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("user: failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]User, 0)
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("user: failed to iterate users: %w", err)
+	}
+
+	return users, nil
+}
+
+// Authenticate 校验用户名和密码，禁用账号返回 ErrDisabled
+func (s *Store) Authenticate(ctx context.Context, username, password string) (*User, error) {
+	u, err := s.GetByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	// SSO 用户没有本地密码，同样执行一次比较以保持耗时一致
+	if u.PasswordHash == "" {
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if u.Disabled {
+		return nil, ErrDisabled
+	}
+
+	return u, nil
+}
+
+// Update 更新用户角色、禁用状态、密码或外部身份源关联。
+// 若更新会导致不再有启用状态的管理员，返回 ErrLastAdmin。
+func (s *Store) Update(ctx context.Context, id int64, params UpdateParams) (*User, error) {
+	var passwordHash string
+	if params.Password != nil {
+		if err := validatePassword(*params.Password); err != nil {
+			return nil, err
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(*params.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("user: failed to hash password: %w", err)
+		}
+		passwordHash = string(hash)
+	}
+	if params.Role != nil {
+		if _, err := ParseRole(string(*params.Role)); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("user: failed to begin update: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	u, err := scanUser(tx.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?`, id))
+	if err != nil {
+		return nil, err
+	}
+
+	wasActiveAdmin := u.Role == RoleAdmin && !u.Disabled
+	if params.Role != nil {
+		u.Role = *params.Role
+	}
+	if params.Disabled != nil {
+		u.Disabled = *params.Disabled
+	}
+	if passwordHash != "" {
+		u.PasswordHash = passwordHash
+	}
+	if params.ExternalAuth != nil {
+		u.ExternalAuth = *params.ExternalAuth
+	}
+
+	if wasActiveAdmin && (u.Role != RoleAdmin || u.Disabled) {
+		if err := ensureOtherActiveAdmin(ctx, tx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	u.UpdatedAt = s.now().Unix()
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE users SET password_hash = ?, role = ?, disabled = ?, external_auth = ?, updated_at = ? WHERE id = ?`,
+		u.PasswordHash,
+		string(u.Role),
+		u.Disabled,
+		u.ExternalAuth,
+		u.UpdatedAt,
+		id,
+	); err != nil {
+		return nil, fmt.Errorf("user: failed to update user %d: %w", id, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("user: failed to commit update: %w", err)
+	}
+
+	return u, nil
+}
+
+// Delete 删除用户，不允许删除最后一个启用的管理员
+func (s *Store) Delete(ctx context.Context, id int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("user: failed to begin delete: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	u, err := scanUser(tx.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?`, id))
+	if err != nil {
+		return err
+	}
+
+	if u.Role == RoleAdmin && !u.Disabled {
+		if err := ensureOtherActiveAdmin(ctx, tx, id); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("user: failed to delete user %d: %w", id, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("user: failed to commit delete: %w", err)
+	}
+
+	return nil
+}
+
+func ensureOtherActiveAdmin(ctx context.Context, tx *sql.Tx, excludeID int64) error {
+	var count int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM users WHERE role = ? AND disabled = 0 AND id <> ?`,
+		string(RoleAdmin),
+		excludeID,
+	).Scan(&count); err != nil {
+		return fmt.Errorf("user: failed to count admins: %w", err)
+	}
+	if count == 0 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (*User, error) {
+	var (
+		u    User
+		role string
+	)
+	if err := row.Scan(
+		&u.ID,
+		&u.Username,
+		&u.PasswordHash,
+		&role,
+		&u.Disabled,
+		&u.CreatedAt,
+		&u.UpdatedAt,
+		&u.TOTPSecret,
+		&u.TOTPEnabled,
+		&u.TOTPLastStep,
+		&u.OIDCIssuer,
+		&u.OIDCSubject,
+		&u.ExternalAuth,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("user: failed to scan user: %w", err)
+	}
+	u.Role = Role(role)
+	// OIDC 与外部身份源创建的用户都没有本地密码
+	u.SSO = u.OIDCSubject != "" || u.PasswordHash == ""
+	return &u, nil
+}
+
+func isUniqueViolation(err error) bool {
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
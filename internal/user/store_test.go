@@ -0,0 +1,54 @@
+package user
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"main/internal/database"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.now
+}
+
+func newTestStore(t *testing.T, clock *fakeClock) *Store {
+	t.Helper()
+
+	dbContainer, err := database.Open(t.Context(), database.Options{
+		Path: filepath.Join(t.TempDir(), "data.db"),
+	})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = dbContainer.Close()
+	})
+
+	store := NewStore(dbContainer.DB())
+	store.now = clock.Now
+	return store
+}
+
+func TestEnsureBootstrapAdminRejectsOverlongPassword(t *testing.T) {
+	store := newTestStore(t, &fakeClock{now: time.Unix(1_700_000_000, 0)})
+
+	created, err := store.EnsureBootstrapAdmin(t.Context(), DefaultAdminUsername, strings.Repeat("k", maxPasswordLength+1))
+	if created || !errors.Is(err, ErrInvalidPassword) || !strings.Contains(err.Error(), "AUTH_KEY") {
+		t.Fatalf("expected overlong AUTH_KEY to be rejected with a clear error, got %t %v", created, err)
+	}
+
+	created, err = store.EnsureBootstrapAdmin(t.Context(), DefaultAdminUsername, strings.Repeat("k", maxPasswordLength))
+	if !created || err != nil {
+		t.Fatalf("expected 72-byte AUTH_KEY to create the admin, got %t %v", created, err)
+	}
+	if _, err := store.Authenticate(t.Context(), DefaultAdminUsername, strings.Repeat("k", maxPasswordLength)); err != nil {
+		t.Fatalf("expected admin to log in with AUTH_KEY, got %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"main/internal/totp"
+)
+
+// BeginTOTPEnrollment 为用户生成待确认的 TOTP 密钥，已启用时返回 ErrTOTPAlreadyEnabled
+func (s *Store) BeginTOTPEnrollment(ctx context.Context, id int64) (string, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", err
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE users SET totp_secret = ?, totp_last_step = 0, updated_at = ? WHERE id = ? AND totp_enabled = 0`,
+		secret,
+		s.now().Unix(),
+		id,
+	)
+	if err != nil {
+		return "", fmt.Errorf("user: failed to store totp secret: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return "", fmt.Errorf("user: failed to read affected rows: %w", err)
+	}
+	if affected == 0 {
+		if _, err := s.GetByID(ctx, id); err != nil {
+			return "", err
+		}
+		return "", ErrTOTPAlreadyEnabled
+	}
+
+	return secret, nil
+}
+
+// ConfirmTOTPEnrollment 校验首个验证码后启用 TOTP，并返回一次性恢复码明文
+func (s *Store) ConfirmTOTPEnrollment(ctx context.Context, id int64, code string) ([]string, error) {
+	u, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if u.TOTPSecret == "" {
+		return nil, ErrTOTPNotEnrolled
+	}
+
+	step, ok := totp.Validate(u.TOTPSecret, code, s.now(), u.TOTPLastStep)
+	if !ok {
+		return nil, ErrInvalidTOTPCode
+	}
+
+	codes, err := totp.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("user: failed to begin totp enrollment: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := s.now().Unix()
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE users SET totp_enabled = 1, totp_last_step = ?, updated_at = ? WHERE id = ?`,
+		step,
+		now,
+		id,
+	); err != nil {
+		return nil, fmt.Errorf("user: failed to enable totp: %w", err)
+	}
+	if err := replaceRecoveryCodes(ctx, tx, id, codes, now); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("user: failed to commit totp enrollment: %w", err)
+	}
+
+	return codes, nil
+}
+
+// VerifyTOTP 校验已启用用户的验证码，同一步长的验证码只能使用一次
+func (s *Store) VerifyTOTP(ctx context.Context, id int64, code string) error {
+	u, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !u.TOTPEnabled {
+		return ErrTOTPNotEnrolled
+	}
+
+	step, ok := totp.Validate(u.TOTPSecret, code, s.now(), u.TOTPLastStep)
+	if !ok {
+		return ErrInvalidTOTPCode
+	}
+
+	// 条件更新保证并发请求中同一验证码只会成功一次
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE users SET totp_last_step = ? WHERE id = ? AND totp_last_step < ?`,
+		step,
+		id,
+		step,
+	)
+	if err != nil {
+		return fmt.Errorf("user: failed to record totp step: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("user: failed to read affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrInvalidTOTPCode
+	}
+
+	return nil
+}
+
+// ConsumeRecoveryCode 使用一次性恢复码，成功后该恢复码失效
+func (s *Store) ConsumeRecoveryCode(ctx context.Context, id int64, code string) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE user_recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL`,
+		s.now().Unix(),
+		id,
+		totp.HashRecoveryCode(code),
+	)
+	if err != nil {
+		return fmt.Errorf("user: failed to consume recovery code: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("user: failed to read affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrInvalidTOTPCode
+	}
+	return nil
+}
+
+// ResetTOTP 关闭用户的 TOTP 并删除全部恢复码
+func (s *Store) ResetTOTP(ctx context.Context, id int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("user: failed to begin totp reset: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE users SET totp_secret = '', totp_enabled = 0, totp_last_step = 0, updated_at = ? WHERE id = ?`,
+		s.now().Unix(),
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("user: failed to reset totp: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("user: failed to read affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = ?`, id); err != nil {
+		return fmt.Errorf("user: failed to delete recovery codes: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("user: failed to commit totp reset: %w", err)
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, id int64, codes []string, now int64) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = ?`, id); err != nil {
+		return fmt.Errorf("user: failed to delete recovery codes: %w", err)
+	}
+	for _, code := range codes {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO user_recovery_codes(user_id, code_hash, created_at) VALUES (?, ?, ?)`,
+			id,
+			totp.HashRecoveryCode(code),
+			now,
+		); err != nil {
+			return fmt.Errorf("user: failed to insert recovery code: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	"main/internal/totp"
+)
+
+func TestTOTPEnrollmentAndVerification(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	store := newTestStore(t, clock)
+
+	u, err := store.Create(t.Context(), "alice", "alice-password", RoleAdmin)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	secret, err := store.BeginTOTPEnrollment(t.Context(), u.ID)
+	if err != nil {
+		t.Fatalf("begin enrollment: %v", err)
+	}
+
+	if _, err := store.ConfirmTOTPEnrollment(t.Context(), u.ID, "000000"); err != ErrInvalidTOTPCode {
+		t.Fatalf("expected ErrInvalidTOTPCode for wrong code, got %v", err)
+	}
+
+	code, err := totp.Code(secret, totp.Step(clock.now))
+	if err != nil {
+		t.Fatalf("compute code: %v", err)
+	}
+	recoveryCodes, err := store.ConfirmTOTPEnrollment(t.Context(), u.ID, code)
+	if err != nil {
+		t.Fatalf("confirm enrollment: %v", err)
+	}
+	if len(recoveryCodes) == 0 {
+		t.Fatal("expected recovery codes")
+	}
+
+	if _, err := store.BeginTOTPEnrollment(t.Context(), u.ID); err != ErrTOTPAlreadyEnabled {
+		t.Fatalf("expected ErrTOTPAlreadyEnabled, got %v", err)
+	}
+
+	// 启用时使用的验证码不能再用于登录
+	if err := store.VerifyTOTP(t.Context(), u.ID, code); err != ErrInvalidTOTPCode {
+		t.Fatalf("expected replayed code to be rejected, got %v", err)
+	}
+
+	clock.now = clock.now.Add(totp.Period * time.Second)
+	next, err := totp.Code(secret, totp.Step(clock.now))
+	if err != nil {
+		t.Fatalf("compute code: %v", err)
+	}
+	if err := store.VerifyTOTP(t.Context(), u.ID, next); err != nil {
+		t.Fatalf("expected next step code to be accepted, got %v", err)
+	}
+
+	clock.now = clock.now.Add(10 * totp.Period * time.Second)
+	if err := store.VerifyTOTP(t.Context(), u.ID, next); err != ErrInvalidTOTPCode {
+		t.Fatalf("expected expired code to be rejected, got %v", err)
+	}
+
+	if err := store.ConsumeRecoveryCode(t.Context(), u.ID, recoveryCodes[0]); err != nil {
+		t.Fatalf("consume recovery code: %v", err)
+	}
+	if err := store.ConsumeRecoveryCode(t.Context(), u.ID, recoveryCodes[0]); err != ErrInvalidTOTPCode {
+		t.Fatalf("expected used recovery code to be rejected, got %v", err)
+	}
+
+	if err := store.ResetTOTP(t.Context(), u.ID); err != nil {
+		t.Fatalf("reset totp: %v", err)
+	}
+	reloaded, err := store.GetByID(t.Context(), u.ID)
+	if err != nil {
+		t.Fatalf("reload user: %v", err)
+	}
+	if reloaded.TOTPEnabled || reloaded.TOTPSecret != "" {
+		t.Fatalf("expected totp to be cleared, got enabled=%v", reloaded.TOTPEnabled)
+	}
+	if err := store.ConsumeRecoveryCode(t.Context(), u.ID, recoveryCodes[1]); err != ErrInvalidTOTPCode {
+		t.Fatalf("expected recovery codes to be removed on reset, got %v", err)
+	}
+}
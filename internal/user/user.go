@@ -0,0 +1,96 @@
+package user
+
+import (
+	"errors"
+	"regexp"
+)
+
+// Role 用户角色，权限由高到低为 admin > operator > viewer
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"
+	RoleOperator Role = "operator"
+	RoleViewer   Role = "viewer"
+)
+
+// DefaultAdminUsername 首次启动时由 AUTH_KEY 创建的管理员用户名
+const DefaultAdminUsername = "admin"
+
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72 // bcrypt 只使用前 72 字节
+)
+
+var roleRanks = map[Role]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._@-]{0,63}$`)
+
+var (
+	ErrNotFound           = errors.New("user: not found")
+	ErrUsernameTaken      = errors.New("user: username already exists")
+	ErrInvalidUsername    = errors.New("user: invalid username")
+	ErrInvalidRole        = errors.New("user: invalid role")
+	ErrInvalidPassword    = errors.New("user: password must be 8-72 bytes")
+	ErrInvalidCredentials = errors.New("user: invalid credentials")
+	ErrDisabled           = errors.New("user: account disabled")
+	ErrLastAdmin          = errors.New("user: at least one active admin is required")
+	ErrTOTPAlreadyEnabled = errors.New("user: totp already enabled")
+	ErrTOTPNotEnrolled    = errors.New("user: totp enrollment not started")
+	ErrInvalidTOTPCode    = errors.New("user: invalid totp code")
+	ErrExternalNotLinked  = errors.New("user: account is not linked to external authentication")
+)
+
+// User 用户信息，敏感字段不参与 JSON 序列化
+type User struct {
+	ID           int64  `json:"id"`
+	Username     string `json:"username"`
+	Role         Role   `json:"role"`
+	Disabled     bool   `json:"disabled"`
+	TOTPEnabled  bool   `json:"totp_enabled"`
+	SSO          bool   `json:"sso"`
+	ExternalAuth bool   `json:"external_auth"`
+	CreatedAt    int64  `json:"created_at"`
+	UpdatedAt    int64  `json:"updated_at"`
+	PasswordHash string `json:"-"`
+	TOTPSecret   string `json:"-"`
+	TOTPLastStep int64  `json:"-"`
+	OIDCIssuer   string `json:"-"`
+	OIDCSubject  string `json:"-"`
+}
+
+// ParseRole 解析角色字符串
+func ParseRole(value string) (Role, error) {
+	role := Role(value)
+	if _, ok := roleRanks[role]; !ok {
+		return "", ErrInvalidRole
+	}
+	return role, nil
+}
+
+// Allows 判断当前角色是否满足 required 所需的最低权限
+func (r Role) Allows(required Role) bool {
+	rank, ok := roleRanks[r]
+	if !ok {
+		return false
+	}
+	return rank >= roleRanks[required]
+}
+
+func validateUsername(username string) error {
+	if !usernamePattern.MatchString(username) {
+		return ErrInvalidUsername
+	}
+	return nil
+}
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return ErrInvalidPassword
+	}
+	return nil
+}
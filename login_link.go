@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"main/internal/audit"
+	"main/internal/config"
+	"main/internal/database"
+	"main/internal/loginlink"
+	"main/internal/user"
+)
+
+// mintLoginLink 为指定用户签发一次性登录链接并写入审计日志，actor 标识签发来源
+func mintLoginLink(ctx context.Context, cfg *config.Config, db *sql.DB, username, actor string) (string, time.Time, error) {
+	u, err := user.NewStore(db).GetByUsername(ctx, username)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("load user %q: %w", username, err)
+	}
+	if u.Disabled {
+		return "", time.Time{}, fmt.Errorf("user %q is disabled", username)
+	}
+
+	token, expiresAt, err := loginlink.NewStore(db, cfg.DataDir).Mint(u.ID, time.Duration(cfg.LoginLinkTTLMinutes)*time.Minute)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if err := audit.NewStore(db).Record(ctx, audit.Event{
+		Actor:   actor,
+		Action:  audit.ActionLoginLinkCreate,
+		Target:  fmt.Sprintf("user:%d", u.ID),
+		Outcome: audit.OutcomeSuccess,
+		Detail:  "expires_at=" + expiresAt.UTC().Format(time.RFC3339),
+	}); err != nil {
+		return "", time.Time{}, err
+	}
+
+	return loginlink.URL(cfg.PublicURL, token), expiresAt, nil
+}
+
+// runLoginLink 实现 login-link 子命令：为用户（默认 admin）签发一次性登录链接并输出到标准输出
+func runLoginLink(args []string) int {
+	username := user.DefaultAdminUsername
+	if len(args) > 0 {
+		username = args[0]
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	dbContainer, err := database.Open(ctx, database.Options{Path: filepath.Join(cfg.DataDir, "data.db")})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open database: %v\n", err)
+		return 2
+	}
+	defer dbContainer.Close()
+
+	link, expiresAt, err := mintLoginLink(ctx, cfg, dbContainer.DB(), username, "cli")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create login link: %v\n", err)
+		return 1
+	}
+
+	fmt.Println(link)
+	fmt.Fprintf(os.Stderr, "一次性登录链接，%s 前有效，仅可使用一次\n", expiresAt.Format(time.DateTime))
+	return 0
+}
@@ -5,15 +5,26 @@ import (
 	"embed"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/quic-go/quic-go/http3"
+
+	"main/internal/audit"
 	"main/internal/config"
 	"main/internal/database"
+	"main/internal/maintenance"
 	"main/internal/middleware"
 	"main/internal/server"
 	"main/internal/session"
 	"main/internal/stream"
+	"main/internal/systemd"
+	"main/internal/user"
 )
 
 //go:embed web/dist
@@ -23,7 +34,15 @@ var distFS embed.FS
 func printBanner(cfg *config.Config) {
 	separator := "════════════════════════════════════════════════"
 	fmt.Println(separator)
-	fmt.Printf("服务地址: http://localhost:%d\n", cfg.Port)
+	scheme := "http"
+	if cfg.TLSCertFile != "" {
+		scheme = "https"
+	}
+	if config.IsUnixListenAddr(cfg.ListenAddr) {
+		fmt.Printf("监听地址: %s\n", cfg.ListenAddr)
+	} else {
+		fmt.Printf("服务地址: %s://localhost:%d\n", scheme, cfg.Port)
+	}
 	fmt.Printf("日志级别: %s\n", cfg.LogLevel)
 	fmt.Printf("数据目录: %s\n", cfg.DataDir)
 	fmt.Printf("数据库文件: %s\n", filepath.Join(cfg.DataDir, "data.db"))
@@ -31,6 +50,17 @@ func printBanner(cfg *config.Config) {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "verify-audit":
+			os.Exit(runVerifyAudit())
+		case "login-link":
+			os.Exit(runLoginLink(os.Args[2:]))
+		case "maintenance":
+			os.Exit(runMaintenance(os.Args[2:]))
+		}
+	}
+
 	startTime := time.Now().Unix()
 
 	// 加载配置
@@ -48,9 +78,9 @@ func main() {
 	// 打印启动横幅
 	printBanner(cfg)
 
-	// 如果是自动生成的 AUTH_KEY，则打印出来
+	// 自动生成的 AUTH_KEY 不写入日志，日志会进入登录用户可读的 SSE 历史
 	if cfg.IsAutoAuthKey {
-		slog.Info("自动生成的 AUTH_KEY", "auth_key", cfg.AuthKey)
+		slog.Warn("AUTH_KEY 未设置，已自动生成，重启后会话将失效")
 	}
 
 	// 初始化 SQLite 数据库
@@ -63,29 +93,156 @@ func main() {
 		slog.Error("failed to initialize database", "error", err)
 		return
 	}
-	defer func() {
-		if err := dbContainer.Close(); err != nil {
-			slog.Warn("failed to close database", "error", err)
-		}
-	}()
+	// 正常退出与启动失败的提前返回都只在这里关闭一次
+	defer closeDatabase(dbContainer)
 	slog.Info("database initialized", "path", dbContainer.Path())
 
+	created, err := user.NewStore(dbContainer.DB()).EnsureBootstrapAdmin(dbCtx, user.DefaultAdminUsername, cfg.AuthKey)
+	if err != nil {
+		slog.Error("failed to bootstrap admin user", "error", err)
+		return
+	}
+	if created {
+		if cfg.IsAutoAuthKey {
+			// 自动生成的 AUTH_KEY 不输出且每次启动都会变化，不能用作密码
+			slog.Warn("初始管理员已创建，密码不可用，请通过一次性登录链接登录后设置密码", "username", user.DefaultAdminUsername)
+		} else {
+			slog.Info("初始管理员已创建，密码为 AUTH_KEY", "username", user.DefaultAdminUsername)
+		}
+		// 链接只打印到标准输出，不经过 slog，避免出现在日志流中
+		link, expiresAt, err := mintLoginLink(dbCtx, cfg, dbContainer.DB(), user.DefaultAdminUsername, "system")
+		if err != nil {
+			slog.Error("failed to create initial login link", "error", err)
+		} else {
+			fmt.Printf("初始管理员一次性登录链接（%s 前有效）:\n%s\n", expiresAt.Format(time.DateTime), link)
+		}
+	}
+
 	if _, err := session.Bootstrap(cfg.DataDir, cfg.AuthKey, time.Now()); err != nil {
 		slog.Error("failed to bootstrap session maintenance", "error", err)
 		return
 	}
 
+	maintenanceMode, err := maintenance.Open(cfg.DataDir)
+	if err != nil {
+		slog.Error("failed to load maintenance state", "error", err)
+		return
+	}
+	if state := maintenanceMode.Current(); state.Enabled {
+		slog.Warn("维护模式已开启，非管理员的写请求将被拒绝", "updated_by", state.UpdatedBy)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	janitorCtx, janitorCancel := context.WithCancel(context.Background())
-	defer janitorCancel()
-	go session.RunJanitor(janitorCtx, cfg.DataDir, time.Now)
+	var background sync.WaitGroup
+	// 启动失败提前返回时同样先停止后台任务，再由上面的 defer 关闭数据库
+	defer func() {
+		janitorCancel()
+		background.Wait()
+	}()
+	background.Go(func() { session.RunJanitor(janitorCtx, cfg.DataDir, time.Now) })
+	background.Go(func() { maintenance.Run(janitorCtx, maintenanceMode, maintenance.PollInterval) })
+	auditLog := audit.NewStore(dbContainer.DB())
+	background.Go(func() {
+		audit.RunRetention(janitorCtx, auditLog, time.Duration(cfg.AuditRetentionDays)*24*time.Hour)
+	})
+	background.Go(func() {
+		audit.RunCheckpoints(
+			janitorCtx,
+			auditLog,
+			audit.NewCheckpoints(cfg.DataDir),
+			time.Duration(cfg.AuditCheckpointMinutes)*time.Minute,
+		)
+	})
+
+	tlsConfig, certs, err := server.NewTLSConfig(cfg)
+	if err != nil {
+		slog.Error("failed to initialize tls", "error", err)
+		return
+	}
+	if certs != nil {
+		background.Go(func() { certs.Run(janitorCtx, cfg.TLSReloadInterval) })
+	}
 
 	// 创建路由
-	r := server.NewRouter(cfg, logBroadcaster, startTime, distFS)
+	r, err := server.NewRouter(cfg, dbContainer, logBroadcaster, maintenanceMode, startTime, distFS)
+	if err != nil {
+		slog.Error("failed to initialize router", "error", err)
+		return
+	}
 
 	// 启动服务器
-	addr := fmt.Sprintf(":%d", cfg.Port)
-	slog.Info("启动 HTTP 服务器", "address", addr)
-	if err := r.Run(addr); err != nil {
+	listener, err := server.Listen(cfg)
+	if err != nil {
+		slog.Error("failed to listen", "address", cfg.ListenAddr, "error", err)
+		return
+	}
+	var handler http.Handler = r
+	var h3Srv *http3.Server
+	if tlsConfig != nil && cfg.HTTP3Enabled {
+		h3Srv = server.NewHTTP3Server(cfg, r, tlsConfig)
+		handler = server.WithAltSvc(h3Srv, r)
+	}
+	srv := server.NewHTTPServer(cfg, handler, tlsConfig)
+	// Shutdown 开始时通知日志流客户端，否则 SSE 长连接会一直占用到超时
+	srv.RegisterOnShutdown(logBroadcaster.Shutdown)
+	srv.RegisterOnShutdown(maintenanceMode.Shutdown)
+	servers := []gracefulServer{srv}
+
+	serveErr := make(chan error, 3)
+	if h3Srv != nil {
+		servers = append(servers, h3Srv)
+		go func() {
+			slog.Info("启动 HTTP/3 服务器", "address", h3Srv.Addr)
+			serveErr <- h3Srv.ListenAndServe()
+		}()
+	}
+	var redirectSrv *http.Server
+	if tlsConfig != nil && cfg.HTTPRedirectPort != 0 {
+		redirectSrv = server.NewRedirectServer(cfg)
+		go func() {
+			slog.Info("启动 HTTP 重定向服务器", "address", redirectSrv.Addr)
+			serveErr <- redirectSrv.ListenAndServe()
+		}()
+	}
+	go func() {
+		if tlsConfig != nil {
+			slog.Info("启动 HTTPS 服务器", "address", listener.Addr().String(), "client_auth", tlsConfig.ClientAuth.String())
+			serveErr <- srv.ServeTLS(listener, "", "")
+		} else {
+			slog.Info("启动 HTTP 服务器", "address", listener.Addr().String())
+			serveErr <- srv.Serve(listener)
+		}
+	}()
+	// 监听器已就绪，由 systemd 管理（Type=notify）时通知启动完成并按 WatchdogSec 发送心跳
+	systemd.NotifyOrLog(systemd.StateReady)
+	background.Go(func() { systemd.RunWatchdog(janitorCtx) })
+
+	select {
+	case err := <-serveErr:
 		slog.Error("http server exited with error", "error", err)
+		// 任一监听失败时一并关闭其他服务器
+		for _, s := range servers {
+			_ = s.Close()
+		}
+		if redirectSrv != nil {
+			_ = redirectSrv.Close()
+		}
+	case <-ctx.Done():
+		// 恢复默认信号处理，再次 Ctrl+C 可强制退出
+		stop()
+		systemd.NotifyOrLog(systemd.StateStopping)
+		slog.Info("收到退出信号，开始关闭服务", "timeout", cfg.ShutdownTimeout.String())
+		if redirectSrv != nil {
+			// 重定向响应即时完成，直接关闭即可
+			_ = redirectSrv.Close()
+		}
+		shutdownServers(cfg.ShutdownTimeout, servers...)
 	}
+
+	janitorCancel()
+	background.Wait()
+	slog.Info("后台任务已停止")
 }
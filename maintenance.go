@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"main/internal/audit"
+	"main/internal/config"
+	"main/internal/database"
+	"main/internal/maintenance"
+)
+
+const maintenanceUsage = `usage: maintenance status
+       maintenance on [-message 说明] [-retry-after 秒]
+       maintenance off`
+
+// runMaintenance 实现 maintenance 子命令：查看或切换维护模式。
+// 状态写入 DATA_DIR，运行中的服务在数秒内生效，未启动的服务在启动时读取
+func runMaintenance(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, maintenanceUsage)
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		return 2
+	}
+
+	state, err := maintenance.Load(cfg.DataDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load maintenance state: %v\n", err)
+		return 2
+	}
+
+	switch args[0] {
+	case "status":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(state)
+		return 0
+	case "on":
+		flags := flag.NewFlagSet("maintenance on", flag.ContinueOnError)
+		message := flags.String("message", "", "展示给用户的维护说明")
+		retryAfter := flags.Int("retry-after", 0, "写请求被拒绝时建议的重试间隔（秒），0 使用默认值")
+		if err := flags.Parse(args[1:]); err != nil {
+			return 2
+		}
+		state = maintenance.State{Enabled: true, Message: *message, RetryAfterSeconds: *retryAfter}
+	case "off":
+		state = maintenance.State{}
+	default:
+		fmt.Fprintln(os.Stderr, maintenanceUsage)
+		return 2
+	}
+
+	state.UpdatedAt = time.Now().Unix()
+	state.UpdatedBy = "cli"
+	if err := os.MkdirAll(cfg.DataDir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create data dir: %v\n", err)
+		return 2
+	}
+	if err := maintenance.Save(cfg.DataDir, state); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to save maintenance state: %v\n", err)
+		return 1
+	}
+
+	if err := recordMaintenanceAudit(cfg, state); err != nil {
+		// 状态已生效，审计写入失败只提示
+		fmt.Fprintf(os.Stderr, "warning: failed to record audit event: %v\n", err)
+	}
+
+	if state.Enabled {
+		fmt.Fprintln(os.Stderr, "维护模式已开启，非管理员的写请求将返回 503")
+	} else {
+		fmt.Fprintln(os.Stderr, "维护模式已关闭")
+	}
+	return 0
+}
+
+func recordMaintenanceAudit(cfg *config.Config, state maintenance.State) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	dbContainer, err := database.Open(ctx, database.Options{Path: filepath.Join(cfg.DataDir, "data.db")})
+	if err != nil {
+		return err
+	}
+	defer dbContainer.Close()
+
+	return audit.NewStore(dbContainer.DB()).Record(ctx, audit.Event{
+		Actor:   state.UpdatedBy,
+		Action:  audit.ActionMaintenance,
+		Target:  "system",
+		Outcome: audit.OutcomeSuccess,
+		Detail:  fmt.Sprintf("enabled=%t retry_after=%d", state.Enabled, state.RetryAfterSeconds),
+	})
+}
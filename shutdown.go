@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"main/internal/database"
+)
+
+// gracefulServer http.Server 与 http3.Server 共有的关闭方法
+type gracefulServer interface {
+	Shutdown(ctx context.Context) error
+	Close() error
+}
+
+// shutdownServers 并行关闭所有服务器：停止接受新连接并等待进行中的请求完成，超过 timeout 后强制断开剩余连接
+func shutdownServers(timeout time.Duration, servers ...gracefulServer) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	errs := make([]error, len(servers))
+	var wg sync.WaitGroup
+	for i, srv := range servers {
+		wg.Go(func() { errs[i] = srv.Shutdown(ctx) })
+	}
+	wg.Wait()
+
+	err := errors.Join(errs...)
+	if errors.Is(err, context.DeadlineExceeded) {
+		slog.Warn("在途请求未能在超时前完成，强制关闭连接", "timeout", timeout.String())
+		for _, srv := range servers {
+			if err := srv.Close(); err != nil {
+				slog.Warn("failed to close http server", "error", err)
+			}
+		}
+		return
+	}
+	if err != nil {
+		slog.Warn("failed to shutdown http server", "error", err)
+		return
+	}
+	slog.Info("HTTP 服务器已停止，在途请求已完成")
+}
+
+// closeDatabase 将 WAL 写回主库后关闭数据库
+func closeDatabase(dbContainer *database.DBContainer) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := dbContainer.Checkpoint(ctx); err != nil {
+		slog.Warn("failed to checkpoint database", "error", err)
+	}
+	if err := dbContainer.Close(); err != nil {
+		slog.Warn("failed to close database", "error", err)
+		return
+	}
+	slog.Info("数据库已关闭", "path", dbContainer.Path())
+}
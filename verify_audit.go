@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"main/internal/audit"
+	"main/internal/config"
+	"main/internal/database"
+)
+
+// runVerifyAudit 实现 verify-audit 子命令：校验审计哈希链与签名检查点，
+// 结果以 JSON 输出到标准输出，断链或出错时返回非零退出码
+func runVerifyAudit() int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	dbContainer, err := database.Open(ctx, database.Options{Path: filepath.Join(cfg.DataDir, "data.db")})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open database: %v\n", err)
+		return 2
+	}
+	defer dbContainer.Close()
+
+	result, err := audit.NewStore(dbContainer.DB()).Verify(ctx, audit.NewCheckpoints(cfg.DataDir))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to verify audit chain: %v\n", err)
+		return 2
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(result)
+
+	if !result.OK {
+		return 1
+	}
+	return 0
+}
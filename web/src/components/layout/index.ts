@@ -1,6 +1,7 @@
 export { default as AppHeader } from './AppHeader.vue'
 export { default as AppSidebar } from './AppSidebar.vue'
 export { default as MainLayout } from './MainLayout.vue'
+export { default as MaintenanceBanner } from './MaintenanceBanner.vue'
 export { default as SidebarFooter } from './SidebarFooter.vue'
 export { default as SidebarHeader } from './SidebarHeader.vue'
 export { default as SidebarNav } from './SidebarNav.vue'
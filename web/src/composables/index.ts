@@ -1,5 +1,6 @@
 export { useTheme, type ThemeMode } from './useTheme'
 export { useLogExport, type LogEntry, type LogExportType } from './useLogExport'
 export { useLogStream, type LogStreamStatus } from './useLogStream'
+export { useMaintenance } from './useMaintenance'
 export { useToast, type ToastController } from './useToast'
 export { useValidatedLocalStorage } from './useValidatedLocalStorage'
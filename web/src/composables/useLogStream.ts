@@ -32,7 +32,7 @@ export function useLogStream(options: UseLogStreamOptions = {}) {
   const authStore = useAuthStore()
   const logs = ref<LogEntry[]>([])
   const status = ref<LogStreamStatus>('connecting')
-  const streamUrl = options.streamUrl ?? '/api/logs/stream?history=0'
+  const streamUrl = options.streamUrl ?? '/api/v1/logs/stream?history=0'
   const historyEndpoint = options.historyEndpoint ?? 'logs/history'
   const maxLogs = options.maxLogs ?? 500
   let eventSource: EventSource | null = null
@@ -94,7 +94,8 @@ export function useLogStream(options: UseLogStreamOptions = {}) {
     if (eventSource) return
     status.value = 'connecting'
     void loadHistory()
-    eventSource = new EventSource(streamUrl)
+    // API 跨域部署时需携带会话 Cookie，同源时该选项无影响
+    eventSource = new EventSource(streamUrl, { withCredentials: true })
 
     eventSource.onopen = () => {
       status.value = 'connected'
@@ -110,6 +111,11 @@ export function useLogStream(options: UseLogStreamOptions = {}) {
       }
     }
 
+    // 服务端正常关闭前发送 shutdown 事件，浏览器按其中的 retry 间隔自动重连
+    eventSource.addEventListener('shutdown', () => {
+      status.value = 'connecting'
+    })
+
     eventSource.onerror = (error) => {
       console.error('SSE 连接错误:', error)
 
@@ -0,0 +1,81 @@
+import { readonly, ref } from 'vue'
+import {
+  maintenancePublicStateSchema,
+  maintenanceStateSchema,
+  type MaintenancePublicState,
+} from '@/types/api'
+import { api, parseWithSchema, resolveApiUrl } from '@/utils'
+
+const RECONNECT_DELAY_MS = 5000
+
+// 维护状态在所有组件间共享，只保持一条推送连接
+const state = ref<MaintenancePublicState | null>(null)
+let eventSource: EventSource | null = null
+let reconnectTimer: ReturnType<typeof setTimeout> | null = null
+let subscribers = 0
+
+async function loadState(): Promise<void> {
+  try {
+    const response = await api.get('maintenance')
+    const payload = await response.json<unknown>()
+    state.value = parseWithSchema(payload, maintenancePublicStateSchema, response.url)
+  } catch (error) {
+    console.error('加载维护状态失败:', error)
+  }
+}
+
+function open(): void {
+  if (eventSource) return
+  eventSource = new EventSource(resolveApiUrl('maintenance/stream'), { withCredentials: true })
+
+  // 连接建立时服务端先推送一次当前状态，之后每次切换推送一次
+  eventSource.addEventListener('maintenance', (event) => {
+    try {
+      state.value = maintenanceStateSchema.parse(JSON.parse(event.data))
+    } catch (error) {
+      console.error('解析维护状态失败:', error)
+    }
+  })
+
+  eventSource.onerror = () => {
+    if (eventSource?.readyState !== EventSource.CLOSED) {
+      return
+    }
+    close()
+    reconnectTimer = setTimeout(() => {
+      reconnectTimer = null
+      if (subscribers > 0) {
+        open()
+      }
+    }, RECONNECT_DELAY_MS)
+  }
+}
+
+function close(): void {
+  eventSource?.close()
+  eventSource = null
+}
+
+export function useMaintenance() {
+  function connect(): void {
+    subscribers += 1
+    if (subscribers === 1) {
+      void loadState()
+      open()
+    }
+  }
+
+  function disconnect(): void {
+    subscribers = Math.max(0, subscribers - 1)
+    if (subscribers > 0) {
+      return
+    }
+    if (reconnectTimer) {
+      clearTimeout(reconnectTimer)
+      reconnectTimer = null
+    }
+    close()
+  }
+
+  return { state: readonly(state), connect, disconnect, refresh: loadState }
+}
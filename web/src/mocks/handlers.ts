@@ -1,20 +1,32 @@
 import { delay, http, HttpResponse, sse } from 'msw'
 import type {
+  ApiErrorResponse,
   DashboardStatsResponse,
   LoginResponse,
   LogsHistoryResponse,
+  MaintenancePublicState,
+  MaintenanceState,
   SessionStatusResponse,
 } from '@/types/api'
 import type { LogEntry } from '@/utils/logs'
 
 interface LoginRequestBody {
-  auth_key: string
+  username: string
+  password: string
 }
 
 const MOCK_MEMORY_TOTAL = 16 * 1024 * 1024 * 1024
 const MOCK_SSE_INTERVAL_MS = 1200
 const MOCK_UPTIME_DAYS = 14
 const MOCK_START_TIME = Math.floor(Date.now() / 1000) - MOCK_UPTIME_DAYS * 24 * 60 * 60
+const MOCK_MAINTENANCE_STATUS: MaintenancePublicState = {
+  enabled: false,
+  retry_after_seconds: 0,
+}
+const MOCK_MAINTENANCE_STATE: MaintenanceState = {
+  ...MOCK_MAINTENANCE_STATUS,
+  updated_at: 0,
+}
 
 function parseBooleanEnv(value: unknown, fallback: boolean): boolean {
   if (typeof value !== 'string') {
@@ -89,6 +101,7 @@ function createDashboardStats(): DashboardStatsResponse {
     memory_total: MOCK_MEMORY_TOTAL,
     memory_percent: memoryPercent,
     start_time: MOCK_START_TIME,
+    panics: {},
   }
 }
 
@@ -105,16 +118,16 @@ function buildLoginSuccessResponse(): LoginResponse {
   return { success: true, message: '登录成功（Mock）' }
 }
 
-function buildLoginInvalidResponse(message: string): LoginResponse {
-  return { success: false, message }
-}
-
 function buildSessionUnauthorizedResponse(): SessionStatusResponse {
-  return { authenticated: false, message: '未授权，请先登录' }
+  return { authenticated: false }
 }
 
 function buildSessionAuthorizedResponse(): SessionStatusResponse {
-  return { authenticated: true }
+  return { authenticated: true, user: { id: 1, username: 'admin', role: 'admin' } }
+}
+
+function buildErrorResponse(code: string, message: string): ApiErrorResponse {
+  return { error: { code, message } }
 }
 
 function requireAuthenticated() {
@@ -122,25 +135,30 @@ function requireAuthenticated() {
     return null
   }
 
-  return HttpResponse.json({ error: '未授权，请先登录' }, { status: 401 })
+  return HttpResponse.json(buildErrorResponse('unauthorized', '未授权，请先登录'), {
+    status: 401,
+  })
 }
 
 export const handlers = [
-  http.post('/api/login', async ({ request }) => {
+  http.post('/api/v1/login', async ({ request }) => {
     await delay(120)
 
     const body = await parseJsonBody<Partial<LoginRequestBody>>(request)
-    const authKey = body?.auth_key?.trim()
+    const username = body?.username?.trim()
+    const password = body?.password
 
-    if (!authKey) {
-      return HttpResponse.json(buildLoginInvalidResponse('认证令牌不能为空'), { status: 400 })
+    if (!username || !password) {
+      return HttpResponse.json(buildErrorResponse('invalid_request', '用户名和密码不能为空'), {
+        status: 400,
+      })
     }
 
     isMockAuthenticated = true
     return HttpResponse.json(buildLoginSuccessResponse())
   }),
 
-  http.get('/api/session', async () => {
+  http.get('/api/v1/session', async () => {
     await delay(60)
 
     if (!isMockAuthenticated) {
@@ -150,13 +168,27 @@ export const handlers = [
     return HttpResponse.json(buildSessionAuthorizedResponse())
   }),
 
-  http.post('/api/logout', async () => {
+  http.get('/api/v1/auth/methods', async () => {
+    await delay(40)
+    return HttpResponse.json({ password: true, oidc: false })
+  }),
+
+  http.post('/api/v1/logout', async () => {
     await delay(80)
     isMockAuthenticated = false
     return HttpResponse.json({ success: true, message: '登出成功（Mock）' })
   }),
 
-  http.get('/api/dashboard/stats', async () => {
+  http.get('/api/v1/maintenance', async () => {
+    await delay(40)
+    return HttpResponse.json(MOCK_MAINTENANCE_STATUS)
+  }),
+
+  sse<{ maintenance: MaintenanceState }>('/api/v1/maintenance/stream', ({ client }) => {
+    client.send({ event: 'maintenance', data: MOCK_MAINTENANCE_STATE })
+  }),
+
+  http.get('/api/v1/dashboard/stats', async () => {
     await delay(100)
 
     const unauthorizedResponse = requireAuthenticated()
@@ -167,7 +199,7 @@ export const handlers = [
     return HttpResponse.json(createDashboardStats())
   }),
 
-  http.get('/api/logs/history', async () => {
+  http.get('/api/v1/logs/history', async () => {
     await delay(100)
 
     const unauthorizedResponse = requireAuthenticated()
@@ -184,7 +216,7 @@ export const handlers = [
     return HttpResponse.json(response)
   }),
 
-  sse('/api/logs/stream', ({ client, request }) => {
+  sse('/api/v1/logs/stream', ({ client, request }) => {
     if (!isMockAuthenticated) {
       client.close()
       return
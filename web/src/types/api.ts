@@ -3,18 +3,55 @@ import { jsonValueSchema } from './zod'
 
 const nonEmptyTrimmedStringSchema = z.string().trim().min(1)
 
+export const apiErrorResponseSchema = z
+  .object({
+    error: z.object({
+      code: nonEmptyTrimmedStringSchema,
+      message: z.string(),
+      request_id: z.string().optional(),
+      details: z
+        .array(z.object({ field: z.string(), code: z.string(), message: z.string() }))
+        .optional(),
+    }),
+  })
+  .describe('ApiErrorResponse')
+
+export type ApiErrorResponse = z.infer<typeof apiErrorResponseSchema>
+
+export type ApiError = ApiErrorResponse['error']
+
 export const loginResponseSchema = z
-  .object({ success: z.boolean(), message: z.string() })
+  .object({ success: z.boolean(), message: z.string(), mfa_required: z.boolean().optional() })
   .describe('LoginResponse')
 
 export type LoginResponse = z.infer<typeof loginResponseSchema>
 
+export const userRoleSchema = z.enum(['admin', 'operator', 'viewer'])
+
+export type UserRole = z.infer<typeof userRoleSchema>
+
+export const sessionUserSchema = z
+  .object({ id: z.int().positive(), username: nonEmptyTrimmedStringSchema, role: userRoleSchema })
+  .describe('SessionUser')
+
+export type SessionUser = z.infer<typeof sessionUserSchema>
+
 export const sessionStatusResponseSchema = z
-  .object({ authenticated: z.boolean(), message: z.string().optional() })
+  .object({
+    authenticated: z.boolean(),
+    user: sessionUserSchema.optional(),
+    csrf_token: z.string().optional(),
+  })
   .describe('SessionStatusResponse')
 
 export type SessionStatusResponse = z.infer<typeof sessionStatusResponseSchema>
 
+export const authMethodsResponseSchema = z
+  .object({ password: z.boolean(), oidc: z.boolean() })
+  .describe('AuthMethodsResponse')
+
+export type AuthMethodsResponse = z.infer<typeof authMethodsResponseSchema>
+
 export const logoutResponseSchema = z
   .object({ success: z.boolean(), message: z.string() })
   .describe('LogoutResponse')
@@ -27,6 +64,8 @@ export const dashboardStatsResponseSchema = z
     memory_total: z.number().positive(),
     memory_percent: z.number().nonnegative().lte(100),
     start_time: z.int().positive(),
+    // 进程启动以来各路由被恢复的 panic 次数，键为“方法 路由模板”
+    panics: z.record(z.string(), z.int().nonnegative()),
   })
   .refine((value) => value.memory_used <= value.memory_total, {
     path: ['memory_used'],
@@ -36,17 +75,40 @@ export const dashboardStatsResponseSchema = z
 
 export type DashboardStatsResponse = z.infer<typeof dashboardStatsResponseSchema>
 
+// 匿名可读的维护状态，不含操作者与切换时间
+export const maintenancePublicStateSchema = z
+  .object({
+    enabled: z.boolean(),
+    // 管理员填写的说明，为空时前端显示默认文案
+    message: z.string().optional(),
+    retry_after_seconds: z.int().nonnegative(),
+  })
+  .describe('MaintenancePublicState')
+
+export type MaintenancePublicState = z.infer<typeof maintenancePublicStateSchema>
+
+export const maintenanceStateSchema = maintenancePublicStateSchema
+  .extend({
+    updated_at: z.int().nonnegative(),
+    updated_by: z.string().optional(),
+  })
+  .describe('MaintenanceState')
+
+export type MaintenanceState = z.infer<typeof maintenanceStateSchema>
+
 const logEntryBaseSchema = z.object({
   time: nonEmptyTrimmedStringSchema,
   level: nonEmptyTrimmedStringSchema,
   msg: z.string(),
+  // 由 HTTP 请求产生的日志携带请求 ID，用于按请求归组
+  request_id: z.string().optional(),
   attrs: z.record(z.string(), jsonValueSchema).optional(),
 })
 
 export const logEntrySchema = logEntryBaseSchema
   .transform((value) => {
     if (value.attrs && Object.keys(value.attrs).length === 0) {
-      return { time: value.time, level: value.level, msg: value.msg }
+      return { time: value.time, level: value.level, msg: value.msg, request_id: value.request_id }
     }
     return value
   })
@@ -1,14 +1,18 @@
 import ky, { HTTPError, type Options } from 'ky'
 import { ZodError, z, type ZodType } from 'zod'
+import { apiErrorResponseSchema, type ApiError } from '@/types/api'
 
-const DEFAULT_API_BASE_URL = '/api'
+const DEFAULT_API_BASE_URL = '/api/v1'
 const DEFAULT_TIMEOUT_MS = 30_000
+const CSRF_HEADER_NAME = 'X-CSRF-Token'
+const CSRF_SAFE_METHODS = new Set(['GET', 'HEAD', 'OPTIONS'])
 
 type UnauthorizedHandler = () => void | Promise<void>
 
 let unauthorizedHandler: UnauthorizedHandler | null = null
 let unauthorizedTask: Promise<void> | null = null
 let redirectingToLogin = false
+let csrfToken: string | null = null
 
 const API_BASE_URL = resolveApiBaseUrl(import.meta.env.VITE_API_BASE_URL)
 
@@ -126,7 +130,25 @@ export const api = ky.create({
   retry: 0,
   timeout: DEFAULT_TIMEOUT_MS,
   hooks: {
+    beforeRequest: [
+      (request) => {
+        // 服务端按 Accept-Language 返回错误文案，与页面语言保持一致
+        if (typeof document !== 'undefined' && document.documentElement.lang) {
+          request.headers.set('Accept-Language', document.documentElement.lang)
+        }
+        if (csrfToken && !CSRF_SAFE_METHODS.has(request.method.toUpperCase())) {
+          request.headers.set(CSRF_HEADER_NAME, csrfToken)
+        }
+      },
+    ],
     afterResponse: [
+      (_request, _options, response) => {
+        const nextToken = response.headers.get(CSRF_HEADER_NAME)
+        if (nextToken) {
+          csrfToken = nextToken
+        }
+        return response
+      },
       async (_request, options, response) => {
         if (shouldSkipUnauthorizedHandler(options) || response.status !== 401) {
           return response
@@ -201,6 +223,12 @@ export async function readHttpErrorData(error: HTTPError): Promise<unknown | nul
   }
 }
 
+// readApiError 解析统一错误响应 {"error": {"code", "message", ...}}，格式不符时返回 null
+export async function readApiError(error: HTTPError): Promise<ApiError | null> {
+  const parsed = apiErrorResponseSchema.safeParse(await readHttpErrorData(error))
+  return parsed.success ? parsed.data.error : null
+}
+
 export function setUnauthorizedHandler(handler: UnauthorizedHandler | null): void {
   unauthorizedHandler = handler
 }
@@ -224,16 +252,13 @@ export function normalizeApiEndpoint(endpoint: string): string {
     throw new Error('API endpoint is required')
   }
 
-  if (trimmedEndpoint.startsWith('/api/')) {
-    return trimmedEndpoint.slice('/api/'.length)
-  }
-  if (trimmedEndpoint.startsWith('api/')) {
-    return trimmedEndpoint.slice('api/'.length)
-  }
-  if (trimmedEndpoint.startsWith('/')) {
-    return trimmedEndpoint.slice(1)
-  }
-  return trimmedEndpoint
+  // 去掉 /api 与版本前缀，统一拼接到 API_BASE_URL 之后
+  const withoutSlash = trimmedEndpoint.startsWith('/') ? trimmedEndpoint.slice(1) : trimmedEndpoint
+  return withoutSlash.replace(/^api\/(v\d+\/)?/, '')
+}
+
+export function resolveApiUrl(endpoint: string): string {
+  return `${API_BASE_URL}/${normalizeApiEndpoint(endpoint)}`
 }
 
 export function getCurrentPath(): string {